@@ -2,25 +2,104 @@ package main
 
 import (
 	"flag"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
 
 	"LogAnalyzer/internal/application"
 	"LogAnalyzer/pkg/logger"
 )
 
+// version - версия программы для отчета, задается при сборке: -ldflags "-X main.version=v1.2.3".
+var version string
+
+// toolVersion - версия из -ldflags, иначе версия модуля из сборки go install, иначе (devel).
+func toolVersion() string {
+	if version != "" {
+		return version
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version
+	}
+
+	return "(devel)"
+}
+
+// sourceList - значения флага, который можно указать несколько раз.
+type sourceList []string
+
+func (s *sourceList) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *sourceList) Set(value string) error {
+	*s = append(*s, value)
+
+	return nil
+}
+
 func main() {
-	source := flag.String("sourcegetters", "", "path or URL")
+	var sources sourceList
+
+	flag.Var(&sources, "sourcegetters", "path, glob, URL or - for stdin; repeat the flag or list more sources after flags")
 	from := flag.String("from", "", "lower time bound in ISO 8601")
 	to := flag.String("to", "", "upper time bound")
-	format := flag.String("format", "markdown", "markdown or adoc")
-	field := flag.String("field", "", "field name for filter")
-	value := flag.String("value", "", "value for filter")
+	format := flag.String("format", "markdown", "markdown, adoc, json or html")
+	filterExpression := flag.String("filter", "", "filter expression, e.g. 'http_code >= 500 && resource =~ \"^/api/\"'")
+	logFormat := flag.String("logformat", "combined", "registered log format name or custom log_format string")
+	logSyntax := flag.String("logsyntax", "nginx", "syntax of custom log format string")
+	top := flag.String("top", "", "ranking size: N for all sections and/or section=N (requests, resources, codes, slowest, clients, ...)")
+	quarantine := flag.String("quarantine", "", "file to write unparsed lines with reasons to")
+	clientsBy := flag.String("clientsby", "requests", "rank top clients by requests or bytes")
+	userAgentDB := flag.String("uadb", "", "user agent regex database in JSON, embedded database by default")
+	geoDB := flag.String("geodb", "", "comma separated MaxMind .mmdb databases (GeoLite2/DB-IP City, Country, ASN) for geo enrichment")
+	pathTemplates := flag.Bool("pathtemplates", true, "collapse numeric ids, UUIDs and hashes in paths into {id}, {uuid}, {hash}")
+	rewrite := flag.String("rewrite", "", "file with path rewrite rules, one 'regex replacement' per line")
+	bySource := flag.Bool("bysource", false, "add per-source breakdown to the report")
+	follow := flag.Bool("follow", false, "follow growing files across rotation and refresh the report until Ctrl+C")
+	state := flag.String("state", "", "state file for incremental runs: parse only lines appended since the previous run")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "number of files parsed concurrently")
+	maxLine := flag.Int("maxline", 1<<20, "max line length in bytes, 0 - no limit")
+	longLines := flag.String("longlines", application.LongLinesSkip, "lines longer than maxline: skip or truncate")
+	refresh := flag.Duration("refresh", 10*time.Second, "report refresh interval in follow mode")
+	bucket := flag.String("bucket", "auto", "time series interval: auto, 1m, 5m, 1h, 1d")
+	percentiles := flag.String("percentiles", "50,90,95,99,99.9", "response size percentiles to report, comma separated")
 
 	flag.Parse()
 
+	sources = append(sources, flag.Args()...)
+
 	fileLogger := logger.NewFileLogger("logs.txt")
 
 	defer fileLogger.Close()
 	app := application.NewApp(fileLogger.Logger())
 
-	app.Start(source, from, to, format, field, value)
+	app.Start(&application.Config{
+		Sources:       sources,
+		From:          *from,
+		To:            *to,
+		Format:        *format,
+		Filter:        *filterExpression,
+		LogFormat:     *logFormat,
+		LogSyntax:     *logSyntax,
+		Top:           *top,
+		Quarantine:    *quarantine,
+		Percentiles:   *percentiles,
+		Bucket:        *bucket,
+		ClientsBy:     *clientsBy,
+		UserAgentDB:   *userAgentDB,
+		GeoDB:         *geoDB,
+		PathTemplates: *pathTemplates,
+		Rewrite:       *rewrite,
+		BySource:      *bySource,
+		Follow:        *follow,
+		Refresh:       *refresh,
+		State:         *state,
+		Workers:       *workers,
+		MaxLineLength: *maxLine,
+		LongLines:     *longLines,
+		Version:       toolVersion(),
+	})
 }
@@ -1,48 +1,138 @@
 package application
 
 import (
-	"bufio"
+	stderrors "errors"
+	"io"
 	"log/slog"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/endpoint"
 	"LogAnalyzer/internal/domain/errors"
+	"LogAnalyzer/internal/domain/filter"
+	"LogAnalyzer/internal/domain/logformats"
 	"LogAnalyzer/internal/domain/reporters"
 	"LogAnalyzer/internal/domain/sourcegetters"
+	"LogAnalyzer/internal/domain/useragent"
 	"LogAnalyzer/internal/infrastructure"
 )
 
+// reportName - имя файла отчета без расширения.
+const reportName = "LogAnalyzerReport"
+
+// Что делать со строками длиннее ограничения: не разбирать и учесть как нераспаршенные или разобрать начало строки.
+const (
+	LongLinesSkip     = "skip"
+	LongLinesTruncate = "truncate"
+)
+
 type SourceGetter interface {
 	FilePaths() ([]string, error)
 }
 
+// sourceLabeler - источники, у которых имя в отчете отличается от пути к файлу, например ссылка или стандартный ввод.
+type sourceLabeler interface {
+	Label(path string) string
+}
+
 type Reporter interface {
 	Build(s *domain.Statistic, filepath string) (err error)
 }
 
+// Config - параметры запуска приложения, заполняются из флагов командной строки.
+type Config struct {
+	// Sources - пути, шаблоны путей, ссылки или "-" для стандартного ввода, результаты сливаются в один отчет.
+	Sources []string
+	From    string
+	To      string
+	Format  string
+	// Filter - выражение для отбора записей, например `http_code >= 500 && resource =~ "^/api/"`.
+	Filter string
+	// LogFormat - имя зарегистрированного формата логов или собственная строка формата.
+	LogFormat string
+	// LogSyntax - синтаксис собственной строки формата, например nginx.
+	LogSyntax string
+	// Top - размер рейтингов: число для всех секций и/или секция=число, например "10,resources=50".
+	Top string
+	// Quarantine - путь к файлу, куда пишутся нераспаршенные строки, пустая строка - не писать.
+	Quarantine string
+	// Percentiles - перцентили размера ответа в процентах через запятую, например "50,90,99.9".
+	Percentiles string
+	// Bucket - размер интервала временного ряда: auto, 1m, 5m, 1h, 1d.
+	Bucket string
+	// ClientsBy - как ранжировать топ клиентов: requests или bytes.
+	ClientsBy string
+	// UserAgentDB - путь к своей базе регулярных выражений User-Agent, пустая строка - встроенная база.
+	UserAgentDB string
+	// GeoDB - пути к базам MaxMind .mmdb через запятую, пустая строка - без геолокации.
+	GeoDB string
+	// BySource - добавить в отчет разбивку по источникам.
+	BySource bool
+	// PathTemplates - сворачивать идентификаторы в путях в шаблоны эндпоинтов.
+	PathTemplates bool
+	// Rewrite - путь к файлу правил переписывания путей, пустая строка - без правил.
+	Rewrite string
+	// Follow - следить за дописываемыми файлами и периодически перестраивать отчет до SIGINT или SIGTERM.
+	Follow bool
+	// Refresh - как часто перестраивать отчет в режиме слежения.
+	Refresh time.Duration
+	// State - путь к файлу состояния: разбираются только строки, дописанные после прошлого запуска,
+	// а отчет строится по всем накопленным данным. Пустая строка - без состояния.
+	State string
+	// Workers - сколько файлов разбирается одновременно.
+	Workers int
+	// MaxLineLength - ограничение длины строки в байтах, 0 - без ограничения.
+	MaxLineLength int
+	// LongLines - что делать со строками длиннее MaxLineLength: skip или truncate.
+	LongLines string
+	// Version - версия программы для отчета.
+	Version string
+}
+
 type Application struct {
 	FilePaths     []string
-	Source        SourceGetter
+	Sources       []SourceGetter
 	Reporter      Reporter
+	Parser        domain.LineParser
 	RawData       *domain.DataHolder
 	Statistics    *domain.Statistic
 	timeFrom      time.Time
 	timeTo        time.Time
 	logger        *slog.Logger
 	OutputHandler *infrastructure.Output
+	quarantine    *infrastructure.Quarantine
+	enrichers     []domain.Enricher
+	geoIP         *infrastructure.GeoIP
+	// labels - имена источников в отчете для файлов, у которых оно отличается от пути.
+	labels map[string]string
+	// refresh - интервал перестроения отчета в режиме слежения, 0 - режим слежения выключен.
+	refresh time.Duration
+	// state - отметки прочитанного по файлам, nil если файл состояния не задан.
+	state *infrastructure.State
+	// filter - фильтр записей, nil - без фильтрации, нужен чтобы создавать DataHolder для каждого обработчика.
+	filter  domain.Filter
+	workers int
+	// throughput - сколько строк и байт разобрано, для отладочного лога.
+	throughput throughput
+	// maxLineLength и truncateLines - ограничение длины строки и обрезать ли длинные строки вместо пропуска.
+	maxLineLength int
+	truncateLines bool
 }
 
 func NewApp(logger *slog.Logger) *Application {
 	return &Application{logger: logger}
 }
 
-func (a *Application) Start(source, from, to, format, field, value *string) {
+func (a *Application) Start(cfg *Config) {
 	a.logger.Info("Starting application")
 
-	if err := a.setUp(source, from, to, format, field, value); err != nil {
+	if err := a.setUp(cfg); err != nil {
 		a.logger.Error("Error occurred in SetUp", "error", err)
 
 		return
@@ -50,51 +140,82 @@ func (a *Application) Start(source, from, to, format, field, value *string) {
 
 	a.logger.Info("SetUp went successfully")
 
-	files, err := a.Source.FilePaths()
-	if err != nil {
+	if a.quarantine != nil {
+		defer a.closeQuarantine()
+	}
+
+	if a.geoIP != nil {
+		defer a.closeGeoIP()
+	}
+
+	defer a.closeSources()
+
+	if err := a.collectFiles(); err != nil {
 		a.logger.Error("Error occurred in source getter", "error", err)
 		a.OutputHandler.Write("Some error occurred opening source files!")
 
 		return
 	}
 
-	a.FilePaths = files
+	if a.refresh > 0 {
+		a.follow()
 
-	for _, logSource := range a.FilePaths {
-		a.ProcessData(logSource)
+		return
 	}
 
+	a.processFiles()
+
 	if a.RawData == nil {
 		a.OutputHandler.Write("No data were parsed from sources")
 		return
 	}
 
-	a.Statistics.Fill(a.RawData)
+	a.fillStatistics()
 
-	err = a.Reporter.Build(a.Statistics, "LogAnalyzerReport")
-	if err != nil {
+	if err := a.Reporter.Build(a.Statistics, reportName); err != nil {
 		a.OutputHandler.Write("Error reporting builder occurred")
 		return
 	}
+
+	if a.state != nil {
+		a.saveState()
+	}
+}
+
+// fillStatistics - считает статистику по накопленным данным и предупреждает, если интервал временного ряда
+// пришлось увеличить.
+func (a *Application) fillStatistics() {
+	a.Statistics.Fill(a.RawData)
+
+	if timeline := a.Statistics.Timeline; timeline.Requested > 0 {
+		a.logger.Warn("Timeline bucket widened to limit the number of intervals", "requested", timeline.Requested,
+			"bucket", timeline.Bucket, "limit", domain.MaxTimelineBuckets)
+	}
 }
 
 // setUp - позволяет провести настройку параметров приложения.
-func (a *Application) setUp(source, from, to, format, field, value *string) error {
+func (a *Application) setUp(cfg *Config) error {
 	a.OutputHandler = infrastructure.NewWriter(os.Stdout, a.logger)
 
-	if *source == "" {
+	if len(cfg.Sources) == 0 {
 		a.OutputHandler.Write("Source is required")
 
 		return errors.ErrNoSource{}
 	}
 
-	if err := a.validateSource(*source); err != nil {
-		a.OutputHandler.Write("Source validation error")
+	for _, source := range cfg.Sources {
+		if err := a.validateSource(source); err != nil {
+			a.OutputHandler.Write("Source validation error:", source)
 
+			return err
+		}
+	}
+
+	if err := a.setUpFollow(cfg); err != nil {
 		return err
 	}
 
-	timeFrom, timeTo, err := a.validateTime(*from, *to)
+	timeFrom, timeTo, err := a.validateTime(cfg.From, cfg.To)
 	if err != nil {
 		return err
 	}
@@ -102,37 +223,368 @@ func (a *Application) setUp(source, from, to, format, field, value *string) erro
 	a.timeTo = timeTo
 	a.timeFrom = timeFrom
 
-	fieldToFilter, valueToFilter := a.validateFilter(*field, *value)
+	parser, err := logformats.Resolve(cfg.LogSyntax, cfg.LogFormat)
+	if err != nil {
+		a.OutputHandler.Write("Log format error:", err)
+
+		return err
+	}
+
+	a.Parser = parser
+
+	if err = a.setUpEnrichers(cfg); err != nil {
+		return err
+	}
+
+	logFilter, err := a.validateFilter(cfg.Filter)
+	if err != nil {
+		return err
+	}
+
+	if err = a.setUpStatistics(cfg); err != nil {
+		return err
+	}
+
+	if err = a.setUpReading(cfg); err != nil {
+		return err
+	}
+
+	a.filter = logFilter
+
+	if cfg.Quarantine != "" {
+		a.quarantine, err = infrastructure.NewQuarantine(cfg.Quarantine, a.logger)
+		if err != nil {
+			a.OutputHandler.Write("Quarantine file error:", err)
+
+			return err
+		}
+	}
+
+	a.RawData = a.newDataHolder()
 
-	a.RawData = domain.NewDataHolder(fieldToFilter, valueToFilter)
-	a.Statistics = &domain.Statistic{}
-	a.Reporter = a.validateFormat(*format)
+	if err = a.setUpState(cfg); err != nil {
+		return err
+	}
+
+	a.Reporter = a.validateFormat(cfg.Format)
 
 	return nil
 }
 
-// validateFilter - позволяет обработать флаги для фильтрации логов по значению поля.
-func (a *Application) validateFilter(field, value string) (fieldToFilter, valueToFilter string) {
-	if field == "" || value == "" {
-		return "", ""
+// setUpFollow - проверяет настройки режима слежения: следить можно только за файлами.
+func (a *Application) setUpFollow(cfg *Config) error {
+	if !cfg.Follow {
+		return nil
 	}
 
-	_, ok := domain.FilterIndices[field]
+	if cfg.Refresh <= 0 {
+		a.OutputHandler.Write("Refresh setting error:", errors.ErrInvalidRefresh{})
 
-	if ok {
-		return field, value
+		return errors.ErrInvalidRefresh{}
+	}
+
+	for i, source := range a.Sources {
+		if _, ok := source.(*sourcegetters.GetFile); !ok {
+			err := errors.ErrFollowSource{Source: cfg.Sources[i]}
+			a.OutputHandler.Write("Follow mode error:", err)
+
+			return err
+		}
 	}
 
-	return "", ""
+	a.refresh = cfg.Refresh
+
+	return nil
+}
+
+// setUpReading - проверяет число обработчиков и ограничение длины строки.
+func (a *Application) setUpReading(cfg *Config) error {
+	if cfg.Workers < 1 {
+		a.OutputHandler.Write("Workers setting error:", errors.ErrInvalidWorkers{})
+
+		return errors.ErrInvalidWorkers{}
+	}
+
+	if cfg.MaxLineLength < 0 {
+		a.OutputHandler.Write("Max line length error:", errors.ErrInvalidMaxLine{})
+
+		return errors.ErrInvalidMaxLine{}
+	}
+
+	switch cfg.LongLines {
+	case "", LongLinesSkip, LongLinesTruncate:
+	default:
+		a.OutputHandler.Write("Long lines setting error:", errors.ErrInvalidLongLines{})
+
+		return errors.ErrInvalidLongLines{}
+	}
+
+	a.workers, a.maxLineLength, a.truncateLines = cfg.Workers, cfg.MaxLineLength, cfg.LongLines == LongLinesTruncate
+
+	return nil
+}
+
+// setUpStatistics - проверяет настройки отчета: размеры рейтингов, перцентили, интервал временного ряда
+// и ранжирование клиентов.
+func (a *Application) setUpStatistics(cfg *Config) error {
+	topLimits, err := a.validateTop(cfg.Top)
+	if err != nil {
+		a.OutputHandler.Write("Top setting error:", err)
+
+		return err
+	}
+
+	percentiles, err := a.validatePercentiles(cfg.Percentiles)
+	if err != nil {
+		a.OutputHandler.Write("Percentiles setting error:", err)
+
+		return err
+	}
+
+	bucket, err := a.validateBucket(cfg.Bucket)
+	if err != nil {
+		a.OutputHandler.Write("Bucket setting error:", err)
+
+		return err
+	}
+
+	if cfg.ClientsBy != "" && cfg.ClientsBy != domain.ClientsByRequests && cfg.ClientsBy != domain.ClientsByBytes {
+		a.OutputHandler.Write("Clients ranking error:", errors.ErrInvalidClientsBy{})
+
+		return errors.ErrInvalidClientsBy{}
+	}
+
+	a.Statistics = &domain.Statistic{
+		Top: topLimits, Percentiles: percentiles, Bucket: bucket, ClientsBy: cfg.ClientsBy, BySource: cfg.BySource,
+		Run: domain.RunInfo{
+			Version: cfg.Version, Sources: cfg.Sources, LogFormat: cfg.LogFormat, LogSyntax: cfg.LogSyntax,
+			Filter: cfg.Filter, From: a.timeFrom, To: a.timeTo,
+		},
+	}
+
+	return nil
+}
+
+// setUpEnrichers - включает геолокацию по базам MaxMind, если они заданы, шаблоны эндпоинтов и классификатор
+// User-Agent, если формат логов содержит $http_user_agent.
+func (a *Application) setUpEnrichers(cfg *Config) error {
+	if err := a.setUpGeoIP(cfg.GeoDB); err != nil {
+		return err
+	}
+
+	if err := a.setUpTemplates(cfg); err != nil {
+		return err
+	}
+
+	if !domain.HasField(a.Parser.Fields(), domain.HTTPUserAgent) {
+		return nil
+	}
+
+	if cfg.UserAgentDB == "" {
+		a.enrichers = append(a.enrichers, useragent.Default())
+
+		return nil
+	}
+
+	data, err := os.ReadFile(cfg.UserAgentDB)
+	if err != nil {
+		a.OutputHandler.Write("User agent database error:", err)
+
+		return err
+	}
+
+	classifier, err := useragent.Load(data)
+	if err != nil {
+		a.OutputHandler.Write("User agent database error:", err)
+
+		return err
+	}
+
+	a.enrichers = append(a.enrichers, classifier)
+
+	return nil
+}
+
+// setUpTemplates - если формат логов содержит путь запроса, раскладывает его на путь и строку запроса
+// и включает шаблоны эндпоинтов.
+func (a *Application) setUpTemplates(cfg *Config) error {
+	if !domain.HasField(a.Parser.Fields(), domain.Resource) {
+		return nil
+	}
+
+	var rules []endpoint.Rule
+
+	if cfg.Rewrite != "" {
+		data, err := os.ReadFile(cfg.Rewrite)
+		if err != nil {
+			a.OutputHandler.Write("Rewrite rules error:", err)
+
+			return err
+		}
+
+		if rules, err = endpoint.ParseRules(data); err != nil {
+			a.OutputHandler.Write("Rewrite rules error:", err)
+
+			return err
+		}
+	}
+
+	a.enrichers = append(a.enrichers, endpoint.New(rules, cfg.PathTemplates))
+
+	return nil
+}
+
+// setUpGeoIP - открывает базы MaxMind, для геолокации формат логов должен содержать $remote_addr.
+func (a *Application) setUpGeoIP(geoDB string) error {
+	var paths []string
+
+	for _, path := range strings.Split(geoDB, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	if len(paths) == 0 {
+		return nil
+	}
+
+	if !domain.HasField(a.Parser.Fields(), domain.RemoteAddr) {
+		a.OutputHandler.Write("Geo database error: log format has no remote_addr")
+
+		return errors.ErrGeoDatabase{Path: geoDB, Reason: "log format has no remote_addr"}
+	}
+
+	geoIP, err := infrastructure.OpenGeoIP(paths)
+	if err != nil {
+		a.OutputHandler.Write("Geo database error:", err)
+
+		return err
+	}
+
+	a.geoIP = geoIP
+	a.enrichers = append(a.enrichers, geoIP)
+
+	return nil
+}
+
+// validateFilter - компилирует выражение фильтра. Выражение может ссылаться только на поля, объявленные в формате логов,
+// и поля обогащений, в случае ошибки выводит выражение и указывает на место ошибки.
+func (a *Application) validateFilter(expression string) (domain.Filter, error) {
+	if strings.TrimSpace(expression) == "" {
+		return nil, nil
+	}
+
+	fields := slices.Clone(a.Parser.Fields())
+	for _, enricher := range a.enrichers {
+		fields = append(fields, enricher.Fields()...)
+	}
+
+	compiled, err := filter.Parse(expression, fields)
+	if err != nil {
+		a.OutputHandler.Write("Filter error:", err)
+
+		var syntaxErr errors.ErrFilterSyntax
+		if stderrors.As(err, &syntaxErr) {
+			a.OutputHandler.Write("  " + expression)
+			a.OutputHandler.Write("  " + strings.Repeat(" ", syntaxErr.Position) + "^")
+		}
+
+		return nil, err
+	}
+
+	return compiled, nil
+}
+
+// validateTop - разбирает размеры рейтингов: "10" задает размер для всех секций, "resources=50" - для одной секции,
+// значения перечисляются через запятую. Пустая строка - размер по умолчанию.
+func (a *Application) validateTop(top string) (domain.TopLimits, error) {
+	limits := domain.TopLimits{Sections: make(map[string]int)}
+
+	for _, part := range strings.Split(top, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		section, value, hasSection := strings.Cut(part, "=")
+		if !hasSection {
+			section, value = "", part
+		}
+
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			return domain.TopLimits{}, errors.ErrInvalidTop{}
+		}
+
+		switch {
+		case !hasSection:
+			limits.Default = limit
+		case slices.Contains(domain.TopSections, section):
+			limits.Sections[section] = limit
+		default:
+			return domain.TopLimits{}, errors.ErrInvalidTop{}
+		}
+	}
+
+	return limits, nil
+}
+
+// validatePercentiles - разбирает перцентили, заданные в процентах через запятую, и переводит их в доли.
+// Пустая строка - перцентили по умолчанию.
+func (a *Application) validatePercentiles(percentiles string) ([]float64, error) {
+	var quantiles []float64
+
+	for _, part := range strings.Split(percentiles, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		percent, err := strconv.ParseFloat(part, 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return nil, errors.ErrInvalidPercentiles{}
+		}
+
+		quantiles = append(quantiles, percent/100)
+	}
+
+	return quantiles, nil
+}
+
+// validateBucket - разбирает размер интервала временного ряда: целое число минут, часов или дней (5m, 1h, 1d).
+// Пустая строка и auto - размер подбирается по диапазону логов, возвращается 0.
+func (a *Application) validateBucket(bucket string) (time.Duration, error) {
+	bucket = strings.TrimSpace(bucket)
+	if bucket == "" || bucket == "auto" {
+		return 0, nil
+	}
+
+	units := map[byte]time.Duration{'m': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour}
+
+	unit, ok := units[bucket[len(bucket)-1]]
+	if !ok {
+		return 0, errors.ErrInvalidBucket{}
+	}
+
+	count, err := strconv.Atoi(bucket[:len(bucket)-1])
+	if err != nil || count <= 0 {
+		return 0, errors.ErrInvalidBucket{}
+	}
+
+	return time.Duration(count) * unit, nil
 }
 
 // validateFormat Помогает обработать введенный флаг формата, в случае если флаг имеет значение ADoc - функция вернет составитель
-// отчета в формате ADoc, во всех остальных случаях - по умолчанию будет выбрать Markdown, в какой бы значение
-// флаг не был поставлен.
+// отчета в формате ADoc, для json - отчет в формате JSON, для html - отчет одним файлом HTML, во всех остальных случаях -
+// по умолчанию будет выбрать Markdown, в какой бы значение флаг не был поставлен.
 func (a *Application) validateFormat(format string) Reporter {
 	switch format {
 	case "adoc":
 		return &reporters.ReportADoc{}
+	case "json":
+		return &reporters.ReportJSON{}
+	case "html":
+		return &reporters.ReportHTML{}
 	default:
 		return &reporters.ReportMd{}
 	}
@@ -172,9 +624,16 @@ func (a *Application) validateTime(from, to string) (fromTime, toTime time.Time,
 // validateSource - позволяет валидировать источник логов, ожидается либо путь к локальным файлам/паттерн файлов,
 // либо URL.
 func (a *Application) validateSource(source string) error {
+	if source == sourcegetters.Stdin {
+		a.logger.Info("Stdin SourceGetter")
+		a.Sources = append(a.Sources, &sourcegetters.GetStdin{})
+
+		return nil
+	}
+
 	if a.isURL(source) {
 		a.logger.Info("URL SourceGetter")
-		a.Source = &sourcegetters.GetURL{URL: source}
+		a.Sources = append(a.Sources, &sourcegetters.GetURL{URL: source})
 
 		return nil
 	}
@@ -185,7 +644,7 @@ func (a *Application) validateSource(source string) error {
 	}
 
 	a.logger.Info("File SourceGetter")
-	a.Source = &sourcegetters.GetFile{FilePath: source}
+	a.Sources = append(a.Sources, &sourcegetters.GetFile{FilePath: source})
 
 	return nil
 }
@@ -197,17 +656,160 @@ func (a *Application) isURL(path string) bool {
 	return err == nil && (parsedURL.Scheme == "http" || parsedURL.Scheme == "https")
 }
 
-// ProcessData - функция отвечающая за открытие и обработку локального файла с логами по имени файла.
-func (a *Application) ProcessData(fileName string) {
-	file, err := os.Open(fileName)
+// collectFiles - собирает файлы всех источников по порядку. Файл, который нашелся по нескольким шаблонам,
+// разбирается один раз.
+func (a *Application) collectFiles() error {
+	seen := make(map[string]bool)
+	a.labels = make(map[string]string)
+
+	for _, source := range a.Sources {
+		files, err := source.FilePaths()
+		if err != nil {
+			return err
+		}
+
+		labeler, hasLabel := source.(sourceLabeler)
+
+		for _, file := range files {
+			if seen[file] {
+				continue
+			}
+
+			seen[file] = true
+			a.FilePaths = append(a.FilePaths, file)
+
+			if hasLabel {
+				a.labels[file] = labeler.Label(file)
+			}
+
+			a.Statistics.Run.Files = append(a.Statistics.Run.Files, a.label(file))
+		}
+	}
+
+	return nil
+}
+
+// openSource - открывает файл с логами или стандартный ввод.
+func (a *Application) openSource(fileName string) (*infrastructure.LogReader, error) {
+	if fileName == sourcegetters.Stdin {
+		return infrastructure.NewLogReader(os.Stdin)
+	}
+
+	return infrastructure.OpenLog(fileName)
+}
+
+// ProcessData - функция отвечающая за открытие и обработку файла с логами по имени файла, "-" - стандартный ввод.
+// Ошибки открытия и чтения пишутся в лог и попадают в отчет.
+func (a *Application) ProcessData(fileName string, data *domain.DataHolder) {
+	label := a.label(fileName)
+	data.StartSource(label)
+
+	file, err := a.openSource(fileName)
 	if err != nil {
+		a.readError(fileName, data, "Error opening log file", err)
+
 		return
 	}
 
-	scanner := bufio.NewScanner(file)
+	defer a.closeReader(fileName, file)
+
+	a.logger.Info("Processing log file", "file", fileName, "compression", file.Compression)
+
+	if a.state != nil {
+		a.processIncremental(file, label, data)
+
+		return
+	}
+
+	if err := a.parseLines(file, data); err != nil {
+		a.readError(fileName, data, "Error reading log file", err)
+	}
+}
+
+// label - имя источника в отчете для файла.
+func (a *Application) label(fileName string) string {
+	if label, ok := a.labels[fileName]; ok {
+		return label
+	}
+
+	return fileName
+}
+
+// parseLines - разбирает все строки потока в data, возвращает ошибку чтения.
+func (a *Application) parseLines(r io.Reader, data *domain.DataHolder) error {
+	var lines, size int64
+
+	defer func() { a.throughput.add(lines, size) }()
+
+	reader := infrastructure.NewLineReader(r, a.maxLineLength)
+
+	for {
+		line, err := reader.Next()
+		if err != nil {
+			if stderrors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		a.handleLine(line, data)
+
+		lines++
+		size += line.Size
+	}
+}
+
+// handleLine - разбирает строку. Строка длиннее ограничения разбирается по началу или учитывается
+// как нераспаршенная, смотря по настройке.
+func (a *Application) handleLine(line infrastructure.Line, data *domain.DataHolder) {
+	if line.Truncated {
+		if !a.truncateLines {
+			data.RejectLine(line.Text, errors.ErrLineTooLong{})
+
+			return
+		}
+
+		data.CountTruncated()
+	}
+
+	data.Parse(line.Text, a.timeFrom, a.timeTo)
+}
+
+// readError - пишет ошибку открытия или чтения файла в лог и запоминает ее для отчета.
+func (a *Application) readError(fileName string, data *domain.DataHolder, message string, err error) {
+	a.logger.Error(message, "file", fileName, "error", err)
+	data.RecordReadError(err)
+}
+
+// closeReader - закрывает файл лога, ошибка закрытия только логируется.
+func (a *Application) closeReader(fileName string, file io.Closer) {
+	if err := file.Close(); err != nil {
+		a.logger.Error("Error closing log file", "file", fileName, "error", err)
+	}
+}
+
+// closeSources - удаляет временные файлы источников, например ответы, скачанные по ссылке.
+func (a *Application) closeSources() {
+	for _, source := range a.Sources {
+		if closer, ok := source.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				a.logger.Error("Error closing source", "error", err)
+			}
+		}
+	}
+}
+
+// closeQuarantine - закрывает файл карантина, ошибка закрытия только логируется, отчет к этому моменту уже построен.
+func (a *Application) closeQuarantine() {
+	if err := a.quarantine.Close(); err != nil {
+		a.logger.Error("Error closing quarantine file", "error", err)
+	}
+}
 
-	for scanner.Scan() {
-		singleLog := scanner.Text()
-		a.RawData.Parse(singleLog, a.timeFrom, a.timeTo)
+// closeGeoIP - закрывает базы MaxMind, ошибка закрытия только логируется.
+func (a *Application) closeGeoIP() {
+	if err := a.geoIP.Close(); err != nil {
+		a.logger.Error("Error closing geo databases", "error", err)
 	}
 }
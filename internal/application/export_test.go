@@ -0,0 +1,13 @@
+package application
+
+import "testing"
+
+// SetSplitSizes - уменьшает размеры частей файла и пачек строк, чтобы тесты делили маленькие файлы.
+func SetSplitSizes(tb testing.TB, chunk int64, batch int) {
+	tb.Helper()
+
+	oldChunk, oldBatch := minChunkSize, batchSize
+	minChunkSize, batchSize = chunk, batch
+
+	tb.Cleanup(func() { minChunkSize, batchSize = oldChunk, oldBatch })
+}
@@ -0,0 +1,87 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"LogAnalyzer/internal/infrastructure"
+)
+
+// followPollInterval - как часто проверяются новые строки в режиме слежения.
+const followPollInterval = 250 * time.Millisecond
+
+// follow - режим слежения: файлы читаются как tail -F, статистика обновляется по мере появления строк,
+// отчет перестраивается каждые a.refresh. По SIGINT или SIGTERM строки дочитываются и пишется финальный отчет.
+func (a *Application) follow() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	followers := make([]*infrastructure.Follower, len(a.FilePaths))
+	for i, path := range a.FilePaths {
+		followers[i] = infrastructure.NewFollower(path, a.maxLineLength)
+	}
+
+	defer func() {
+		for _, follower := range followers {
+			follower.Close()
+		}
+	}()
+
+	poll := time.NewTicker(followPollInterval)
+	defer poll.Stop()
+
+	refresh := time.NewTicker(a.refresh)
+	defer refresh.Stop()
+
+	a.OutputHandler.Write("Following", len(followers), "file(s), press Ctrl+C to stop")
+
+	for {
+		a.pollFollowers(followers)
+
+		select {
+		case <-ctx.Done():
+			a.pollFollowers(followers)
+			a.render()
+			a.logger.Info("Follow mode stopped")
+
+			return
+		case <-refresh.C:
+			a.render()
+		case <-poll.C:
+		}
+	}
+}
+
+// pollFollowers - разбирает строки, дописанные во все файлы с прошлой проверки.
+func (a *Application) pollFollowers(followers []*infrastructure.Follower) {
+	for i, follower := range followers {
+		a.RawData.StartSource(a.FilePaths[i])
+
+		err := follower.Poll(func(line infrastructure.Line) {
+			a.handleLine(line, a.RawData)
+		})
+		if err != nil {
+			a.readError(a.FilePaths[i], a.RawData, "Error following log file", err)
+		}
+	}
+}
+
+// render - пересчитывает статистику, перестраивает отчет и выводит краткую сводку в терминал.
+func (a *Application) render() {
+	a.fillStatistics()
+
+	if err := a.Reporter.Build(a.Statistics, reportName); err != nil {
+		a.logger.Error("Error building report", "error", err)
+		a.OutputHandler.Write("Error reporting builder occurred")
+
+		return
+	}
+
+	metrics := a.Statistics.LogsMetrics
+	a.OutputHandler.Write(fmt.Sprintf("%s requests: %d, unparsed: %d, errors: %.2f%%", time.Now().Format(time.TimeOnly),
+		metrics.ProcessedLogs, metrics.UnparsedLogs, a.Statistics.ErrorRate))
+}
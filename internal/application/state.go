@@ -0,0 +1,120 @@
+package application
+
+import (
+	"bufio"
+	stderrors "errors"
+	"fmt"
+	"io"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+	"LogAnalyzer/internal/infrastructure"
+)
+
+// setUpState - загружает файл состояния и восстанавливает накопленные в прошлые запуски данные.
+func (a *Application) setUpState(cfg *Config) error {
+	if cfg.State == "" {
+		return nil
+	}
+
+	if cfg.Follow {
+		err := errors.ErrStateFile{Path: cfg.State, Reason: "not supported in follow mode"}
+		a.OutputHandler.Write("State file error:", err)
+
+		return err
+	}
+
+	state, err := infrastructure.LoadState(cfg.State, stateSettings(cfg))
+	if err != nil {
+		a.OutputHandler.Write("State file error:", err)
+
+		return err
+	}
+
+	if len(state.Data) > 0 {
+		if err := a.RawData.UnmarshalBinary(state.Data); err != nil {
+			err := errors.ErrStateFile{Path: cfg.State, Reason: "corrupted: " + err.Error()}
+			a.OutputHandler.Write("State file error:", err)
+
+			return err
+		}
+	}
+
+	a.state = state
+
+	return nil
+}
+
+// stateSettings - настройки, от которых зависят накопленные данные. Размеры рейтингов, перцентили и формат
+// отчета считаются по данным при каждом запуске, поэтому их можно менять между запусками.
+func stateSettings(cfg *Config) string {
+	return fmt.Sprintf("logformat=%q logsyntax=%q filter=%q from=%q to=%q pathtemplates=%t rewrite=%q geodb=%q uadb=%q "+
+		"maxline=%d longlines=%q", cfg.LogFormat, cfg.LogSyntax, cfg.Filter, cfg.From, cfg.To, cfg.PathTemplates, cfg.Rewrite,
+		cfg.GeoDB, cfg.UserAgentDB, cfg.MaxLineLength, cfg.LongLines)
+}
+
+// processIncremental - разбирает только строки, дописанные после прошлого запуска. Недописанная последняя строка
+// остается на следующий запуск.
+func (a *Application) processIncremental(file io.Reader, label string, data *domain.DataHolder) {
+	buffered := bufio.NewReader(file)
+
+	// Ошибку не проверяем: у файла короче FingerprintSize отпечаток снимается со всего содержимого
+	head, _ := buffered.Peek(infrastructure.FingerprintSize)
+	checkpoint, found := a.state.Resume(label, head)
+	skipped := checkpoint
+
+	if found {
+		if _, err := io.CopyN(io.Discard, buffered, checkpoint.Offset); err != nil {
+			a.logger.Warn("Log file is shorter than its checkpoint, skipping it", "file", label, "error", err)
+			a.state.Commit(checkpoint)
+
+			return
+		}
+
+		data.SkipLines(checkpoint.Lines)
+		a.logger.Info("Resuming log file", "file", label, "offset", checkpoint.Offset, "line", checkpoint.Lines)
+	}
+
+	reader := infrastructure.NewLineReader(buffered, a.maxLineLength)
+
+	for {
+		line, err := reader.Next()
+		if err != nil {
+			if !stderrors.Is(err, io.EOF) {
+				a.readError(label, data, "Error reading log file", err)
+			}
+
+			break
+		}
+
+		if !line.Terminated {
+			break
+		}
+
+		checkpoint.Offset += line.Size
+		checkpoint.Lines++
+
+		a.handleLine(line, data)
+	}
+
+	a.state.Commit(checkpoint)
+	a.throughput.add(int64(checkpoint.Lines-skipped.Lines), checkpoint.Offset-skipped.Offset)
+}
+
+// saveState - сохраняет отметки прочитанного и накопленные данные после того, как отчет построен.
+func (a *Application) saveState() {
+	for _, missing := range a.state.Missing() {
+		a.logger.Warn("Log file from the previous run was not found, lines appended to it after that run are skipped",
+			"file", missing.Source)
+	}
+
+	data, err := a.RawData.MarshalBinary()
+	if err == nil {
+		err = a.state.Save(data)
+	}
+
+	if err != nil {
+		a.logger.Error("Error saving state file", "error", err)
+		a.OutputHandler.Write("State file error:", err)
+	}
+}
@@ -0,0 +1,264 @@
+package application
+
+import (
+	stderrors "errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/infrastructure"
+)
+
+// Переменные, а не константы, чтобы тесты могли делить маленькие файлы.
+var (
+	// minChunkSize - части файла меньше этого размера не выделяются: слияние съест выигрыш от параллельного разбора.
+	minChunkSize int64 = 8 << 20
+	// batchSize - сколько байт строк читатель сжатого потока отдает обработчику за раз, пачка может превысить
+	// его на одну строку.
+	batchSize = 4 << 20
+)
+
+// job - задание обработчику: файл целиком, часть файла или пачка строк из потока. Результат отдается в result,
+// результаты сливаются в порядке создания заданий.
+type job struct {
+	run    func(data *domain.DataHolder)
+	result chan *domain.DataHolder
+}
+
+// throughput - счетчики разобранных строк и байт, пополняются обработчиками по окончании задания.
+type throughput struct {
+	lines atomic.Int64
+	bytes atomic.Int64
+}
+
+func (t *throughput) add(lines, bytes int64) {
+	t.lines.Add(lines)
+	t.bytes.Add(bytes)
+}
+
+// newDataHolder - пустой DataHolder с настройками разбора приложения: парсером, фильтром, обогащениями и карантином.
+func (a *Application) newDataHolder() *domain.DataHolder {
+	data := domain.NewDataHolder(a.Parser, a.filter)
+	for _, enricher := range a.enrichers {
+		data.AddEnricher(enricher)
+	}
+
+	if a.quarantine != nil {
+		data.SetQuarantine(a.quarantine)
+	}
+
+	return data
+}
+
+// processFiles - разбирает файлы в a.workers горутин, у каждого задания свой DataHolder. Если файлов меньше,
+// чем обработчиков, файлы делятся: несжатый - на части по границам строк, а сжатый поток читается одной горутиной
+// и раздается обработчикам пачками строк. Результаты сливаются в a.RawData в порядке файлов и частей, поэтому
+// отчет совпадает с последовательным разбором. Заданий в работе не больше 2*a.workers, так память ограничена.
+func (a *Application) processFiles() {
+	started := time.Now()
+	defer a.logThroughput(started)
+
+	// С файлом состояния отметки ставятся по целым файлам, поэтому файлы не делятся
+	split := len(a.FilePaths) < a.workers && a.state == nil
+
+	if a.workers <= 1 || (len(a.FilePaths) <= 1 && !split) {
+		for _, logSource := range a.FilePaths {
+			a.ProcessData(logSource, a.RawData)
+		}
+
+		return
+	}
+
+	jobs := make(chan job)
+	order := make(chan chan *domain.DataHolder, 2*a.workers)
+
+	submit := func(run func(data *domain.DataHolder)) {
+		result := make(chan *domain.DataHolder, 1)
+		order <- result
+		jobs <- job{run: run, result: result}
+	}
+
+	go func() {
+		defer close(order)
+		defer close(jobs)
+
+		for _, logSource := range a.FilePaths {
+			a.scheduleFile(logSource, split, submit)
+		}
+	}()
+
+	var wg sync.WaitGroup
+
+	for range a.workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				data := a.newDataHolder()
+				job.run(data)
+				job.result <- data
+			}
+		}()
+	}
+
+	for result := range order {
+		if err := a.RawData.Merge(<-result); err != nil {
+			a.logger.Error("Error merging parsed data", "error", err)
+		}
+	}
+
+	wg.Wait()
+}
+
+// scheduleFile - создает задания для файла: одно на весь файл, по одному на каждую часть, или по пачкам строк.
+func (a *Application) scheduleFile(fileName string, split bool, submit func(func(*domain.DataHolder))) {
+	if !split {
+		submit(func(data *domain.DataHolder) { a.ProcessData(fileName, data) })
+
+		return
+	}
+
+	chunks, err := infrastructure.SplitFile(fileName, a.workers, minChunkSize)
+	if err == nil && len(chunks) > 1 {
+		a.scheduleChunks(fileName, chunks, submit)
+
+		return
+	}
+
+	a.scheduleBatches(fileName, submit)
+}
+
+// scheduleChunks - задание на каждую часть файла. Чтобы номера нераспаршенных строк совпадали с номерами в файле,
+// обработчик сначала считает строки своей части, а затем ждет, пока посчитают предыдущие. Подсчет строк намного
+// быстрее разбора, а задания берутся по порядку, поэтому ожидание короткое и не может зациклиться.
+func (a *Application) scheduleChunks(fileName string, chunks []infrastructure.Chunk, submit func(func(*domain.DataHolder))) {
+	label := a.label(fileName)
+	lines := make([]int, len(chunks))
+	counted := make([]chan struct{}, len(chunks))
+
+	for i := range counted {
+		counted[i] = make(chan struct{})
+	}
+
+	a.logger.Info("Processing log file in chunks", "file", fileName, "chunks", len(chunks))
+
+	for i, chunk := range chunks {
+		submit(func(data *domain.DataHolder) {
+			data.StartSource(label)
+
+			count, err := infrastructure.CountLines(fileName, chunk)
+			if err != nil {
+				a.readError(fileName, data, "Error counting lines in log file chunk", err)
+			}
+
+			lines[i] = count
+			close(counted[i])
+
+			skip := 0
+
+			for j := range i {
+				<-counted[j]
+				skip += lines[j]
+			}
+
+			data.SkipLines(skip)
+
+			reader, err := infrastructure.OpenChunk(fileName, chunk)
+			if err != nil {
+				a.readError(fileName, data, "Error opening log file chunk", err)
+
+				return
+			}
+
+			defer a.closeReader(fileName, reader)
+
+			if err := a.parseLines(reader, data); err != nil {
+				a.readError(fileName, data, "Error reading log file chunk", err)
+			}
+		})
+	}
+}
+
+// scheduleBatches - читает поток в этой горутине и отдает обработчикам пачками целых строк. Так разбираются
+// сжатые файлы и стандартный ввод, которые нельзя читать с середины: распаковка идет параллельно с разбором.
+// Ошибка открытия или чтения отдается отдельным заданием, чтобы попасть в отчет после уже прочитанных строк.
+func (a *Application) scheduleBatches(fileName string, submit func(func(*domain.DataHolder))) {
+	label := a.label(fileName)
+	line := 0
+
+	fail := func(message string, err error) {
+		start := line
+
+		submit(func(data *domain.DataHolder) {
+			data.StartSource(label)
+			data.SkipLines(start)
+			a.readError(fileName, data, message, err)
+		})
+	}
+
+	file, err := a.openSource(fileName)
+	if err != nil {
+		fail("Error opening log file", err)
+
+		return
+	}
+
+	defer a.closeReader(fileName, file)
+
+	a.logger.Info("Processing log file in batches", "file", fileName, "compression", file.Compression)
+
+	reader := infrastructure.NewLineReader(file, a.maxLineLength)
+
+	var (
+		batch []infrastructure.Line
+		size  int64
+	)
+
+	for {
+		next, err := reader.Next()
+		if err == nil {
+			batch = append(batch, next)
+			size += next.Size
+		}
+
+		if len(batch) > 0 && (err != nil || size >= int64(batchSize)) {
+			lines, start, bytes := batch, line, size
+			line += len(batch)
+
+			submit(func(data *domain.DataHolder) {
+				data.StartSource(label)
+				data.SkipLines(start)
+
+				for _, logLine := range lines {
+					a.handleLine(logLine, data)
+				}
+
+				a.throughput.add(int64(len(lines)), bytes)
+			})
+
+			batch, size = nil, 0
+		}
+
+		if err != nil {
+			if !stderrors.Is(err, io.EOF) {
+				fail("Error reading log file", err)
+			}
+
+			return
+		}
+	}
+}
+
+// logThroughput - пишет в отладочный лог скорость разбора.
+func (a *Application) logThroughput(started time.Time) {
+	elapsed := time.Since(started)
+	lines, size := a.throughput.lines.Load(), a.throughput.bytes.Load()
+
+	a.logger.Debug("Parsing throughput", "files", len(a.FilePaths), "workers", a.workers, "lines", lines, "bytes", size,
+		"elapsed", elapsed, "lines_per_second", int64(float64(lines)/max(elapsed.Seconds(), 1e-9)),
+		"mb_per_second", float64(size)/(1<<20)/max(elapsed.Seconds(), 1e-9))
+}
@@ -0,0 +1,240 @@
+package application_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/application"
+	"LogAnalyzer/internal/domain"
+)
+
+var userAgents = []string{
+	"curl/8.4.0",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+	"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+}
+
+// writeCorpus - создает files файлов по lines строк в формате combined, часть строк не разбирается.
+func writeCorpus(tb testing.TB, dir string, files, lines int) {
+	tb.Helper()
+
+	for file := range files {
+		var buf strings.Builder
+
+		for line := range lines {
+			if line%97 == 0 {
+				buf.WriteString("garbage line\n")
+
+				continue
+			}
+
+			n := file*lines + line
+			fmt.Fprintf(&buf, "10.0.%d.%d - - [17/May/2015:%02d:%02d:%02d +0300] \"GET /users/%d?page=%d HTTP/1.1\" %d %d \"-\" \"%s\"\n",
+				n%7, n%251, 8+file, line/60%60, line%60, n%1000, n%13, []int{200, 304, 404, 500}[n%4], n%5000, userAgents[n%len(userAgents)])
+		}
+
+		require.NoError(tb, os.WriteFile(filepath.Join(dir, fmt.Sprintf("access.log.%d", file)), []byte(buf.String()), 0o600))
+	}
+}
+
+// runApp - запускает приложение в dir, отчет и карантин пишутся туда же, configure меняют настройки по умолчанию.
+func runApp(tb testing.TB, dir string, workers int, configure ...func(cfg *application.Config)) *application.Application {
+	tb.Helper()
+
+	wd, err := os.Getwd()
+	require.NoError(tb, err)
+	require.NoError(tb, os.Chdir(dir))
+
+	defer func() { require.NoError(tb, os.Chdir(wd)) }()
+
+	stdout := os.Stdout
+	os.Stdout, err = os.Open(os.DevNull)
+	require.NoError(tb, err)
+
+	defer func() { os.Stdout.Close(); os.Stdout = stdout }()
+
+	cfg := &application.Config{
+		Sources:       []string{filepath.Join(dir, "access.log.*")},
+		Format:        "markdown",
+		LogFormat:     "combined",
+		LogSyntax:     "nginx",
+		Bucket:        "auto",
+		Quarantine:    "quarantine.jsonl",
+		PathTemplates: true,
+		BySource:      true,
+		Workers:       workers,
+	}
+
+	for _, apply := range configure {
+		apply(cfg)
+	}
+
+	app := application.NewApp(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	app.Start(cfg)
+
+	return app
+}
+
+// Запускать с -race: обработчики используют общие обогащения и карантин.
+func TestStart_WorkersMatchSerial(t *testing.T) {
+	dir := t.TempDir()
+	writeCorpus(t, dir, 8, 500)
+
+	serial := runApp(t, dir, 1)
+	parallel := runApp(t, dir, 4)
+
+	require.NotNil(t, serial.Statistics)
+	assert.Equal(t, 8*500, serial.Statistics.LogsMetrics.ProcessedLogs+serial.Statistics.LogsMetrics.UnparsedLogs)
+	assert.Equal(t, serial.Statistics, parallel.Statistics)
+}
+
+// quarantined - строки карантина в отсортированном виде: обработчики пишут их в разном порядке.
+func quarantined(t *testing.T, dir string) []string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, "quarantine.jsonl"))
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	slices.Sort(lines)
+
+	return lines
+}
+
+func TestStart_SplitFileMatchesSerial(t *testing.T) {
+	application.SetSplitSizes(t, 16<<10, 8<<10)
+
+	plain := t.TempDir()
+	writeCorpus(t, plain, 1, 3000)
+
+	data, err := os.ReadFile(filepath.Join(plain, "access.log.0"))
+	require.NoError(t, err)
+
+	// Последняя строка без перевода строки тоже должна разобраться
+	require.NoError(t, os.WriteFile(filepath.Join(plain, "access.log.0"), bytes.TrimSuffix(data, []byte("\n")), 0o600))
+
+	compressed := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(compressed, "access.log.0.gz"), gzipped(t, data), 0o600))
+
+	for _, dir := range []string{plain, compressed} {
+		serial := runApp(t, dir, 1)
+		serialRejected := quarantined(t, dir)
+
+		parallel := runApp(t, dir, 4)
+
+		require.NotNil(t, serial.Statistics)
+		assert.Equal(t, 3000, serial.Statistics.LogsMetrics.ProcessedLogs+serial.Statistics.LogsMetrics.UnparsedLogs)
+		assert.Equal(t, serial.Statistics, parallel.Statistics)
+		assert.Equal(t, serialRejected, quarantined(t, dir), "line numbers of rejected lines")
+	}
+}
+
+// gzipped - содержимое, сжатое gzip.
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	writer := gzip.NewWriter(&buf)
+	_, err := writer.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return buf.Bytes()
+}
+
+func TestStart_LongLines(t *testing.T) {
+	application.SetSplitSizes(t, 16<<10, 8<<10)
+
+	dir := t.TempDir()
+	writeCorpus(t, dir, 1, 3000)
+
+	data, err := os.ReadFile(filepath.Join(dir, "access.log.0"))
+	require.NoError(t, err)
+
+	// Строка длиннее буфера bufio.Scanner раньше обрывала разбор файла
+	long := fmt.Sprintf("10.0.0.1 - - [17/May/2015:08:00:00 +0300] \"GET /%s HTTP/1.1\" 200 1 \"-\" \"curl\"\n",
+		strings.Repeat("a", 200<<10))
+	cut := bytes.IndexByte(data, '\n') + 1
+	data = append(append(slices.Clone(data[:cut]), long...), data[cut:]...)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "access.log.0"), gzipped(t, data), 0o600))
+
+	for _, mode := range []string{application.LongLinesSkip, application.LongLinesTruncate} {
+		limit := func(cfg *application.Config) { cfg.MaxLineLength, cfg.LongLines = 1<<10, mode }
+
+		serial := runApp(t, dir, 1, limit)
+		parallel := runApp(t, dir, 4, limit)
+
+		require.NotNil(t, serial.Statistics, mode)
+
+		metrics := serial.Statistics.LogsMetrics
+		assert.Equal(t, 3001, metrics.ProcessedLogs+metrics.UnparsedLogs, mode)
+		assert.Equal(t, serial.Statistics, parallel.Statistics, mode)
+
+		if mode == application.LongLinesSkip {
+			assert.Contains(t, serial.Statistics.ParseQuality.Reasons, domain.KeyCount{Value: "line_too_long", Count: 1})
+			assert.Zero(t, serial.Statistics.ParseQuality.Truncated)
+		} else {
+			assert.Equal(t, 1, serial.Statistics.ParseQuality.Truncated)
+		}
+	}
+}
+
+func TestStart_ReadErrors(t *testing.T) {
+	application.SetSplitSizes(t, 16<<10, 8<<10)
+
+	dir := t.TempDir()
+	writeCorpus(t, dir, 1, 3000)
+
+	data, err := os.ReadFile(filepath.Join(dir, "access.log.0"))
+	require.NoError(t, err)
+
+	// Оборванный архив: строки до обрыва разбираются, ошибка чтения попадает в отчет
+	compressed := gzipped(t, data)
+	path := filepath.Join(dir, "access.log.0")
+	require.NoError(t, os.WriteFile(path, compressed[:len(compressed)/2], 0o600))
+
+	serial := runApp(t, dir, 1)
+	parallel := runApp(t, dir, 4)
+
+	require.NotNil(t, serial.Statistics)
+
+	readErrors := serial.Statistics.ParseQuality.ReadErrors
+	require.Len(t, readErrors, 1)
+	assert.Equal(t, path, readErrors[0].Source)
+	assert.Equal(t, serial.Statistics.LogsMetrics.ProcessedLogs+serial.Statistics.LogsMetrics.UnparsedLogs, readErrors[0].Line)
+	assert.Positive(t, readErrors[0].Line)
+	assert.Equal(t, serial.Statistics, parallel.Statistics)
+
+	report, err := os.ReadFile(filepath.Join(dir, "LogAnalyzerReport.md"))
+	require.NoError(t, err)
+	assert.Contains(t, string(report), "Ошибки чтения")
+}
+
+func BenchmarkStart_Workers(b *testing.B) {
+	dir := b.TempDir()
+	writeCorpus(b, dir, 16, 20000)
+
+	for _, workers := range []int{1, 4, 2 * runtime.GOMAXPROCS(0)} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				runApp(b, dir, workers)
+			}
+
+			b.ReportMetric(float64(b.N*16*20000)/b.Elapsed().Seconds(), "lines/s")
+		})
+	}
+}
@@ -2,20 +2,53 @@ package domain
 
 import (
 	"math"
-	"slices"
 	"sort"
 	"strconv"
 	"time"
+
+	"LogAnalyzer/pkg/sketch"
 )
 
 type Statistic struct {
-	LogsMetrics          Metrics
-	CommonStats          CommonStats
-	TimeRange            TimeRange
-	NinetyFivePercentile float32
-	Median               float32
-	ErrorRate            float32
-	ResponseCodes        map[string]int
+	// Top - размеры рейтингов, задаются до вызова Fill.
+	Top TopLimits
+	// Percentiles - какие перцентили размера ответа считать, доли из (0, 1]. Пустой слайс - DefaultPercentiles.
+	Percentiles []float64
+	// ClientsBy - как ранжировать топ клиентов: ClientsByRequests (по умолчанию) или ClientsByBytes.
+	ClientsBy string
+	// BySource - считать разбивку по источникам логов.
+	BySource bool
+	// Bucket - размер интервала временного ряда, 0 - подобрать по диапазону логов.
+	Bucket time.Duration
+	// Run - параметры запуска для отчета, задаются до вызова Fill.
+	Run           RunInfo
+	LogsMetrics   Metrics
+	CommonStats   CommonStats
+	TimeRange     TimeRange
+	ResponseSize  SizeStats
+	ErrorRate     float32
+	ResponseCodes map[string]int
+	// Время обработки запросов, заполняется если формат содержит $request_time или $upstream_response_time.
+	RequestLatency   LatencyStats
+	UpstreamLatency  LatencyStats
+	ResourceLatency  []EndpointLatency
+	SlowestEndpoints []EndpointLatency
+	ParseQuality     ParseQuality
+	// Оценка уникальных клиентов: общая и для топ ресурсов, заполняется если формат содержит $remote_addr.
+	Visitors         Visitors
+	ResourceVisitors map[string]Visitors
+	// TopClients - топ клиентов по $remote_addr, заполняется если формат его содержит.
+	TopClients []ClientStats
+	// UserAgents - браузеры, ОС, устройства и боты, заполняется если включен классификатор User-Agent.
+	UserAgents UserAgentStats
+	// Sources - разбивка по источникам в порядке разбора, заполняется если включен BySource.
+	Sources []SourceStats
+	// QueryParams - самые частые параметры строки запроса с числом их различных значений.
+	QueryParams []QueryParamStats
+	// Geo - трафик по странам и ASN, заполняется если заданы базы MaxMind.
+	Geo GeoStats
+	// Timeline - трафик по интервалам размера Bucket.
+	Timeline Timeline
 }
 
 const (
@@ -42,9 +75,47 @@ type CommonStats struct {
 	HTTPCode    []KeyCount
 }
 
+// KeyCount - строка рейтинга. Other отмечает строку, в которую просуммировано все, что не вошло в рейтинг.
 type KeyCount struct {
 	Value string
 	Count int
+	Other bool
+}
+
+// DefaultPercentiles - перцентили размера ответа по умолчанию: p50, p90, p95, p99, p99.9.
+var DefaultPercentiles = []float64{0.5, 0.9, 0.95, 0.99, 0.999}
+
+// maxSizeBuckets - наибольшее число столбцов гистограммы размеров ответа.
+const maxSizeBuckets = 16
+
+// SizeStats - распределение размеров ответа. Перцентили и гистограмма считаются по скетчу с относительной ошибкой
+// не больше sketch.DefaultRelativeAccuracy (1%), минимум, максимум, среднее и стандартное отклонение - точные.
+type SizeStats struct {
+	Min         float64
+	Max         float64
+	Mean        float64
+	StdDev      float64
+	Percentiles []Percentile
+	Histogram   []SizeBucket
+}
+
+// SizeBucket - столбец гистограммы размеров ответа: число ответов больше From и не больше To байт,
+// в первый столбец (From = 0) входят и пустые ответы.
+type SizeBucket struct {
+	From  float64
+	To    float64
+	Count uint64
+}
+
+// Percentile - значение перцентиля, Quantile - доля из (0, 1].
+type Percentile struct {
+	Quantile float64
+	Value    float64
+}
+
+// Label - подпись перцентиля для отчета, например p95 или p99.9.
+func (p Percentile) Label() string {
+	return "p" + strconv.FormatFloat(p.Quantile*100, 'f', -1, 64)
 }
 
 type TimeRange struct {
@@ -55,24 +126,11 @@ type TimeRange struct {
 // Fill - метод структуры Statistic, нужен для конфертации сырых данных полученных после парсинга логов,
 // в статистику которая уже будет использоваться для составления отчета.
 func (s *Statistic) Fill(data *DataHolder) {
-	var totalBytes, totalErrors int
-	for _, bytes := range data.BytesSend {
-		totalBytes += bytes
-	}
-
-	averageAnswerSize := float32(totalBytes) / float32(len(data.BytesSend))
-	commonHTTPRequests := s.findTopThree(data.HTTPRequests)
-	commonResources := s.findTopThree(data.RequestedResources)
-	commonHTTPCodes := s.findTopThree(data.CommonAnswers)
-
-	slices.Sort(data.BytesSend)
+	var totalErrors int
 
-	// Позиция для перцентиля
-	indexForNfPercentile := int(math.Floor(0.95 * float64(len(data.BytesSend)-1)))
-	NFPercentile := float32(data.BytesSend[indexForNfPercentile])
-	// позиция для медианы
-	indexForMedian := int(math.Floor(0.5 * float64(len(data.BytesSend)-1)))
-	median := float32(data.BytesSend[indexForMedian])
+	commonHTTPRequests := findTop(data.HTTPRequests, s.Top.For(TopRequests))
+	commonResources := findTop(data.RequestedResources, s.Top.For(TopResources))
+	commonHTTPCodes := findTop(data.CommonAnswers, s.Top.For(TopCodes))
 
 	// Подсчет распределения кодов ответов и ошибок
 	ResponseCodeDistribution := map[string]int{
@@ -103,12 +161,15 @@ func (s *Statistic) Fill(data *DataHolder) {
 	}
 
 	// Процент ошибок по отношению к общему количеству запросов
-	errorRate := float32(totalErrors) / float32(data.TotalCounter) * 100
+	var errorRate float32
+	if data.TotalCounter > 0 {
+		errorRate = float32(totalErrors) / float32(data.TotalCounter) * 100
+	}
 
 	s.LogsMetrics = Metrics{
 		ProcessedLogs:     data.TotalCounter,
 		UnparsedLogs:      data.UnparsedLogs,
-		AverageAnswerSize: averageAnswerSize,
+		AverageAnswerSize: float32(data.ResponseSizeMoments.Mean()),
 		TotalError:        totalErrors,
 	}
 	s.CommonStats = CommonStats{
@@ -121,27 +182,110 @@ func (s *Statistic) Fill(data *DataHolder) {
 		To:   data.To,
 	}
 
-	s.NinetyFivePercentile = NFPercentile
-	s.Median = median
+	s.fillResponseSize(data)
 	s.ErrorRate = errorRate
 	s.ResponseCodes = ResponseCodeDistribution
+	s.fillLatency(data, commonResources)
+	s.fillTimeline(data)
+	s.fillVisitors(data, commonResources)
+	s.fillTopClients(data)
+	s.fillUserAgents(data)
+	s.fillGeo(data)
+	s.fillQueryParams(data)
+	s.fillSources(data)
+	s.fillParseQuality(data)
+}
+
+// fillResponseSize - считает распределение размеров ответа. Если формат лога не содержит размера ответа,
+// метрики остаются нулевыми.
+func (s *Statistic) fillResponseSize(data *DataHolder) {
+	quantiles := s.Percentiles
+	if len(quantiles) == 0 {
+		quantiles = DefaultPercentiles
+	}
+
+	percentiles := make([]Percentile, 0, len(quantiles))
+	for _, quantile := range quantiles {
+		percentiles = append(percentiles, Percentile{Quantile: quantile, Value: data.ResponseSizes.Quantile(quantile)})
+	}
+
+	s.ResponseSize = SizeStats{
+		Min:         data.ResponseSizes.Min(),
+		Max:         data.ResponseSizes.Max(),
+		Mean:        data.ResponseSizeMoments.Mean(),
+		StdDev:      data.ResponseSizeMoments.StdDev(),
+		Percentiles: percentiles,
+		Histogram:   sizeHistogram(data.ResponseSizes),
+	}
 }
 
-// findTopThree - функция, которая помогает найти топ три самых используемых значения в мапе,
-// Вынесено в отдельную функцию для удобства использования.
-func (s *Statistic) findTopThree(data map[string]int) []KeyCount {
-	items := make([]KeyCount, 0, len(data))
-	for value, count := range data {
-		items = append(items, KeyCount{Value: value, Count: count})
+// sizeHistogram - гистограмма с границами по степеням двойки от минимума до максимума. Если столбцов получается
+// больше maxSizeBuckets, границы берутся через одну, две и т.д. степени.
+func sizeHistogram(sizes *sketch.DDSketch) []SizeBucket {
+	if sizes.Count() == 0 {
+		return nil
+	}
+
+	low := 0
+	if sizes.Min() >= 1 {
+		low = int(math.Floor(math.Log2(sizes.Min())))
 	}
 
-	sort.Slice(items, func(i, j int) bool {
-		return items[i].Count > items[j].Count
+	high := int(math.Ceil(math.Log2(math.Max(sizes.Max(), 1))))
+	step := max(1, (high-low+maxSizeBuckets-1)/maxSizeBuckets)
+
+	var (
+		buckets []SizeBucket
+		from    float64
+		counted uint64
+	)
+
+	for exponent := low + step; len(buckets) == 0 || from < sizes.Max(); exponent += step {
+		to := math.Ldexp(1, exponent)
+		total := sizes.CountAtMost(to)
+		buckets = append(buckets, SizeBucket{From: from, To: to, Count: total - counted})
+		from, counted = to, total
+	}
+
+	return buckets
+}
+
+// fillLatency - считает перцентили времени обработки запросов: общие, для топ ресурсов и
+// самые медленные ресурсы по 95-му перцентилю среди ресурсов не меньше чем с MinSlowestRequests запросами.
+func (s *Statistic) fillLatency(data *DataHolder, topResources []KeyCount) {
+	s.RequestLatency = latencyStats(data.RequestTimes)
+	s.UpstreamLatency = latencyStats(data.UpstreamTimes)
+
+	s.ResourceLatency = make([]EndpointLatency, 0, len(topResources))
+
+	for _, resource := range topResources {
+		if resource.Other {
+			continue
+		}
+
+		if durations, ok := data.ResourceLatencies[resource.Value]; ok {
+			s.ResourceLatency = append(s.ResourceLatency, EndpointLatency{Resource: resource.Value, Latency: latencyStats(durations)})
+		}
+	}
+
+	endpoints := make([]EndpointLatency, 0, len(data.ResourceLatencies))
+	for resource, durations := range data.ResourceLatencies {
+		if latency := latencyStats(durations); latency.Count >= MinSlowestRequests {
+			endpoints = append(endpoints, EndpointLatency{Resource: resource, Latency: latency})
+		}
+	}
+
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Latency.P95 != endpoints[j].Latency.P95 {
+			return endpoints[i].Latency.P95 > endpoints[j].Latency.P95
+		}
+
+		return endpoints[i].Resource < endpoints[j].Resource
 	})
 
-	if len(items) > 3 {
-		return items[:3]
+	if limit := s.Top.For(TopSlowest); len(endpoints) > limit {
+		endpoints = endpoints[:limit]
 	}
 
-	return items
+	s.SlowestEndpoints = endpoints
 }
@@ -1,36 +1,43 @@
 package domain_test
 
 import (
+	"fmt"
 	"math"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/filter"
+	"LogAnalyzer/internal/domain/logformats"
+	"LogAnalyzer/internal/domain/useragent"
 )
 
 func TestAnalyzeData(t *testing.T) {
-	data := &domain.DataHolder{
-		BytesSend: []int{100, 200, 150, 300, 250},
-		HTTPRequests: map[string]int{
-			"GET":  10,
-			"POST": 15,
-			"PUT":  5,
-		},
-		RequestedResources: map[string]int{
-			"/home":  10,
-			"/about": 20,
-		},
-		CommonAnswers: map[string]int{
-			"200": 25,
-			"404": 5,
-			"500": 2,
-		},
-		TotalCounter: 50,
-		UnparsedLogs: 5,
-		From:         time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
-		To:           time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC),
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	data.HTTPRequests = map[string]int{
+		"GET":  10,
+		"POST": 15,
+		"PUT":  5,
+	}
+	data.RequestedResources = map[string]int{
+		"/home":  10,
+		"/about": 20,
+	}
+	data.CommonAnswers = map[string]int{
+		"200": 25,
+		"404": 5,
+		"500": 2,
+	}
+	data.TotalCounter = 50
+	data.UnparsedLogs = 5
+	data.From = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	data.To = time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC)
+
+	for _, size := range []int{100, 200, 150, 300, 250} {
+		data.AddResponseSize(size)
 	}
 
 	statistic := &domain.Statistic{}
@@ -45,14 +52,17 @@ func TestAnalyzeData(t *testing.T) {
 	expectedAvgSize := float32(100+200+150+300+250) / 5
 	assert.Equal(t, expectedAvgSize, statistic.LogsMetrics.AverageAnswerSize)
 
-	// Проверка 95-го перцентиля
-	sortedBytesSend := []int{100, 150, 200, 250, 300}
-	expectedNFPercentile := float32(sortedBytesSend[int(math.Floor(0.95*float64(len(sortedBytesSend)-1)))])
-	assert.Equal(t, expectedNFPercentile, statistic.NinetyFivePercentile)
-
-	// Проверка медианы
-	expectedMedian := float32(sortedBytesSend[int(math.Floor(0.5*float64(len(sortedBytesSend)-1)))])
-	assert.Equal(t, expectedMedian, statistic.Median)
+	// Проверка распределения размеров ответа: перцентили приблизительные, остальное точно
+	require.Len(t, statistic.ResponseSize.Percentiles, len(domain.DefaultPercentiles))
+	assert.Equal(t, "p50", statistic.ResponseSize.Percentiles[0].Label())
+	assert.InEpsilon(t, 200, statistic.ResponseSize.Percentiles[0].Value, 0.01)
+	assert.Equal(t, "p95", statistic.ResponseSize.Percentiles[2].Label())
+	assert.InEpsilon(t, 250, statistic.ResponseSize.Percentiles[2].Value, 0.01)
+	assert.Equal(t, "p99.9", statistic.ResponseSize.Percentiles[4].Label())
+	assert.InDelta(t, 100, statistic.ResponseSize.Min, 0)
+	assert.InDelta(t, 300, statistic.ResponseSize.Max, 0)
+	assert.InDelta(t, 200, statistic.ResponseSize.Mean, 0)
+	assert.InDelta(t, math.Sqrt(5000), statistic.ResponseSize.StdDev, 1e-9)
 
 	// Проверка распределения HTTP кодов
 	assert.Equal(t, 25, statistic.ResponseCodes[domain.Success])
@@ -73,3 +83,445 @@ func TestAnalyzeData(t *testing.T) {
 	assert.Equal(t, []domain.KeyCount{{Value: "200", Count: 25}, {Value: "404", Count: 5}, {Value: "500", Count: 2}},
 		statistic.CommonStats.HTTPCode)
 }
+
+func TestFillResponseSizeHistogram(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+
+	for _, size := range []int{0, 0, 3, 100, 100, 5000, 1 << 20} {
+		data.Parse(fmt.Sprintf(`10.0.0.1 - - [17/May/2015:08:05:24 +0000] "GET / HTTP/1.1" 200 %d "-" "curl"`, size),
+			time.Time{}, time.Time{})
+	}
+
+	statistic := &domain.Statistic{}
+	statistic.Fill(data)
+
+	histogram := statistic.ResponseSize.Histogram
+	require.LessOrEqual(t, len(histogram), 16)
+
+	var total uint64
+
+	for i, bucket := range histogram {
+		total += bucket.Count
+
+		if i > 0 {
+			assert.InDelta(t, histogram[i-1].To, bucket.From, 0, "buckets are contiguous")
+		}
+	}
+
+	// 20 степеней двойки не помещаются в 16 столбцов, поэтому границы через одну степень
+	assert.Equal(t, domain.SizeBucket{From: 0, To: 4, Count: 3}, histogram[0])
+	assert.Equal(t, uint64(7), total)
+	assert.GreaterOrEqual(t, histogram[len(histogram)-1].To, float64(1<<20))
+
+	// Большой диапазон размеров укладывается в ограниченное число столбцов
+	data.Parse(`10.0.0.1 - - [17/May/2015:08:05:24 +0000] "GET / HTTP/1.1" 200 10000000000 "-" "curl"`, time.Time{}, time.Time{})
+	statistic.Fill(data)
+	assert.LessOrEqual(t, len(statistic.ResponseSize.Histogram), 16)
+
+	statistic.Fill(domain.NewDataHolder(logformats.NewCombined(), nil))
+	assert.Empty(t, statistic.ResponseSize.Histogram)
+}
+
+func TestFillLatency(t *testing.T) {
+	parser, err := logformats.NewNginx(`$remote_addr [$time_local] "$request" $status $request_time "$upstream_response_time"`)
+	require.NoError(t, err)
+
+	data := domain.NewDataHolder(parser, nil)
+	logs := []string{
+		`10.0.0.1 [17/May/2015:08:05:24 +0000] "GET /fast HTTP/1.1" 200 0.010 "0.008"`,
+		`10.0.0.1 [17/May/2015:08:05:25 +0000] "GET /fast HTTP/1.1" 200 0.020 "0.015"`,
+		`10.0.0.1 [17/May/2015:08:05:26 +0000] "GET /slow HTTP/1.1" 502 3.000 "1.000, 1.500 : 0.400"`,
+		`10.0.0.1 [17/May/2015:08:05:27 +0000] "GET /static HTTP/1.1" 200 0.001 "-"`,
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	statistic := &domain.Statistic{}
+	statistic.Fill(data)
+
+	assert.Equal(t, 4, statistic.RequestLatency.Count)
+	// Перцентили считаются по скетчу с относительной ошибкой 1%, максимум точный
+	assert.InEpsilon(t, 0.010, statistic.RequestLatency.P50, 0.01)
+	assert.InDelta(t, 3.0, statistic.RequestLatency.Max, 1e-9)
+
+	// "-" не учитывается, а повторные попытки суммируются
+	assert.Equal(t, 3, statistic.UpstreamLatency.Count)
+	assert.InDelta(t, 2.9, statistic.UpstreamLatency.Max, 1e-9)
+
+	// Ресурсов с MinSlowestRequests запросами нет, поэтому рейтинг самых медленных пуст
+	assert.Empty(t, statistic.SlowestEndpoints)
+	assert.Equal(t, "/fast", statistic.ResourceLatency[0].Resource)
+	assert.Equal(t, 2, statistic.ResourceLatency[0].Latency.Count)
+}
+
+func TestFillLatency_SlowestNeedsSamples(t *testing.T) {
+	parser, err := logformats.NewNginx(`$remote_addr [$time_local] "$request" $status $request_time`)
+	require.NoError(t, err)
+
+	data := domain.NewDataHolder(parser, nil)
+
+	for i := range domain.MinSlowestRequests + 5 {
+		data.Parse(fmt.Sprintf(`10.0.0.1 [17/May/2015:08:05:%02d +0000] "GET /fast HTTP/1.1" 200 0.010`, i%60),
+			time.Time{}, time.Time{})
+	}
+
+	for i := range domain.MinSlowestRequests {
+		data.Parse(fmt.Sprintf(`10.0.0.1 [17/May/2015:08:06:%02d +0000] "GET /api HTTP/1.1" 200 0.500`, i%60),
+			time.Time{}, time.Time{})
+	}
+
+	// Единичный медленный запрос не попадает в рейтинг
+	data.Parse(`10.0.0.1 [17/May/2015:08:07:00 +0000] "GET /outlier HTTP/1.1" 200 9.000`, time.Time{}, time.Time{})
+
+	statistic := &domain.Statistic{}
+	statistic.Fill(data)
+
+	slowest := make([]string, 0, len(statistic.SlowestEndpoints))
+	for _, endpoint := range statistic.SlowestEndpoints {
+		slowest = append(slowest, endpoint.Resource)
+	}
+
+	assert.Equal(t, []string{"/api", "/fast"}, slowest)
+	assert.InDelta(t, 9.0, statistic.RequestLatency.Max, 1e-9)
+}
+
+func TestFillTopN(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	data.RequestedResources = map[string]int{"/d": 5, "/c": 5, "/b": 7, "/a": 1, "/e": 2}
+	data.CommonAnswers = map[string]int{"200": 10, "404": 10}
+	data.TotalCounter = 20
+
+	statistic := &domain.Statistic{Top: domain.TopLimits{Default: 1, Sections: map[string]int{domain.TopResources: 3}}}
+	statistic.Fill(data)
+
+	// При равном количестве порядок определяется значением, остальное суммируется в последнюю строку
+	assert.Equal(t, []domain.KeyCount{
+		{Value: "/b", Count: 7}, {Value: "/c", Count: 5}, {Value: "/d", Count: 5}, {Count: 3, Other: true},
+	}, statistic.CommonStats.Resource)
+	assert.Equal(t, []domain.KeyCount{{Value: "200", Count: 10}, {Count: 10, Other: true}}, statistic.CommonStats.HTTPCode)
+	assert.Empty(t, statistic.CommonStats.HTTPRequest)
+}
+
+func TestFillTimeline(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	logs := []string{
+		`10.0.0.1 - - [17/May/2015:08:01:10 +0300] "GET /a HTTP/1.1" 200 100 "-" "curl"`,
+		`10.0.0.1 - - [17/May/2015:08:03:00 +0300] "GET /b HTTP/1.1" 404 50 "-" "curl"`,
+		`10.0.0.1 - - [17/May/2015:08:04:59 +0300] "GET /c HTTP/1.1" 500 - "-" "curl"`,
+		`10.0.0.1 - - [17/May/2015:08:12:00 +0300] "GET /a HTTP/1.1" 200 10 "-" "curl"`,
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	statistic := &domain.Statistic{Bucket: 5 * time.Minute}
+	statistic.Fill(data)
+
+	zone := time.FixedZone("", 3*60*60)
+
+	// Пустой интервал внутри диапазона тоже выводится
+	assert.Equal(t, "5m", statistic.Timeline.BucketLabel())
+	assert.Equal(t, []domain.TrafficBucket{
+		{
+			Start: time.Date(2015, 5, 17, 8, 0, 0, 0, zone), Requests: 3, ClientErrors: 1, ServerErrors: 1,
+			ErrorRate: float32(2) / 3 * 100, Bytes: 150, Visitors: domain.Visitors{ByIP: 1, ByIPAgent: 1},
+		},
+		{Start: time.Date(2015, 5, 17, 8, 5, 0, 0, zone)},
+		{Start: time.Date(2015, 5, 17, 8, 10, 0, 0, zone), Requests: 1, Bytes: 10, Visitors: domain.Visitors{ByIP: 1, ByIPAgent: 1}},
+	}, statistic.Timeline.Buckets)
+	assert.Equal(t, 0, statistic.Timeline.Peak)
+
+	// Автоматический размер: 11 минут укладываются в минутные интервалы
+	statistic = &domain.Statistic{}
+	statistic.Fill(data)
+
+	assert.Equal(t, time.Minute, statistic.Timeline.Bucket)
+	assert.Len(t, statistic.Timeline.Buckets, 12)
+}
+
+func TestFillTimeline_DayAlignedToLogZone(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	logs := []string{
+		`10.0.0.1 - - [17/May/2015:23:59:00 +0300] "GET /a HTTP/1.1" 200 1 "-" "curl"`,
+		`10.0.0.1 - - [18/May/2015:00:01:00 +0300] "GET /a HTTP/1.1" 200 1 "-" "curl"`,
+		`10.0.0.1 - - [18/May/2015:02:30:00 +0300] "GET /a HTTP/1.1" 200 1 "-" "curl"`,
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	statistic := &domain.Statistic{Bucket: 24 * time.Hour}
+	statistic.Fill(data)
+
+	require.Len(t, statistic.Timeline.Buckets, 2)
+	assert.Equal(t, "1d", statistic.Timeline.BucketLabel())
+	assert.Equal(t, "2015-05-18T00:00:00+03:00", statistic.Timeline.Buckets[1].Start.Format(time.RFC3339))
+	assert.Equal(t, 2, statistic.Timeline.Buckets[1].Requests)
+	assert.Equal(t, 1, statistic.Timeline.Peak)
+}
+
+func TestFillTimeline_LimitsBuckets(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	logs := []string{
+		`10.0.0.1 - - [01/Jan/1970:00:00:00 +0000] "GET /a HTTP/1.1" 200 1 "-" "curl"`,
+		`10.0.0.1 - - [17/May/2025:08:00:00 +0000] "GET /a HTTP/1.1" 200 1 "-" "curl"`,
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	statistic := &domain.Statistic{Bucket: time.Minute}
+	statistic.Fill(data)
+
+	timeline := statistic.Timeline
+	assert.LessOrEqual(t, len(timeline.Buckets), domain.MaxTimelineBuckets)
+	assert.Equal(t, time.Minute, timeline.Requested)
+	assert.Equal(t, "3d", timeline.BucketLabel(), "widened bucket is rounded up to whole days")
+	assert.Equal(t, 1, timeline.Buckets[0].Requests)
+	assert.Equal(t, 1, timeline.Buckets[len(timeline.Buckets)-1].Requests)
+}
+
+func TestFillVisitors(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	logs := []string{
+		`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 1 "-" "curl"`,
+		`10.0.0.1 - - [17/May/2015:08:01:30 +0000] "GET /a HTTP/1.1" 200 1 "-" "firefox"`,
+		`10.0.0.2 - - [17/May/2015:08:02:00 +0000] "GET /a HTTP/1.1" 200 1 "-" "curl"`,
+		`10.0.0.2 - - [17/May/2015:08:02:30 +0000] "GET /b HTTP/1.1" 200 1 "-" "curl"`,
+		`10.0.0.3 - - [17/May/2015:08:03:00 +0000] "GET /b HTTP/1.1" 200 1 "-" "curl"`,
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	statistic := &domain.Statistic{Bucket: 2 * time.Minute, Top: domain.TopLimits{Default: 1}}
+	statistic.Fill(data)
+
+	// На малых количествах оценка HyperLogLog точная
+	assert.Equal(t, domain.Visitors{ByIP: 3, ByIPAgent: 4}, statistic.Visitors)
+	assert.Equal(t, map[string]domain.Visitors{"/a": {ByIP: 2, ByIPAgent: 3}}, statistic.ResourceVisitors)
+
+	require.Len(t, statistic.Timeline.Buckets, 2)
+	assert.True(t, statistic.Timeline.HasVisitors)
+	assert.Equal(t, domain.Visitors{ByIP: 1, ByIPAgent: 2}, statistic.Timeline.Buckets[0].Visitors)
+	assert.Equal(t, domain.Visitors{ByIP: 2, ByIPAgent: 2}, statistic.Timeline.Buckets[1].Visitors)
+}
+
+func TestFillVisitors_ResourceSketchesCapped(t *testing.T) {
+	resourceLog := func(data *domain.DataHolder, resource string) {
+		data.Parse(`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET `+resource+` HTTP/1.1" 200 1 "-" "curl"`,
+			time.Time{}, time.Time{})
+	}
+
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	for i := range domain.MaxResourceVisitors {
+		resourceLog(data, fmt.Sprintf("/r%d", i))
+	}
+
+	// Новые ресурсы сверх лимита скетчей не получают, известные продолжают учитываться
+	resourceLog(data, "/late")
+	resourceLog(data, "/r0")
+
+	assert.Len(t, data.ResourceVisitors, domain.MaxResourceVisitors)
+	assert.NotContains(t, data.ResourceVisitors, "/late")
+	assert.Equal(t, 2, data.RequestedResources["/r0"])
+
+	// Слияние тоже не выходит за лимит
+	other := domain.NewDataHolder(logformats.NewCombined(), nil)
+	resourceLog(other, "/other")
+	resourceLog(other, "/r1")
+	require.NoError(t, data.Merge(other))
+
+	assert.Len(t, data.ResourceVisitors, domain.MaxResourceVisitors)
+	assert.NotContains(t, data.ResourceVisitors, "/other")
+
+	statistic := &domain.Statistic{Top: domain.TopLimits{Default: 1}}
+	statistic.Fill(data)
+
+	assert.Equal(t, map[string]domain.Visitors{"/r0": {ByIP: 1, ByIPAgent: 1}}, statistic.ResourceVisitors)
+}
+
+// minuteLogs - по запросу в минуту начиная с start, клиенты чередуются между ips.
+func minuteLogs(data *domain.DataHolder, start time.Time, minutes int, ips ...string) {
+	for i := range minutes {
+		logTime := start.Add(time.Duration(i) * time.Minute).Format("02/Jan/2006:15:04:05 -0700")
+		data.Parse(ips[i%len(ips)]+` - - [`+logTime+`] "GET /a HTTP/1.1" 200 1 "-" "curl"`, time.Time{}, time.Time{})
+	}
+}
+
+func TestFillVisitors_IntervalSketchesBounded(t *testing.T) {
+	start := time.Date(2015, time.May, 17, 0, 0, 0, 0, time.UTC)
+
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	minuteLogs(data, start, 3000, "10.0.0.1", "10.0.0.2")
+
+	// 3000 минутных скетчей не помещаются в 1440, поэтому шаг становится 5 минут
+	assert.Equal(t, int64(5), data.VisitorStep)
+	assert.Len(t, data.TrafficVisitors, 600)
+
+	hourly := &domain.Statistic{Bucket: time.Hour}
+	hourly.Fill(data)
+
+	assert.True(t, hourly.Timeline.HasVisitors)
+	assert.Equal(t, domain.Visitors{ByIP: 2, ByIPAgent: 2}, hourly.Timeline.Buckets[0].Visitors)
+
+	// Минутные интервалы мельче шага скетчей: оценки клиентов по интервалам нет
+	minutely := &domain.Statistic{Bucket: time.Minute}
+	minutely.Fill(data)
+
+	assert.False(t, minutely.Timeline.HasVisitors)
+	assert.Zero(t, minutely.Timeline.Buckets[0].Visitors)
+
+	// При слиянии скетчи с мелким шагом переводятся в крупный
+	merged := domain.NewDataHolder(logformats.NewCombined(), nil)
+	minuteLogs(merged, start, 10, "10.0.0.3")
+	require.NoError(t, merged.Merge(data))
+
+	assert.Equal(t, int64(5), merged.VisitorStep)
+
+	hourly.Fill(merged)
+	assert.Equal(t, domain.Visitors{ByIP: 3, ByIPAgent: 3}, hourly.Timeline.Buckets[0].Visitors)
+}
+
+func TestFillTopClients(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	logs := []string{
+		`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 100 "-" "curl"`,
+		`10.0.0.1 - - [17/May/2015:08:03:00 +0000] "GET /a HTTP/1.1" 404 10 "-" "curl"`,
+		`10.0.0.1 - - [17/May/2015:08:02:00 +0000] "GET /a HTTP/1.1" 500 0 "-" "curl"`,
+		`10.0.0.2 - - [17/May/2015:08:04:00 +0000] "GET /big HTTP/1.1" 200 5000 "-" "curl"`,
+		`10.0.0.3 - - [17/May/2015:08:00:00 +0000] "GET /a HTTP/1.1" 200 1 "-" "curl"`,
+		`10.0.0.4 - - [17/May/2015:08:05:00 +0000] "GET /a HTTP/1.1" 403 1 "-" "curl"`,
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	minute := func(m int) time.Time { return time.Date(2015, 5, 17, 8, m, 0, 0, time.UTC) }
+
+	statistic := &domain.Statistic{Top: domain.TopLimits{Sections: map[string]int{domain.TopClients: 2}}}
+	statistic.Fill(data)
+
+	// При равном числе запросов выше клиент с большим объемом, остальные суммируются в последнюю строку
+	assert.Equal(t, []domain.ClientStats{
+		{Address: "10.0.0.1", Requests: 3, Bytes: 110, ErrorRate: float32(2) / 3 * 100, FirstSeen: minute(1), LastSeen: minute(3)},
+		{Address: "10.0.0.2", Requests: 1, Bytes: 5000, FirstSeen: minute(4), LastSeen: minute(4)},
+		{Requests: 2, Bytes: 2, ErrorRate: 50, FirstSeen: minute(0), LastSeen: minute(5), Other: true},
+	}, statistic.TopClients)
+
+	statistic = &domain.Statistic{ClientsBy: domain.ClientsByBytes, Top: domain.TopLimits{Default: 1}}
+	statistic.Fill(data)
+
+	require.Len(t, statistic.TopClients, 2)
+	assert.Equal(t, "10.0.0.2", statistic.TopClients[0].Address)
+	assert.Equal(t, 5, statistic.TopClients[1].Requests)
+}
+
+func TestFillUserAgents(t *testing.T) {
+	parser := logformats.NewCombined()
+	classifier := useragent.Default()
+
+	// Поля классификатора доступны фильтру
+	botFilter, err := filter.Parse(`ua_device != tablet`, append(parser.Fields(), classifier.Fields()...))
+	require.NoError(t, err)
+
+	data := domain.NewDataHolder(parser, botFilter)
+	data.AddEnricher(classifier)
+
+	logs := []string{
+		`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 1 "-" "Mozilla/5.0 (Windows NT 10.0) Chrome/120.0"`,
+		`10.0.0.2 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 1 "-" "Mozilla/5.0 (Windows NT 6.1) Chrome/109.0"`,
+		`10.0.0.3 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 1 "-" "Mozilla/5.0 (compatible; Googlebot/2.1)"`,
+		`10.0.0.4 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 1 "-" "Mozilla/5.0 (Linux; Android 13) Chrome/119.0"`,
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	statistic := &domain.Statistic{}
+	statistic.Fill(data)
+
+	assert.Equal(t, []domain.KeyCount{{Value: "Chrome", Count: 2}}, statistic.UserAgents.Browsers)
+	assert.Equal(t, []domain.KeyCount{{Value: "Windows", Count: 2}}, statistic.UserAgents.OperatingSystems)
+	assert.Equal(t, []domain.KeyCount{{Value: domain.DeviceDesktop, Count: 2}, {Value: domain.DeviceBot, Count: 1}},
+		statistic.UserAgents.Devices)
+	assert.Equal(t, []domain.KeyCount{{Value: "Googlebot", Count: 1}}, statistic.UserAgents.Bots)
+	assert.InDelta(t, float32(100)/3, statistic.UserAgents.BotShare, 1e-4)
+}
+
+func TestSplitURI(t *testing.T) {
+	tests := []struct {
+		uri, path, query string
+	}{
+		{"/search?q=a", "/search", "q=a"},
+		{"/caf%C3%A9/a+b?q=hello+world&x=%2F", "/café/a+b", "q=hello world&x=/"},
+		{"/broken%zz?q=%zz", "/broken%zz", "q=%zz"},
+		// Закодированный слеш не делит сегмент пути
+		{"/files/a%2Fb/c%20d", "/files/a%2Fb/c d", ""},
+		{"/files/a%2fb", "/files/a%2Fb", ""},
+		{"/plain", "/plain", ""},
+	}
+
+	for _, tt := range tests {
+		path, query := domain.SplitURI(tt.uri)
+		assert.Equal(t, tt.path, path, tt.uri)
+		assert.Equal(t, tt.query, query, tt.uri)
+	}
+}
+
+func TestFillQueryParams(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+
+	for i := range 40 {
+		uri := fmt.Sprintf("/search?q=%s&_=%d", []string{"go", "rust"}[i%2], 1700000000000+i)
+		if i%4 == 0 {
+			uri += "&page=2"
+		}
+
+		data.Parse(`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET `+uri+` HTTP/1.1" 200 1 "-" "curl/8.0"`, time.Time{}, time.Time{})
+	}
+
+	data.Parse(`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET /search HTTP/1.1" 200 1 "-" "curl/8.0"`, time.Time{}, time.Time{})
+
+	// Запросы считаются по пути без строки запроса
+	assert.Equal(t, map[string]int{"/search": 41}, data.RequestedResources)
+
+	statistic := &domain.Statistic{Top: domain.TopLimits{Sections: map[string]int{domain.TopParams: 2}}}
+	statistic.Fill(data)
+
+	// Число различных значений приблизительное, поэтому проверяется отдельно
+	require.Len(t, statistic.QueryParams, 3)
+	assert.InEpsilon(t, 40, statistic.QueryParams[0].Cardinality, 0.05)
+	statistic.QueryParams[0].Cardinality = 0
+
+	assert.Equal(t, []domain.QueryParamStats{
+		{Name: "_", Requests: 40, Share: 100, CacheBuster: true},
+		{Name: "q", Requests: 40, Share: 100, Cardinality: 2},
+		{Requests: 10, Other: true},
+	}, statistic.QueryParams)
+}
+
+func TestFillQueryParams_RepeatedParam(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+
+	for _, uri := range []string{"/a?tag=1&tag=2&tag=3", "/a?tag=4&x=1", "/a?x=2"} {
+		data.Parse(`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET `+uri+` HTTP/1.1" 200 1 "-" "curl/8.0"`, time.Time{}, time.Time{})
+	}
+
+	statistic := &domain.Statistic{}
+	statistic.Fill(data)
+
+	// Повторенный параметр учитывается в запросе один раз, но все его значения считаются различными
+	assert.Equal(t, []domain.QueryParamStats{
+		{Name: "tag", Requests: 2, Share: float32(2) / 3 * 100, Cardinality: 4},
+		{Name: "x", Requests: 2, Share: float32(2) / 3 * 100, Cardinality: 2},
+	}, statistic.QueryParams)
+}
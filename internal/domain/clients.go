@@ -0,0 +1,134 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Способы ранжирования клиентов в топе.
+const (
+	ClientsByRequests = "requests"
+	ClientsByBytes    = "bytes"
+)
+
+// ClientCounters - счетчики запросов одного клиента.
+type ClientCounters struct {
+	Requests  int
+	Errors    int
+	Bytes     uint64
+	FirstSeen time.Time
+	LastSeen  time.Time
+}
+
+// ClientStats - строка топа клиентов, ErrorRate - процент ответов 4xx и 5xx. Other отмечает строку,
+// в которую просуммированы клиенты, не вошедшие в топ.
+type ClientStats struct {
+	Address   string
+	Requests  int
+	Bytes     uint64
+	ErrorRate float32
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Other     bool
+}
+
+// collectClient - учитывает запрос в счетчиках клиента. Адрес копируется при первом появлении клиента,
+// чтобы ключ мапы не удерживал в памяти всю строку лога.
+func (s *DataHolder) collectClient(address string, logTime time.Time, code int, bytes int) {
+	counters, ok := s.Clients[address]
+	if !ok {
+		address = strings.Clone(address)
+	}
+
+	counters.Requests++
+	counters.Bytes += uint64(max(bytes, 0))
+
+	if code >= 400 {
+		counters.Errors++
+	}
+
+	if !logTime.IsZero() {
+		if counters.FirstSeen.IsZero() || logTime.Before(counters.FirstSeen) {
+			counters.FirstSeen = logTime
+		}
+
+		if logTime.After(counters.LastSeen) {
+			counters.LastSeen = logTime
+		}
+	}
+
+	s.Clients[address] = counters
+}
+
+// fillTopClients - топ клиентов по числу запросов или по объему ответов, при равенстве - по второму показателю
+// и по адресу. Все, кто не вошел в топ, суммируются в строку Other.
+func (s *Statistic) fillTopClients(data *DataHolder) {
+	clients := make([]ClientStats, 0, len(data.Clients))
+	for address, counters := range data.Clients {
+		clients = append(clients, clientStats(address, counters))
+	}
+
+	byBytes := s.ClientsBy == ClientsByBytes
+
+	slices.SortFunc(clients, func(a, b ClientStats) int {
+		first, second := cmp.Compare(b.Requests, a.Requests), cmp.Compare(b.Bytes, a.Bytes)
+		if byBytes {
+			first, second = second, first
+		}
+
+		switch {
+		case first != 0:
+			return first
+		case second != 0:
+			return second
+		default:
+			return strings.Compare(a.Address, b.Address)
+		}
+	})
+
+	limit := s.Top.For(TopClients)
+	if len(clients) <= limit {
+		s.TopClients = clients
+
+		return
+	}
+
+	other := ClientCounters{}
+	for _, client := range clients[limit:] {
+		counters := data.Clients[client.Address]
+		other.Requests += counters.Requests
+		other.Errors += counters.Errors
+		other.Bytes += counters.Bytes
+
+		if other.FirstSeen.IsZero() || (!counters.FirstSeen.IsZero() && counters.FirstSeen.Before(other.FirstSeen)) {
+			other.FirstSeen = counters.FirstSeen
+		}
+
+		if counters.LastSeen.After(other.LastSeen) {
+			other.LastSeen = counters.LastSeen
+		}
+	}
+
+	otherStats := clientStats("", other)
+	otherStats.Other = true
+
+	s.TopClients = append(clients[:limit:limit], otherStats)
+}
+
+func clientStats(address string, counters ClientCounters) ClientStats {
+	stats := ClientStats{
+		Address:   address,
+		Requests:  counters.Requests,
+		Bytes:     counters.Bytes,
+		FirstSeen: counters.FirstSeen,
+		LastSeen:  counters.LastSeen,
+	}
+
+	if counters.Requests > 0 {
+		stats.ErrorRate = float32(counters.Errors) / float32(counters.Requests) * 100
+	}
+
+	return stats
+}
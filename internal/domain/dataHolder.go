@@ -1,9 +1,10 @@
 package domain
 
 import (
-	"regexp"
 	"strconv"
 	"time"
+
+	"LogAnalyzer/pkg/sketch"
 )
 
 const (
@@ -18,20 +19,6 @@ const (
 	HTTPUserAgent = "http_user_agent"
 )
 
-// FilterIndices содержит индексы для соответствующих ключей.
-// Каждый ключ - имя определенной части NGINX лога.
-var FilterIndices = map[string]int{
-	RemoteAddr:    1,
-	RemoteUser:    2,
-	HTTPReq:       4,
-	Resource:      5,
-	HTTPVersion:   6,
-	HTTPCode:      7,
-	BytesSend:     8,
-	HTTPReferer:   9,
-	HTTPUserAgent: 10,
-}
-
 // DataHolder - сырые данные, которые я обрабатываю, хранятся в этой структуре.
 // В дальнейшем они будут обработаны другой структурой чтобы не нарушать SingleResponsibility.
 type DataHolder struct {
@@ -39,53 +26,131 @@ type DataHolder struct {
 	TotalCounter int
 	// Число логов которые мы не смогли распарсить.
 	UnparsedLogs int
-	// Слайс содержащий все размеры ответов - bytesSend, нужен для подсчета среднего ответа и 95-персентиля.
-	BytesSend []int
+	// Число нераспаршенных логов по причинам и несколько примеров таких строк на каждую причину.
+	UnparsedReasons map[string]int
+	UnparsedSamples map[string][]RejectedLine
+	// Число строк, обрезанных до ограничения длины и разобранных по началу, и ошибки чтения источников.
+	TruncatedLines int
+	ReadErrors     []ReadError
+	// Скетч размеров ответов - bytesSend, нужен для подсчета перцентилей. Память не зависит от числа логов,
+	// а точные среднее и стандартное отклонение считаются по моментам.
+	ResponseSizes       *sketch.DDSketch
+	ResponseSizeMoments sketch.Moments
 	// Мапа которая содержит все http запросы к серверу, где ключ - запрос, значение - число таких запросов.
 	HTTPRequests map[string]int
-	// Мапа содержит ключами ресурсы сервера к которым обращались, значениями сколько раз.
+	// Мапа содержит ключами пути ресурсов сервера к которым обращались (без строки запроса), значениями сколько раз.
+	// Если включены шаблоны эндпоинтов, ключами будут шаблоны, например /users/{id}.
 	RequestedResources map[string]int
+	// Мапа содержит ключами имена параметров строки запроса, а значениями их счетчики. QueryRequests - число
+	// запросов со строкой запроса.
+	QueryParams   map[string]*QueryParamCounters
+	QueryRequests int
 	// Мапа содржит ключами коды http ответов, а значениями сколько подобных ответов было.
 	CommonAnswers map[string]int
+	// Скетчи времени обработки запросов в секундах из $request_time и $upstream_response_time, если они есть в формате.
+	RequestTimes  *sketch.DDSketch
+	UpstreamTimes *sketch.DDSketch
+	// Мапа содержит ключами ресурсы, а значениями время обработки запросов к ним, нужна для поиска медленных ресурсов.
+	ResourceLatencies map[string]*sketch.DDSketch
+	// Мапа содержит ключами адреса клиентов ($remote_addr), а значениями их счетчики, нужна для топа клиентов.
+	Clients map[string]ClientCounters
+	// Мапы содержат ключами браузеры, ОС и типы устройств из классификатора User-Agent, а значениями число запросов.
+	// Боты считаются отдельно: по имени в Bots и общим числом запросов в BotRequests.
+	Browsers         map[string]int
+	OperatingSystems map[string]int
+	Devices          map[string]int
+	Bots             map[string]int
+	BotRequests      int
+	// Мапы содержат ключами страны и ASN из баз MaxMind, а значениями счетчики запросов, для ASN отдельно
+	// запоминается организация.
+	Countries        map[string]GeoCounters
+	ASNs             map[string]GeoCounters
+	ASNOrganizations map[string]string
+	// Оценки уникальных клиентов по $remote_addr и $http_user_agent: общая и для каждого ресурса. Скетч заводится
+	// для первых MaxResourceVisitors различных ресурсов, а не только для топа: до 2 КБ на ресурс, не больше ~8 МБ.
+	// Шаблоны эндпоинтов сводят пути к небольшому числу шаблонов, и тогда оценку получают все ресурсы.
+	Visitors         *VisitorSketch
+	ResourceVisitors map[string]*VisitorSketch
+	// Мапа содержит ключами минуты (unix время / 60), а значениями счетчики трафика за минуту, из нее строится
+	// временной ряд. location - часовой пояс первой записи, по нему выравниваются интервалы.
+	Traffic  map[int64]TrafficCounters
+	location *time.Location
+	// Скетчи уникальных клиентов по отрезкам времени длиной VisitorStep минут, ключ - unix время / 60 / VisitorStep.
+	// Скетч занимает до 2 КБ, поэтому шаг растет от минуты до часа, пока скетчей больше maxVisitorCells: память -
+	// не больше ~3 МБ на первые сутки логов и ~50 КБ на каждые следующие.
+	TrafficVisitors map[int64]*VisitorSketch
+	VisitorStep     int64
+	// Счетчики источников логов в порядке разбора, новый источник добавляется в StartSource,
+	// sourceIndex - индекс текущего.
+	Sources     []SourceCounters
+	sourceIndex int
 	// Временные границы, будут стандартным значением если не усановленны (January 1, year 1, 00:00:00 UTC.)
 	From time.Time
 	To   time.Time
-	// Поля для фильтрации в случае если установлены то будет проведена фильтрация поля по значению.
-	filter string
-	value  string
+	// Парсер формата строк лога и запись, в которую он раскладывает очередную строку.
+	parser LineParser
+	entry  LogEntry
+	// Текущий источник и номер строки в нем, нужны чтобы указать откуда взялась нераспаршенная строка.
+	source     string
+	lineNumber int
+	quarantine RejectSink
+	// Фильтр записей, если установлен то в статистику попадут только подходящие под него записи.
+	filter Filter
+	// Обогащения записей, применяются после разбора строки и до фильтра.
+	enrichers []Enricher
 }
 
-// NewDataHolder - принимает параметрами timeFrom и timeTo, и инициализирует map`ы которые потом пригодятся для анализа.
-// На go.dev написано, что "нулевое значение", для time.Time это January 1, year 1, 00:00:00 UTC.
+// Filter - условие отбора записей, реализуется выражениями из пакета filter.
+type Filter interface {
+	Match(entry *LogEntry) bool
+}
+
+// NewDataHolder - принимает парсер формата логов и фильтр (nil - без фильтрации), и инициализирует map`ы которые потом
+// пригодятся для анализа. На go.dev написано, что "нулевое значение", для time.Time это January 1, year 1, 00:00:00 UTC.
 // Это удобно тк в мы сможем воспользоваться в методе Parser при проверке заданы ли вообще временные рамки для логов.
-func NewDataHolder(fieldToFilter, valueToFilter string) *DataHolder {
+func NewDataHolder(parser LineParser, filter Filter) *DataHolder {
 	return &DataHolder{
 		HTTPRequests:       make(map[string]int, 9),  // в http 1.1 определенно 9 стандартных методов, р
 		RequestedResources: make(map[string]int),     // решил указать тк на лекциях сказали что в рантайме может сказаться на производительности
 		CommonAnswers:      make(map[string]int, 63), // вроде как существует 63 стандартных кода ответа
-		filter:             fieldToFilter,
-		value:              valueToFilter,
+		ResponseSizes:      sketch.NewDDSketch(sketch.DefaultRelativeAccuracy),
+		RequestTimes:       sketch.NewDDSketch(sketch.DefaultRelativeAccuracy),
+		UpstreamTimes:      sketch.NewDDSketch(sketch.DefaultRelativeAccuracy),
+		ResourceLatencies:  make(map[string]*sketch.DDSketch),
+		Traffic:            make(map[int64]TrafficCounters),
+		TrafficVisitors:    make(map[int64]*VisitorSketch),
+		VisitorStep:        1,
+		Visitors:           newVisitorSketch(sketch.DefaultPrecision),
+		ResourceVisitors:   make(map[string]*VisitorSketch),
+		Clients:            make(map[string]ClientCounters),
+		Browsers:           make(map[string]int),
+		OperatingSystems:   make(map[string]int),
+		Devices:            make(map[string]int),
+		Bots:               make(map[string]int),
+		QueryParams:        make(map[string]*QueryParamCounters),
+		Countries:          make(map[string]GeoCounters),
+		ASNs:               make(map[string]GeoCounters),
+		ASNOrganizations:   make(map[string]string),
+		UnparsedReasons:    make(map[string]int),
+		UnparsedSamples:    make(map[string][]RejectedLine),
+		parser:             parser,
+		filter:             filter,
 	}
 }
 
-// Parse метод структуры DataHolder, принимает строку singleLog в качестве аргумента, и пытается с помощью регулярного
-// выражения, разбить на подстроки уже пригодные для анализа.
+// Parse метод структуры DataHolder, принимает строку singleLog в качестве аргумента, и разбирает ее парсером формата
+// на переменные, к которым дальше обращается по имени.
 func (s *DataHolder) Parse(singleLog string, timeFrom, timeTo time.Time) {
-	logsFormat := regexp.MustCompile("^(\\S+) - (\\S*) \\[(.*?)] \"(\\S+) (\\S+) (\\S+)\" (\\d{3}) (\\d+) \"(.*?)\" \"(.*?)\"$")
-	matches := logsFormat.FindStringSubmatch(singleLog)
+	s.lineNumber++
 
-	if matches == nil {
-		s.UnparsedLogs++
-		return
-	}
-
-	logTime, err := time.Parse("02/Jan/2006:15:04:05 -0700", matches[3])
-	if err != nil {
-		s.UnparsedLogs++
+	if err := s.parser.Parse(singleLog, &s.entry); err != nil {
+		s.reject(singleLog, err)
 
 		return
 	}
 
+	logTime := s.entry.Time
+
 	// Проверка попадает ли лог в выбранный временной промежуток если он задан
 	if (!timeFrom.IsZero() && logTime.Before(timeFrom)) || (!timeTo.IsZero() && logTime.After(timeTo)) {
 		return
@@ -100,18 +165,111 @@ func (s *DataHolder) Parse(singleLog string, timeFrom, timeTo time.Time) {
 		s.To = logTime
 	}
 
-	if s.filter != "" {
-		if idx, exists := FilterIndices[s.filter]; exists {
-			if idx < len(matches) && matches[idx] != s.value {
-				return
+	for _, enricher := range s.enrichers {
+		enricher.Enrich(&s.entry)
+	}
+
+	if s.filter != nil && !s.filter.Match(&s.entry) {
+		return
+	}
+
+	s.TotalCounter++
+	s.collect(logTime)
+}
+
+// collect - учитывает прошедшую фильтр запись во всех счетчиках.
+func (s *DataHolder) collect(logTime time.Time) {
+	if method, ok := s.entry.Get(HTTPReq); ok {
+		s.HTTPRequests[method]++
+	}
+
+	uri, hasURI := s.entry.Get(Resource)
+	if hasURI {
+		s.collectQueryParams(uri)
+	}
+
+	resource, hasResource := s.entry.Get(Endpoint)
+	if !hasResource && hasURI {
+		resource, _ = SplitURI(uri)
+		hasResource = true
+	}
+
+	if hasResource {
+		s.RequestedResources[resource]++
+	}
+
+	var answerCode, bytesInSingleLog int
+
+	if code, ok := s.entry.Get(HTTPCode); ok {
+		s.CommonAnswers[code]++
+		answerCode, _ = strconv.Atoi(code)
+	}
+
+	if bytes, ok := s.entry.Get(BytesSend); ok {
+		// Значение "-" (пустое тело ответа) считаем нулем
+		bytesInSingleLog, _ = strconv.Atoi(bytes)
+		s.AddResponseSize(bytesInSingleLog)
+	}
+
+	s.collectLatency(resource, hasResource)
+	s.collectSource(answerCode, bytesInSingleLog)
+	s.collectUserAgent()
+	s.collectGeo(bytesInSingleLog)
+
+	if address, ok := s.entry.Get(RemoteAddr); ok {
+		s.collectClient(address, logTime, answerCode, bytesInSingleLog)
+	}
+
+	var client *clientHashes
+
+	if hashes, ok := s.clientOf(); ok {
+		client = &hashes
+		s.collectVisitors(hashes, resource, hasResource)
+	}
+
+	s.collectTraffic(logTime, answerCode, bytesInSingleLog, client)
+}
+
+// collectLatency - сохраняет время обработки запроса. Для поиска медленных ресурсов берется $request_time,
+// а если его нет в формате - время ответа upstream.
+func (s *DataHolder) collectLatency(resource string, hasResource bool) {
+	var (
+		latency    float64
+		hasLatency bool
+	)
+
+	if value, ok := s.entry.Get(RequestTime); ok {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+			s.RequestTimes.Add(seconds)
+			latency, hasLatency = seconds, true
+		}
+	}
+
+	if value, ok := s.entry.Get(UpstreamResponseTime); ok {
+		if seconds, found := parseUpstreamTime(value); found {
+			s.UpstreamTimes.Add(seconds)
+
+			if !hasLatency {
+				latency, hasLatency = seconds, true
 			}
 		}
 	}
 
-	s.TotalCounter++
-	s.HTTPRequests[matches[4]]++
-	s.RequestedResources[matches[5]]++
-	bytesInSingleLog, _ := strconv.Atoi(matches[8])
-	s.BytesSend = append(s.BytesSend, bytesInSingleLog)
-	s.CommonAnswers[matches[7]]++
+	if hasLatency && hasResource {
+		resourceLatency, ok := s.ResourceLatencies[resource]
+		if !ok {
+			resourceLatency = sketch.NewDDSketch(sketch.DefaultRelativeAccuracy)
+			s.ResourceLatencies[resource] = resourceLatency
+		}
+
+		resourceLatency.Add(latency)
+	}
+}
+
+// AddResponseSize - учитывает размер одного ответа.
+func (s *DataHolder) AddResponseSize(bytes int) {
+	bytes = max(bytes, 0)
+
+	s.ResponseSizes.Add(float64(bytes))
+	s.ResponseSizeMoments.Add(uint64(bytes))
 }
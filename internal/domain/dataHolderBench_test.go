@@ -0,0 +1,86 @@
+package domain_test
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/logformats"
+)
+
+var benchmarkLines = []string{
+	`80.91.33.133 - - [17/May/2015:08:05:24 +0000] "GET /downloads/product_1 HTTP/1.1" 304 0 "-" ` +
+		`"Debian APT-HTTP/1.3 (0.8.16~exp12ubuntu10.17)"`,
+	`217.168.17.5 - - [17/May/2015:08:05:34 +0000] "GET /downloads/product_1 HTTP/1.1" 200 490 "-" ` +
+		`"Debian APT-HTTP/1.3 (0.8.10.3)"`,
+	`93.180.71.3 - - [17/May/2015:08:05:57 +0000] "GET /downloads/product_2 HTTP/1.1" 404 337 ` +
+		`"https://example.com/start" "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko)"`,
+	`188.138.60.101 - - [17/May/2015:08:06:03 +0000] "POST /api/v1/items HTTP/1.1" 201 53 "-" "curl/8.4.0"`,
+}
+
+// legacyParse - разбор строки так, как DataHolder.Parse работал до появления форматов и токенизатора:
+// регулярное выражение компилируется на каждой строке, время разбирается через time.Parse.
+// Используется только как точка отсчета для сравнения.
+func legacyParse(line string) bool {
+	logsFormat := regexp.MustCompile("^(\\S+) - (\\S*) \\[(.*?)] \"(\\S+) (\\S+) (\\S+)\" (\\d{3}) (\\d+) \"(.*?)\" \"(.*?)\"$")
+
+	matches := logsFormat.FindStringSubmatch(line)
+	if matches == nil {
+		return false
+	}
+
+	if _, err := time.Parse("02/Jan/2006:15:04:05 -0700", matches[3]); err != nil {
+		return false
+	}
+
+	_, err := strconv.Atoi(matches[8])
+
+	return err == nil
+}
+
+func reportLinesPerSecond(b *testing.B) {
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "lines/s")
+}
+
+// Одна итерация бенчмарка - одна строка лога, поэтому allocs/op равен числу аллокаций на строку.
+func BenchmarkParse_LegacyRegexPerLine(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		legacyParse(benchmarkLines[i%len(benchmarkLines)])
+	}
+
+	reportLinesPerSecond(b)
+}
+
+func BenchmarkParse_RegexFormat(b *testing.B) {
+	parser, err := logformats.NewNginx(logformats.NginxCombined)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	benchmarkDataHolder(b, parser)
+}
+
+func BenchmarkParse_CombinedTokenizer(b *testing.B) {
+	benchmarkDataHolder(b, logformats.NewCombined())
+}
+
+func benchmarkDataHolder(b *testing.B, parser domain.LineParser) {
+	data := domain.NewDataHolder(parser, nil)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		data.Parse(benchmarkLines[i%len(benchmarkLines)], time.Time{}, time.Time{})
+	}
+
+	reportLinesPerSecond(b)
+
+	if data.UnparsedLogs != 0 {
+		b.Fatalf("unexpected unparsed logs: %d", data.UnparsedLogs)
+	}
+}
@@ -1,12 +1,18 @@
 package domain_test
 
 import (
+	stderrors "errors"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+	"LogAnalyzer/internal/domain/logformats"
+	"LogAnalyzer/pkg/sketch"
 )
 
 func TestDataHolder_Parser(t *testing.T) {
@@ -14,6 +20,7 @@ func TestDataHolder_Parser(t *testing.T) {
 		testScenario string
 		logs         []string
 		parsedData   domain.DataHolder
+		bytesSend    []uint64
 		to           string
 		from         string
 	}{
@@ -30,7 +37,6 @@ func TestDataHolder_Parser(t *testing.T) {
 			parsedData: domain.DataHolder{
 				TotalCounter: 3,
 				UnparsedLogs: 0,
-				BytesSend:    []int{0, 0, 0},
 				RequestedResources: map[string]int{
 					"/downloads/product_1": 3,
 				},
@@ -38,6 +44,7 @@ func TestDataHolder_Parser(t *testing.T) {
 					"304": 3,
 				},
 			},
+			bytesSend: []uint64{0, 0, 0},
 		},
 		{
 			testScenario: "corrupted logs",
@@ -52,7 +59,6 @@ func TestDataHolder_Parser(t *testing.T) {
 			parsedData: domain.DataHolder{
 				TotalCounter:       0,
 				UnparsedLogs:       3,
-				BytesSend:          nil,
 				RequestedResources: map[string]int{},
 				CommonAnswers:      map[string]int{},
 			},
@@ -76,12 +82,12 @@ func TestDataHolder_Parser(t *testing.T) {
 			parsedData: domain.DataHolder{
 				TotalCounter:       4,
 				UnparsedLogs:       0,
-				BytesSend:          []int{0, 490, 490, 0},
 				RequestedResources: map[string]int{"/downloads/product_1": 3, "/downloads/product_2": 1},
 				CommonAnswers:      map[string]int{"304": 2, "200": 2},
 			},
-			to:   "17/May/2015:08:08:24 +0000",
-			from: "17/May/2015:08:05:57 +0000",
+			bytesSend: []uint64{0, 490, 490, 0},
+			to:        "17/May/2015:08:08:24 +0000",
+			from:      "17/May/2015:08:05:57 +0000",
 		},
 	}
 
@@ -89,7 +95,10 @@ func TestDataHolder_Parser(t *testing.T) {
 		t.Run(tc.testScenario, func(tt *testing.T) {
 			tt.Parallel()
 
-			data := domain.NewDataHolder("", "")
+			parser, err := logformats.NewNginx(logformats.NginxCombined)
+			assert.NoError(tt, err)
+
+			data := domain.NewDataHolder(parser, nil)
 
 			for _, log := range tc.logs {
 				to, _ := time.Parse("02/Jan/2006:15:04:05 -0700", tc.to)
@@ -99,9 +108,210 @@ func TestDataHolder_Parser(t *testing.T) {
 
 			assert.Equal(t, data.TotalCounter, tc.parsedData.TotalCounter)
 			assert.Equal(t, data.UnparsedLogs, tc.parsedData.UnparsedLogs)
-			assert.Equal(t, data.BytesSend, tc.parsedData.BytesSend)
+			var moments sketch.Moments
+			for _, size := range tc.bytesSend {
+				moments.Add(size)
+			}
+
+			assert.Equal(t, moments, data.ResponseSizeMoments)
+			assert.Equal(t, uint64(len(tc.bytesSend)), data.ResponseSizes.Count())
 			assert.Equal(t, data.RequestedResources, tc.parsedData.RequestedResources)
 			assert.Equal(t, data.CommonAnswers, tc.parsedData.CommonAnswers)
 		})
 	}
 }
+
+type rejectRecorder struct {
+	lines []domain.RejectedLine
+}
+
+func (r *rejectRecorder) Reject(line domain.RejectedLine) {
+	r.lines = append(r.lines, line)
+}
+
+func TestDataHolder_RejectedLines(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	sink := &rejectRecorder{}
+	data.SetQuarantine(sink)
+	data.StartSource("access.log")
+
+	logs := []string{
+		"80.91.33.133 - - [17/May/2015:08:05:24 +0000] \"GET /downloads/product_1 HTTP/1.1\" 304 0 \"-\" \"APT\"",
+		"garbage",
+		"93.180.71.3 - - [May/2015:08:05:23 +0000] \"GET /downloads/product_1 HTTP/1.1\" 304 0 \"-\" \"APT\"",
+		"80.91.33.133 - - [17/May/2015:08:05:24 +0000] \"/downloads/product_1\" 304 0 \"-\" \"APT\"",
+		"more garbage",
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	data.StartSource("other.log")
+	data.Parse("garbage again", time.Time{}, time.Time{})
+
+	assert.Equal(t, 5, data.UnparsedLogs)
+	assert.Equal(t, map[string]int{"format_mismatch": 3, "bad_timestamp": 1, "malformed_request": 1}, data.UnparsedReasons)
+	assert.Equal(t, []domain.RejectedLine{
+		{Source: "access.log", Line: 2, Reason: "format_mismatch", Text: "garbage"},
+		{Source: "access.log", Line: 3, Reason: "bad_timestamp", Text: logs[2]},
+		{Source: "access.log", Line: 4, Reason: "malformed_request", Text: logs[3]},
+		{Source: "access.log", Line: 5, Reason: "format_mismatch", Text: "more garbage"},
+		{Source: "other.log", Line: 1, Reason: "format_mismatch", Text: "garbage again"},
+	}, sink.lines)
+
+	statistic := &domain.Statistic{}
+	statistic.Fill(data)
+
+	assert.Equal(t, []domain.KeyCount{
+		{Value: "format_mismatch", Count: 3}, {Value: "bad_timestamp", Count: 1}, {Value: "malformed_request", Count: 1},
+	}, statistic.ParseQuality.Reasons)
+	assert.Len(t, statistic.ParseQuality.Samples, 5)
+	assert.Equal(t, "format_mismatch", statistic.ParseQuality.Samples[0].Reason)
+}
+
+func TestDataHolder_LongLinesAndReadErrors(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	sink := &rejectRecorder{}
+	data.SetQuarantine(sink)
+	data.StartSource("access.log")
+
+	data.Parse(`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 100 "-" "curl/8.0"`, time.Time{}, time.Time{})
+	data.RejectLine("10.0.0.1 - - [17/May", errors.ErrLineTooLong{})
+	data.CountTruncated()
+	data.Parse(`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET /b HTTP/1.1" 200 100 "-" "curl/8.0"`, time.Time{}, time.Time{})
+	data.RecordReadError(stderrors.New("unexpected EOF"))
+
+	assert.Equal(t, []domain.RejectedLine{
+		{Source: "access.log", Line: 2, Reason: "line_too_long", Text: "10.0.0.1 - - [17/May"},
+	}, sink.lines)
+
+	other := domain.NewDataHolder(logformats.NewCombined(), nil)
+	other.StartSource("other.log")
+	other.RecordReadError(stderrors.New("permission denied"))
+	other.CountTruncated()
+	require.NoError(t, data.Merge(other))
+
+	statistic := &domain.Statistic{}
+	statistic.Fill(data)
+
+	assert.Equal(t, 2, statistic.LogsMetrics.ProcessedLogs)
+	assert.Equal(t, []domain.KeyCount{{Value: "line_too_long", Count: 1}}, statistic.ParseQuality.Reasons)
+	assert.Equal(t, 2, statistic.ParseQuality.Truncated)
+	assert.Equal(t, []domain.ReadError{
+		{Source: "access.log", Line: 3, Error: "unexpected EOF"},
+		{Source: "other.log", Line: 0, Error: "permission denied"},
+	}, statistic.ParseQuality.ReadErrors)
+}
+
+func TestDataHolder_Sources(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+
+	data.StartSource("access.log")
+	data.Parse(`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 100 "-" "curl/8.0"`, time.Time{}, time.Time{})
+	data.Parse(`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 500 50 "-" "curl/8.0"`, time.Time{}, time.Time{})
+	data.Parse("garbage", time.Time{}, time.Time{})
+
+	data.StartSource("stdin")
+	data.Parse(`10.0.0.2 - - [17/May/2015:08:02:00 +0000] "GET /b HTTP/1.1" 200 10 "-" "curl/8.0"`, time.Time{}, time.Time{})
+
+	// Без флага разбивка не заполняется
+	statistic := &domain.Statistic{}
+	statistic.Fill(data)
+	assert.Empty(t, statistic.Sources)
+
+	statistic = &domain.Statistic{BySource: true}
+	statistic.Fill(data)
+
+	assert.Equal(t, []domain.SourceStats{
+		{Name: "access.log", Requests: 2, Unparsed: 1, Bytes: 150, Share: float32(2) / 3 * 100, ErrorRate: 50},
+		{Name: "stdin", Requests: 1, Bytes: 10, Share: float32(1) / 3 * 100},
+	}, statistic.Sources)
+}
+
+func TestDataHolder_MarshalBinary(t *testing.T) {
+	logs := []string{
+		`10.0.0.1 - - [17/May/2015:08:01:00 +0300] "GET /a?page=1 HTTP/1.1" 200 100 "-" "curl/8.0"`,
+		`10.0.0.2 - - [17/May/2015:08:02:00 +0300] "GET /b HTTP/1.1" 500 50 "-" "Mozilla/5.0"`,
+		"garbage",
+		`10.0.0.1 - - [17/May/2015:09:15:00 +0300] "POST /a?page=2 HTTP/1.1" 404 0 "-" "curl/8.0"`,
+		`10.0.0.3 - - [17/May/2015:10:30:00 +0300] "GET /c HTTP/1.1" 200 4096 "-" "curl/8.0"`,
+	}
+
+	whole := domain.NewDataHolder(logformats.NewCombined(), nil)
+	whole.StartSource("access.log")
+
+	for _, log := range logs {
+		whole.Parse(log, time.Time{}, time.Time{})
+	}
+
+	// Первая часть разбирается в одном запуске, сохраняется, а остальное дочитывает следующий запуск
+	first := domain.NewDataHolder(logformats.NewCombined(), nil)
+	first.StartSource("access.log")
+
+	for _, log := range logs[:3] {
+		first.Parse(log, time.Time{}, time.Time{})
+	}
+
+	state, err := first.MarshalBinary()
+	require.NoError(t, err)
+
+	resumed := domain.NewDataHolder(logformats.NewCombined(), nil)
+	require.NoError(t, resumed.UnmarshalBinary(state))
+	resumed.StartSource("access.log")
+	resumed.SkipLines(3)
+
+	for _, log := range logs[3:] {
+		resumed.Parse(log, time.Time{}, time.Time{})
+	}
+
+	expected := &domain.Statistic{BySource: true}
+	expected.Fill(whole)
+
+	actual := &domain.Statistic{BySource: true}
+	actual.Fill(resumed)
+
+	assert.Equal(t, expected, actual)
+	assert.Error(t, resumed.UnmarshalBinary([]byte("garbage")))
+}
+
+func TestDataHolder_Merge(t *testing.T) {
+	files := [][]string{
+		{
+			`10.0.0.1 - - [17/May/2015:08:01:00 +0300] "GET /a?page=1 HTTP/1.1" 200 100 "-" "curl/8.0"`,
+			"garbage",
+			`10.0.0.2 - - [17/May/2015:08:02:00 +0300] "GET /b HTTP/1.1" 500 50 "-" "Mozilla/5.0"`,
+		},
+		{
+			`10.0.0.1 - - [17/May/2015:07:15:00 +0300] "POST /a?page=2&q=x HTTP/1.1" 404 0 "-" "curl/8.0"`,
+			"more garbage",
+			`10.0.0.3 - - [17/May/2015:10:30:00 +0300] "GET /c HTTP/1.1" 200 4096 "-" "curl/8.0"`,
+		},
+	}
+
+	serial := domain.NewDataHolder(logformats.NewCombined(), nil)
+	merged := domain.NewDataHolder(logformats.NewCombined(), nil)
+
+	for i, logs := range files {
+		name := "access.log." + strconv.Itoa(i)
+		serial.StartSource(name)
+
+		part := domain.NewDataHolder(logformats.NewCombined(), nil)
+		part.StartSource(name)
+
+		for _, log := range logs {
+			serial.Parse(log, time.Time{}, time.Time{})
+			part.Parse(log, time.Time{}, time.Time{})
+		}
+
+		require.NoError(t, merged.Merge(part))
+	}
+
+	expected := &domain.Statistic{BySource: true}
+	expected.Fill(serial)
+
+	actual := &domain.Statistic{BySource: true}
+	actual.Fill(merged)
+
+	assert.Equal(t, expected, actual)
+}
@@ -0,0 +1,215 @@
+// Package endpoint - сворачивает пути запросов в шаблоны эндпоинтов: числовые идентификаторы, UUID и хеши
+// заменяются плейсхолдерами, например /users/42/orders -> /users/{id}/orders.
+package endpoint
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+	"sync"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// Плейсхолдеры, которыми заменяются сегменты пути.
+const (
+	IDPlaceholder   = "{id}"
+	UUIDPlaceholder = "{uuid}"
+	HashPlaceholder = "{hash}"
+)
+
+const (
+	// minHashLength - с какой длины шестнадцатеричная строка считается хешем: ObjectID MongoDB - 24 символа,
+	// MD5 - 32, SHA-1 - 40, более короткие строки легко спутать со словами вроде "cafe" или "facade".
+	minHashLength = 16
+	// uuidLength - длина UUID в каноническом виде 8-4-4-4-12.
+	uuidLength = 36
+	// maxCacheSize - сколько различных путей запоминается, кеш сбрасывается при переполнении.
+	maxCacheSize = 16384
+)
+
+// Rule - пользовательское правило переписывания: все совпадения Pattern в пути заменяются на Replacement,
+// в котором можно ссылаться на группы через $1, $2...
+type Rule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// Templater - переводит пути в шаблоны эндпоинтов, реализует domain.Enricher. Сначала по порядку применяются
+// правила, затем, если включено, автоматическая замена идентификаторов. Шаблон строится по раскодированному
+// пути, строка запроса отбрасывается.
+// Безопасен для одновременного использования из нескольких горутин.
+type Templater struct {
+	rules []Rule
+	auto  bool
+	mu    sync.RWMutex
+	cache map[string]string
+}
+
+// New - создает шаблонизатор с правилами rules, auto включает автоматическую замену идентификаторов.
+func New(rules []Rule, auto bool) *Templater {
+	return &Templater{rules: rules, auto: auto, cache: make(map[string]string)}
+}
+
+// ParseRules - разбирает правила переписывания: по одному на строку, регулярное выражение и замена через пробел.
+// Пустые строки и строки, начинающиеся с #, пропускаются.
+func ParseRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, errors.ErrRewriteRule{Line: lineNumber, Reason: "expected pattern and replacement separated by space"}
+		}
+
+		pattern, err := regexp.Compile(fields[0])
+		if err != nil {
+			return nil, errors.ErrRewriteRule{Line: lineNumber, Reason: err.Error()}
+		}
+
+		rules = append(rules, Rule{Pattern: pattern, Replacement: fields[1]})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.ErrRewriteRule{Reason: err.Error()}
+	}
+
+	return rules, nil
+}
+
+// Fields - поля, которые добавляет Enrich.
+func (t *Templater) Fields() []string {
+	return []string{domain.Path, domain.Query, domain.Endpoint}
+}
+
+// Enrich - раскладывает $request_uri записи на путь и строку запроса и записывает шаблон эндпоинта,
+// если $request_uri нет - запись не меняется.
+func (t *Templater) Enrich(entry *domain.LogEntry) {
+	resource, ok := entry.Get(domain.Resource)
+	if !ok {
+		return
+	}
+
+	path, query := domain.SplitURI(resource)
+
+	entry.Set(domain.Path, path)
+	entry.Set(domain.Query, query)
+	entry.Set(domain.Endpoint, t.templatePath(path))
+}
+
+// Template - шаблон эндпоинта для URI.
+func (t *Templater) Template(resource string) string {
+	path, _ := domain.SplitURI(resource)
+
+	return t.templatePath(path)
+}
+
+func (t *Templater) templatePath(path string) string {
+	t.mu.RLock()
+	template, ok := t.cache[path]
+	t.mu.RUnlock()
+
+	if ok {
+		return template
+	}
+
+	template = t.template(path)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.cache) >= maxCacheSize {
+		clear(t.cache)
+	}
+
+	t.cache[strings.Clone(path)] = template
+
+	return template
+}
+
+func (t *Templater) template(path string) string {
+	for _, rule := range t.rules {
+		path = rule.Pattern.ReplaceAllString(path, rule.Replacement)
+	}
+
+	if !t.auto {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = placeholder(segment)
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// placeholder - плейсхолдер для сегмента пути или сам сегмент, если он не похож на идентификатор.
+func placeholder(segment string) string {
+	switch {
+	case segment == "":
+		return segment
+	case isDigits(segment):
+		return IDPlaceholder
+	case isUUID(segment):
+		return UUIDPlaceholder
+	case len(segment) >= minHashLength && isHex(segment):
+		return HashPlaceholder
+	default:
+		return segment
+	}
+}
+
+func isDigits(segment string) bool {
+	for i := range len(segment) {
+		if segment[i] < '0' || segment[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isHex(segment string) bool {
+	for i := range len(segment) {
+		if !isHexByte(segment[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isHexByte(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isUUID(segment string) bool {
+	if len(segment) != uuidLength {
+		return false
+	}
+
+	for i := range len(segment) {
+		if i == 8 || i == 13 || i == 18 || i == 23 {
+			if segment[i] != '-' {
+				return false
+			}
+
+			continue
+		}
+
+		if !isHexByte(segment[i]) {
+			return false
+		}
+	}
+
+	return true
+}
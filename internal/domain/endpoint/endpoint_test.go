@@ -0,0 +1,90 @@
+package endpoint_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/endpoint"
+	"LogAnalyzer/internal/domain/logformats"
+)
+
+func TestTemplate(t *testing.T) {
+	templater := endpoint.New(nil, true)
+
+	tests := []struct {
+		resource string
+		want     string
+	}{
+		{"/", "/"},
+		{"/users/42", "/users/{id}"},
+		{"/users/42/orders/7?expand=items", "/users/{id}/orders/{id}"},
+		{"/files/3f2504e0-4f89-11d3-9a0c-0305e82c3301", "/files/{uuid}"},
+		{"/static/app.9b1d2c3e4f5a6b7c8d9e0f1a2b3c4d5e.js", "/static/app.9b1d2c3e4f5a6b7c8d9e0f1a2b3c4d5e.js"},
+		{"/blobs/d41d8cd98f00b204e9800998ecf8427e", "/blobs/{hash}"},
+		{"/api/v2/facade", "/api/v2/facade"},
+		{"/users/me", "/users/me"},
+		{"/users/42/files/a%2Fb", "/users/{id}/files/a%2Fb"},
+		{"/users/42%2F7", "/users/42%2F7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			assert.Equal(t, tt.want, templater.Template(tt.resource))
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := endpoint.ParseRules([]byte(`
+# версии ассетов
+^/static/.*\.(js|css)$ /static/*.$1
+^/u/([^/]+)$           /users/{name}
+`))
+	require.NoError(t, err)
+
+	templater := endpoint.New(rules, true)
+
+	assert.Equal(t, "/static/*.js", templater.Template("/static/app.3f9a1c.js"))
+	assert.Equal(t, "/users/{name}", templater.Template("/u/alice"))
+	assert.Equal(t, "/users/{id}", templater.Template("/users/1"))
+
+	// Без автоматической замены применяются только правила
+	assert.Equal(t, "/users/1", endpoint.New(rules, false).Template("/users/1?a=b"))
+
+	_, err = endpoint.ParseRules([]byte("^/a(\n"))
+	assert.Error(t, err)
+
+	_, err = endpoint.ParseRules([]byte("^/a /b /c\n"))
+	assert.Error(t, err)
+}
+
+func TestTemplatedResources(t *testing.T) {
+	data := domain.NewDataHolder(logformats.NewCombined(), nil)
+	data.AddEnricher(endpoint.New(nil, true))
+
+	for _, resource := range []string{"/users/1", "/users/2", "/users/3", "/health"} {
+		data.Parse(`10.0.0.1 - - [17/May/2015:08:01:00 +0000] "GET `+resource+` HTTP/1.1" 200 1 "-" "curl/8.0"`,
+			time.Time{}, time.Time{})
+	}
+
+	assert.Equal(t, map[string]int{"/users/{id}": 3, "/health": 1}, data.RequestedResources)
+}
+
+func TestEnrich(t *testing.T) {
+	var entry domain.LogEntry
+
+	entry.Set(domain.RequestURI, "/users/42/caf%C3%A9?q=a+b")
+	endpoint.New(nil, true).Enrich(&entry)
+
+	path, _ := entry.Get(domain.Path)
+	query, _ := entry.Get(domain.Query)
+	template, _ := entry.Get(domain.Endpoint)
+
+	assert.Equal(t, "/users/42/café", path)
+	assert.Equal(t, "q=a b", query)
+	assert.Equal(t, "/users/{id}/café", template)
+}
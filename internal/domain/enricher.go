@@ -0,0 +1,53 @@
+package domain
+
+// Поля, которые добавляет классификатор User-Agent. UABot равно "true" для известных ботов и краулеров,
+// для них UABrowser содержит имя бота, а UADevice - DeviceBot.
+const (
+	UABrowser        = "ua_browser"
+	UABrowserVersion = "ua_browser_version"
+	UAOS             = "ua_os"
+	UAOSVersion      = "ua_os_version"
+	UADevice         = "ua_device"
+	UABot            = "ua_bot"
+)
+
+// Поля, которые добавляет обогащение по базам MaxMind: страна (ISO код), город, номер автономной системы
+// в виде AS13335 и ее организация. Если адрес не найден в базах, значения - пустые строки.
+const (
+	GeoCountry      = "geo_country"
+	GeoCity         = "geo_city"
+	GeoASN          = "geo_asn"
+	GeoOrganization = "geo_as_org"
+)
+
+// Endpoint - шаблон эндпоинта, в который свернут путь запроса, например /users/{id}. Если поле есть в записи,
+// статистика по ресурсам считается по нему, а не по исходному пути.
+const Endpoint = "endpoint"
+
+// GeoUnknown - страна или ASN для адресов, которых нет в базах.
+const GeoUnknown = "unknown"
+
+// Классы устройств User-Agent.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceTV      = "tv"
+	DeviceConsole = "console"
+	DeviceBot     = "bot"
+	DeviceOther   = "other"
+)
+
+// Enricher - дополняет разобранную запись вычисляемыми полями, например классом User-Agent.
+// Поля обогащения доступны фильтру и отчетам так же, как переменные формата.
+type Enricher interface {
+	// Enrich дописывает поля в запись, вызывается после разбора строки и до фильтра.
+	Enrich(entry *LogEntry)
+	// Fields возвращает имена полей, которые добавляет Enrich.
+	Fields() []string
+}
+
+// AddEnricher - добавляет обогащение записей, обогащения применяются в порядке добавления.
+func (s *DataHolder) AddEnricher(enricher Enricher) {
+	s.enrichers = append(s.enrichers, enricher)
+}
@@ -1,5 +1,7 @@
 package errors
 
+import "fmt"
+
 type ErrOpenFile struct{}
 
 func (e ErrOpenFile) Error() string {
@@ -42,6 +44,8 @@ func (e ErrTimeParsing) Error() string {
 	return "err parsing time"
 }
 
+func (e ErrTimeParsing) Reason() string { return "bad_timestamp" }
+
 type ErrWrongTimeBoundaries struct{}
 
 func (e ErrWrongTimeBoundaries) Error() string { return "to before from" }
@@ -83,3 +87,131 @@ type ErrOutPut struct{}
 func (e ErrOutPut) Error() string {
 	return "output error"
 }
+
+type ErrUnknownLogFormat struct{}
+
+func (e ErrUnknownLogFormat) Error() string { return "unknown log format" }
+
+// ErrLogFormatSyntax - ошибка в строке формата лога, Reason указывает что именно не так.
+type ErrLogFormatSyntax struct {
+	Reason string
+}
+
+func (e ErrLogFormatSyntax) Error() string { return "log format syntax error: " + e.Reason }
+
+type ErrLineFormatMismatch struct{}
+
+func (e ErrLineFormatMismatch) Error() string { return "line does not match log format" }
+
+func (e ErrLineFormatMismatch) Reason() string { return "format_mismatch" }
+
+type ErrMalformedRequest struct{}
+
+func (e ErrMalformedRequest) Error() string { return "malformed request line" }
+
+func (e ErrMalformedRequest) Reason() string { return "malformed_request" }
+
+// ErrFilterSyntax - ошибка в выражении фильтра, Position - смещение в байтах, на котором она обнаружена.
+type ErrFilterSyntax struct {
+	Position int
+	Message  string
+}
+
+func (e ErrFilterSyntax) Error() string {
+	return fmt.Sprintf("filter error at position %d: %s", e.Position, e.Message)
+}
+
+type ErrInvalidTop struct{}
+
+func (e ErrInvalidTop) Error() string {
+	return "invalid top setting, expected N or section=N for requests, resources, codes, slowest, clients, " +
+		"browsers, os, devices, bots, countries, asns, params"
+}
+
+type ErrInvalidPercentiles struct{}
+
+func (e ErrInvalidPercentiles) Error() string {
+	return "invalid percentiles, expected comma separated numbers in (0, 100], e.g. 50,90,99.9"
+}
+
+type ErrInvalidBucket struct{}
+
+func (e ErrInvalidBucket) Error() string {
+	return "invalid bucket, expected auto or a whole number of minutes, hours or days, e.g. 5m, 1h, 1d"
+}
+
+type ErrInvalidClientsBy struct{}
+
+func (e ErrInvalidClientsBy) Error() string {
+	return "invalid clients ranking, expected requests or bytes"
+}
+
+// ErrUserAgentDatabase - ошибка в базе регулярных выражений классификатора User-Agent.
+type ErrUserAgentDatabase struct {
+	Reason string
+}
+
+func (e ErrUserAgentDatabase) Error() string { return "user agent database error: " + e.Reason }
+
+// ErrGeoDatabase - базу .mmdb не удалось открыть или прочитать.
+type ErrGeoDatabase struct {
+	Path   string
+	Reason string
+}
+
+func (e ErrGeoDatabase) Error() string { return "geo database " + e.Path + ": " + e.Reason }
+
+// ErrRewriteRule - ошибка в файле правил переписывания путей, Line - номер строки.
+type ErrRewriteRule struct {
+	Line   int
+	Reason string
+}
+
+func (e ErrRewriteRule) Error() string {
+	return fmt.Sprintf("rewrite rule at line %d: %s", e.Line, e.Reason)
+}
+
+// ErrFollowSource - в режиме слежения можно следить только за файлами, а не за ссылками и стандартным вводом.
+type ErrFollowSource struct {
+	Source string
+}
+
+func (e ErrFollowSource) Error() string { return "follow mode supports only files, got " + e.Source }
+
+type ErrInvalidRefresh struct{}
+
+func (e ErrInvalidRefresh) Error() string {
+	return "invalid refresh interval, expected a positive duration, e.g. 5s, 1m"
+}
+
+// ErrStateFile - файл состояния инкрементального разбора не удалось прочитать, записать или он не подходит
+// к текущим настройкам.
+type ErrStateFile struct {
+	Path   string
+	Reason string
+}
+
+func (e ErrStateFile) Error() string { return "state file " + e.Path + ": " + e.Reason }
+
+type ErrInvalidWorkers struct{}
+
+func (e ErrInvalidWorkers) Error() string { return "invalid workers, expected a positive number" }
+
+// ErrLineTooLong - строка длиннее ограничения -maxline, в режиме skip она не разбирается.
+type ErrLineTooLong struct{}
+
+func (e ErrLineTooLong) Error() string { return "line is longer than the limit" }
+
+func (e ErrLineTooLong) Reason() string { return "line_too_long" }
+
+type ErrInvalidMaxLine struct{}
+
+func (e ErrInvalidMaxLine) Error() string {
+	return "invalid max line length, expected a non-negative number of bytes, 0 - no limit"
+}
+
+type ErrInvalidLongLines struct{}
+
+func (e ErrInvalidLongLines) Error() string {
+	return "invalid long lines mode, expected skip or truncate"
+}
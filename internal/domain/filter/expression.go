@@ -0,0 +1,158 @@
+package filter
+
+import (
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"LogAnalyzer/internal/domain"
+)
+
+// node - узел дерева выражения.
+type node interface {
+	eval(entry *domain.LogEntry) bool
+}
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(entry *domain.LogEntry) bool { return n.left.eval(entry) && n.right.eval(entry) }
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(entry *domain.LogEntry) bool { return n.left.eval(entry) || n.right.eval(entry) }
+
+type notNode struct{ operand node }
+
+func (n notNode) eval(entry *domain.LogEntry) bool { return !n.operand.eval(entry) }
+
+// operand - значение справа от оператора. Число разбирается заранее, чтобы не делать этого на каждой строке.
+type operand struct {
+	text     string
+	number   float64
+	isNumber bool
+}
+
+func newOperand(text string) operand {
+	number, err := strconv.ParseFloat(text, 64)
+
+	return operand{text: text, number: number, isNumber: err == nil}
+}
+
+// compare - сравнивает значение поля с операндом: как числа, если оба числа, иначе как строки.
+func (o operand) compare(value string) int {
+	if o.isNumber {
+		if number, err := strconv.ParseFloat(value, 64); err == nil {
+			switch {
+			case number < o.number:
+				return -1
+			case number > o.number:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	return strings.Compare(value, o.text)
+}
+
+// compareNode - сравнение поля с операндом операторами ==, !=, <, <=, >, >=.
+type compareNode struct {
+	field    string
+	operator string
+	operand  operand
+}
+
+func (n compareNode) eval(entry *domain.LogEntry) bool {
+	value, _ := entry.Get(n.field)
+	result := n.operand.compare(value)
+
+	switch n.operator {
+	case "==":
+		return result == 0
+	case "!=":
+		return result != 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	default:
+		return result >= 0
+	}
+}
+
+// matchNode - проверка поля регулярным выражением (=~, !~) или glob шаблоном, который заранее
+// переводится в регулярное выражение.
+type matchNode struct {
+	field   string
+	pattern *regexp.Regexp
+	negate  bool
+}
+
+func (n matchNode) eval(entry *domain.LogEntry) bool {
+	value, _ := entry.Get(n.field)
+
+	return n.pattern.MatchString(value) != n.negate
+}
+
+// inNode - проверка вхождения значения поля в список значений и подсетей.
+type inNode struct {
+	field    string
+	values   []operand
+	prefixes []netip.Prefix
+}
+
+func (n inNode) eval(entry *domain.LogEntry) bool {
+	value, _ := entry.Get(n.field)
+
+	for _, candidate := range n.values {
+		if candidate.compare(value) == 0 {
+			return true
+		}
+	}
+
+	if len(n.prefixes) == 0 {
+		return false
+	}
+
+	address, err := netip.ParseAddr(value)
+	if err != nil {
+		return false
+	}
+
+	address = address.Unmap()
+
+	for _, prefix := range n.prefixes {
+		if prefix.Contains(address) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// globToRegexp - переводит glob шаблон в регулярное выражение: * - любая последовательность символов
+// (в том числе с '/'), ? - один любой символ, остальные символы сравниваются буквально.
+func globToRegexp(glob string) string {
+	var builder strings.Builder
+
+	builder.WriteString("^")
+
+	for _, r := range glob {
+		switch r {
+		case '*':
+			builder.WriteString(".*")
+		case '?':
+			builder.WriteString(".")
+		default:
+			builder.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	builder.WriteString("$")
+
+	return builder.String()
+}
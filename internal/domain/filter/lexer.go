@@ -0,0 +1,140 @@
+package filter
+
+import (
+	"strings"
+
+	"LogAnalyzer/internal/domain/errors"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+	tokenNot
+	tokenAnd
+	tokenOr
+	tokenOperator
+)
+
+// token - лексема выражения фильтра и ее позиция в исходной строке.
+type token struct {
+	kind     tokenKind
+	text     string
+	position int
+}
+
+// operators - операторы сравнения, более длинные идут раньше, чтобы "<=" не разбиралось как "<".
+var operators = []string{"==", "!=", "<=", ">=", "=~", "!~", "<", ">"}
+
+// specialChars - символы, которые заканчивают слово без кавычек.
+const specialChars = "()!,=<>&|\""
+
+// tokenize - разбивает выражение на лексемы. Словом считается все, что не содержит пробелов и специальных
+// символов, поэтому IP адреса, подсети, числа и пути можно писать без кавычек.
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(expression); {
+		c := expression[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, text: "(", position: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, text: ")", position: i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", position: i})
+			i++
+		case strings.HasPrefix(expression[i:], "&&"):
+			tokens = append(tokens, token{kind: tokenAnd, text: "&&", position: i})
+			i += 2
+		case strings.HasPrefix(expression[i:], "||"):
+			tokens = append(tokens, token{kind: tokenOr, text: "||", position: i})
+			i += 2
+		case c == '"':
+			text, width, err := readString(expression[i:], i)
+			if err != nil {
+				return nil, err
+			}
+
+			tokens = append(tokens, token{kind: tokenString, text: text, position: i})
+			i += width
+		default:
+			if operator := readOperator(expression[i:]); operator != "" {
+				tokens = append(tokens, token{kind: tokenOperator, text: operator, position: i})
+				i += len(operator)
+
+				continue
+			}
+
+			if c == '!' {
+				tokens = append(tokens, token{kind: tokenNot, text: "!", position: i})
+				i++
+
+				continue
+			}
+
+			width := wordLength(expression[i:])
+			if width == 0 {
+				return nil, errors.ErrFilterSyntax{Position: i, Message: "unexpected character " + string(c)}
+			}
+
+			tokens = append(tokens, token{kind: tokenWord, text: expression[i : i+width], position: i})
+			i += width
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, position: len(expression)}), nil
+}
+
+func readOperator(s string) string {
+	for _, operator := range operators {
+		if strings.HasPrefix(s, operator) {
+			return operator
+		}
+	}
+
+	return ""
+}
+
+func wordLength(s string) int {
+	width := 0
+
+	for width < len(s) && s[width] > ' ' && strings.IndexByte(specialChars, s[width]) < 0 {
+		width++
+	}
+
+	return width
+}
+
+// readString - читает строку в двойных кавычках, внутри экранируются только \" и \\.
+func readString(s string, position int) (text string, width int, err error) {
+	var builder strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			// Остальные обратные слеши остаются как есть, чтобы в регулярных выражениях можно было писать \d
+			if i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+				i++
+			}
+
+			builder.WriteByte(s[i])
+		case '"':
+			return builder.String(), i + 1, nil
+		default:
+			builder.WriteByte(s[i])
+		}
+	}
+
+	return "", 0, errors.ErrFilterSyntax{Position: position, Message: "unterminated string"}
+}
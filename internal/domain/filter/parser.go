@@ -0,0 +1,264 @@
+package filter
+
+import (
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// Expression - скомпилированное выражение фильтра, реализует domain.Filter.
+//
+// Грамматика:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" expr ")" | comparison
+//	comparison = field ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "=~" | "!~" | "glob" ) value
+//	           | field "in" ( value | "(" value { "," value } ")" )
+//
+// Значение - строка в двойных кавычках или слово без пробелов, например 500, 10.0.0.0/8 или /api/*.
+type Expression struct {
+	root node
+}
+
+// Match - проверяет, подходит ли запись под выражение.
+func (e *Expression) Match(entry *domain.LogEntry) bool {
+	return e.root.eval(entry)
+}
+
+// parser - рекурсивный спуск по лексемам выражения.
+type parser struct {
+	tokens []token
+	pos    int
+	fields []string
+}
+
+// Parse - компилирует выражение фильтра. fields - переменные формата логов, выражение может ссылаться только
+// на них и на их логические псевдонимы, иначе вернется ошибка с позицией неизвестного поля.
+func Parse(expression string, fields []string) (*Expression, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, fields: fields}
+
+	if p.peek().kind == tokenEOF {
+		return nil, errors.ErrFilterSyntax{Position: 0, Message: "empty expression"}
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != tokenEOF {
+		return nil, unexpected(next, "end of expression")
+	}
+
+	return &Expression{root: root}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	current := p.tokens[p.pos]
+
+	if current.kind != tokenEOF {
+		p.pos++
+	}
+
+	return current
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenAnd {
+		p.next()
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch current := p.peek(); current.kind {
+	case tokenNot:
+		p.next()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return notNode{operand: operand}, nil
+	case tokenLeftParen:
+		p.next()
+
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokenRightParen {
+			return nil, unexpected(closing, "')'")
+		}
+
+		return inner, nil
+	case tokenWord:
+		return p.parseComparison()
+	default:
+		return nil, unexpected(current, "field name, '!' or '('")
+	}
+}
+
+func (p *parser) parseComparison() (node, error) {
+	field := p.next()
+
+	if !domain.HasField(p.fields, field.text) {
+		return nil, errors.ErrFilterSyntax{
+			Position: field.position,
+			Message:  "unknown field " + strconv.Quote(field.text) + ", log format declares: " + strings.Join(p.fields, ", "),
+		}
+	}
+
+	operator := p.next()
+
+	switch {
+	case operator.kind == tokenWord && operator.text == "in":
+		return p.parseIn(field.text)
+	case operator.kind == tokenWord && operator.text == "glob":
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		return matchNode{field: field.text, pattern: regexp.MustCompile(globToRegexp(value.text))}, nil
+	case operator.kind == tokenOperator && (operator.text == "=~" || operator.text == "!~"):
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		pattern, err := regexp.Compile(value.text)
+		if err != nil {
+			return nil, errors.ErrFilterSyntax{Position: value.position, Message: "invalid regular expression: " + err.Error()}
+		}
+
+		return matchNode{field: field.text, pattern: pattern, negate: operator.text == "!~"}, nil
+	case operator.kind == tokenOperator:
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		return compareNode{field: field.text, operator: operator.text, operand: newOperand(value.text)}, nil
+	default:
+		return nil, unexpected(operator, "operator after field "+strconv.Quote(field.text))
+	}
+}
+
+// parseIn - разбирает список после in: одно значение или несколько в скобках. Значения с '/', которые
+// разбираются как подсеть, проверяются на вхождение IP адреса.
+func (p *parser) parseIn(field string) (node, error) {
+	var values []token
+
+	if p.peek().kind == tokenLeftParen {
+		p.next()
+
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+
+			separator := p.next()
+			if separator.kind == tokenRightParen {
+				break
+			}
+
+			if separator.kind != tokenComma {
+				return nil, unexpected(separator, "',' or ')'")
+			}
+		}
+	} else {
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	result := inNode{field: field}
+
+	for _, value := range values {
+		if strings.Contains(value.text, "/") {
+			if prefix, err := netip.ParsePrefix(value.text); err == nil {
+				result.prefixes = append(result.prefixes, prefix.Masked())
+
+				continue
+			}
+		}
+
+		result.values = append(result.values, newOperand(value.text))
+	}
+
+	return result, nil
+}
+
+func (p *parser) parseValue() (token, error) {
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return token{}, unexpected(value, "value")
+	}
+
+	return value, nil
+}
+
+func unexpected(got token, expected string) error {
+	found := strconv.Quote(got.text)
+	if got.kind == tokenEOF {
+		found = "end of expression"
+	}
+
+	return errors.ErrFilterSyntax{Position: got.position, Message: "expected " + expected + ", found " + found}
+}
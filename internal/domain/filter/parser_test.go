@@ -0,0 +1,117 @@
+package filter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+	"LogAnalyzer/internal/domain/filter"
+	"LogAnalyzer/internal/domain/logformats"
+)
+
+func parseEntry(t *testing.T, parser domain.LineParser, line string) *domain.LogEntry {
+	t.Helper()
+
+	entry := &domain.LogEntry{}
+	require.NoError(t, parser.Parse(line, entry))
+
+	return entry
+}
+
+func TestExpression_Match(t *testing.T) {
+	parser, err := logformats.NewNginx(`$remote_addr [$time_local] "$request" $status $body_bytes_sent $request_time "$http_user_agent"`)
+	require.NoError(t, err)
+
+	apiError := parseEntry(t, parser, `10.1.2.3 [17/May/2015:08:05:24 +0000] "GET /api/v1/items HTTP/1.1" 502 0 1.250 "curl/8.0"`)
+	external := parseEntry(t, parser, `203.0.113.9 [17/May/2015:08:05:24 +0000] "GET /static/app.js HTTP/2.0" 200 5120 0.002 "Mozilla/5.0"`)
+
+	testCases := []struct {
+		expression string
+		apiError   bool
+		external   bool
+	}{
+		{expression: `http_code >= 500`, apiError: true, external: false},
+		{expression: `status == 200`, apiError: false, external: true},
+		{expression: `http_code != "502"`, apiError: false, external: true},
+		{expression: `bytes_send > 1000 && request_time < 0.01`, apiError: false, external: true},
+		{expression: `resource =~ "^/api/"`, apiError: true, external: false},
+		{expression: `resource !~ "^/api/v\d+/"`, apiError: false, external: true},
+		{expression: `resource glob "/static/*.js"`, apiError: false, external: true},
+		{expression: `remote_addr in 10.0.0.0/8`, apiError: true, external: false},
+		{expression: `!(remote_addr in (10.0.0.0/8, 192.168.0.0/16))`, apiError: false, external: true},
+		{expression: `http_code in (500, 502, 503)`, apiError: true, external: false},
+		{expression: `http_req == GET && (http_code >= 500 || http_user_agent glob "Mozilla*")`, apiError: true, external: true},
+		{
+			expression: `http_code >= 500 && resource =~ "^/api/" && !(remote_addr in 10.0.0.0/8)`,
+			apiError:   false,
+			external:   false,
+		},
+		{expression: `!!(http_version == "HTTP/2.0")`, apiError: false, external: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(tt *testing.T) {
+			expression, err := filter.Parse(tc.expression, parser.Fields())
+			require.NoError(tt, err)
+
+			assert.Equal(tt, tc.apiError, expression.Match(apiError))
+			assert.Equal(tt, tc.external, expression.Match(external))
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	fields := logformats.NewCombined().Fields()
+
+	testCases := []struct {
+		expression string
+		position   int
+	}{
+		{expression: ``, position: 0},
+		{expression: `http_code >=`, position: 12},
+		{expression: `http_code 500`, position: 10},
+		{expression: `request_time > 1`, position: 0},
+		{expression: `http_code == 500 && (resource == "/"`, position: 36},
+		{expression: `resource =~ "[a-"`, position: 12},
+		{expression: `resource == "unterminated`, position: 12},
+		{expression: `http_code in (500 502)`, position: 18},
+		{expression: `http_code == 500 resource`, position: 17},
+		{expression: `http_code == 500 & resource == "/"`, position: 17},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(tt *testing.T) {
+			_, err := filter.Parse(tc.expression, fields)
+
+			var syntaxErr errors.ErrFilterSyntax
+
+			require.ErrorAs(tt, err, &syntaxErr)
+			assert.Equal(tt, tc.position, syntaxErr.Position, syntaxErr.Error())
+		})
+	}
+}
+
+func TestDataHolder_Filter(t *testing.T) {
+	parser := logformats.NewCombined()
+
+	expression, err := filter.Parse(`http_code >= 400`, parser.Fields())
+	require.NoError(t, err)
+
+	data := domain.NewDataHolder(parser, expression)
+	logs := []string{
+		`1.1.1.1 - - [17/May/2015:08:05:24 +0000] "GET /a HTTP/1.1" 200 10 "-" "-"`,
+		`1.1.1.1 - - [17/May/2015:08:05:25 +0000] "GET /b HTTP/1.1" 404 10 "-" "-"`,
+		`1.1.1.1 - - [17/May/2015:08:05:26 +0000] "GET /c HTTP/1.1" 500 10 "-" "-"`,
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	assert.Equal(t, 2, data.TotalCounter)
+	assert.Equal(t, map[string]int{"404": 1, "500": 1}, data.CommonAnswers)
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// GeoCounters - счетчики запросов одной страны или автономной системы.
+type GeoCounters struct {
+	Requests int
+	Bytes    uint64
+}
+
+// GeoTraffic - строка рейтинга стран или ASN. Для ASN Name содержит организацию, Share - процент запросов
+// от общего числа. Other отмечает строку, в которую просуммировано все, что не вошло в рейтинг.
+type GeoTraffic struct {
+	Value    string
+	Name     string
+	Requests int
+	Bytes    uint64
+	Share    float32
+	Other    bool
+}
+
+// GeoStats - трафик по странам и автономным системам, адреса, которых нет в базах, попадают в GeoUnknown.
+type GeoStats struct {
+	Countries []GeoTraffic
+	ASNs      []GeoTraffic
+}
+
+// collectGeo - учитывает страну и ASN клиента, если запись обогащена по базам MaxMind.
+func (s *DataHolder) collectGeo(bytes int) {
+	country, ok := s.entry.Get(GeoCountry)
+	if !ok {
+		return
+	}
+
+	if country == "" {
+		country = GeoUnknown
+	}
+
+	addGeo(s.Countries, country, bytes)
+
+	asn, _ := s.entry.Get(GeoASN)
+	if asn == "" {
+		asn = GeoUnknown
+	} else if _, known := s.ASNOrganizations[asn]; !known {
+		organization, _ := s.entry.Get(GeoOrganization)
+		s.ASNOrganizations[strings.Clone(asn)] = strings.Clone(organization)
+	}
+
+	addGeo(s.ASNs, asn, bytes)
+}
+
+func addGeo(data map[string]GeoCounters, key string, bytes int) {
+	counters, ok := data[key]
+	if !ok {
+		key = strings.Clone(key)
+	}
+
+	counters.Requests++
+	counters.Bytes += uint64(max(bytes, 0))
+	data[key] = counters
+}
+
+func (s *Statistic) fillGeo(data *DataHolder) {
+	s.Geo = GeoStats{
+		Countries: geoTop(data.Countries, nil, data.TotalCounter, s.Top.For(TopCountries)),
+		ASNs:      geoTop(data.ASNs, data.ASNOrganizations, data.TotalCounter, s.Top.For(TopASNs)),
+	}
+}
+
+// geoTop - рейтинг по числу запросов, при равенстве - по объему ответов и по значению.
+func geoTop(data map[string]GeoCounters, names map[string]string, total, limit int) []GeoTraffic {
+	items := make([]GeoTraffic, 0, len(data))
+	for value, counters := range data {
+		items = append(items, geoTraffic(GeoTraffic{Value: value, Name: names[value]}, counters, total))
+	}
+
+	slices.SortFunc(items, func(a, b GeoTraffic) int {
+		if c := cmp.Compare(b.Requests, a.Requests); c != 0 {
+			return c
+		}
+
+		if c := cmp.Compare(b.Bytes, a.Bytes); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Value, b.Value)
+	})
+
+	if len(items) <= limit {
+		return items
+	}
+
+	other := GeoCounters{}
+	for _, item := range items[limit:] {
+		other.Requests += item.Requests
+		other.Bytes += item.Bytes
+	}
+
+	return append(items[:limit:limit], geoTraffic(GeoTraffic{Other: true}, other, total))
+}
+
+func geoTraffic(item GeoTraffic, counters GeoCounters, total int) GeoTraffic {
+	item.Requests = counters.Requests
+	item.Bytes = counters.Bytes
+
+	if total > 0 {
+		item.Share = float32(counters.Requests) / float32(total) * 100
+	}
+
+	return item
+}
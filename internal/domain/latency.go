@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+
+	"LogAnalyzer/pkg/sketch"
+)
+
+// Переменные с временем обработки запроса в секундах.
+const (
+	RequestTime          = "request_time"
+	UpstreamResponseTime = "upstream_response_time"
+)
+
+// LatencyStats - перцентили времени обработки запросов в секундах, с относительной ошибкой скетча (1%).
+type LatencyStats struct {
+	Count int
+	P50   float64
+	P90   float64
+	P95   float64
+	P99   float64
+	Max   float64
+}
+
+// MinSlowestRequests - сколько запросов нужно ресурсу, чтобы попасть в самые медленные ресурсы: иначе единичный
+// медленный запрос к редкому ресурсу вытесняет из рейтинга действительно медленные частые ресурсы.
+const MinSlowestRequests = 20
+
+// EndpointLatency - время обработки запросов к одному ресурсу.
+type EndpointLatency struct {
+	Resource string
+	Latency  LatencyStats
+}
+
+// parseUpstreamTime - суммирует время всех попыток из $upstream_response_time. При повторных запросах к другим
+// серверам NGINX пишет значения через ", ", при внутренних перенаправлениях - через " : ", а "-" означает,
+// что до ответа от сервера дело не дошло. Если ни одного значения нет - вернет false.
+func parseUpstreamTime(value string) (float64, bool) {
+	var (
+		total float64
+		found bool
+	)
+
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ':' || r == ' ' }) {
+		seconds, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			continue
+		}
+
+		total += seconds
+		found = true
+	}
+
+	return total, found
+}
+
+// latencyStats - считает перцентили времени обработки по скетчу.
+func latencyStats(durations *sketch.DDSketch) LatencyStats {
+	if durations == nil || durations.Count() == 0 {
+		return LatencyStats{}
+	}
+
+	return LatencyStats{
+		Count: int(durations.Count()),
+		P50:   durations.Quantile(0.5),
+		P90:   durations.Quantile(0.9),
+		P95:   durations.Quantile(0.95),
+		P99:   durations.Quantile(0.99),
+		Max:   durations.Max(),
+	}
+}
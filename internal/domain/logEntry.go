@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+// Логические имена дополнительных переменных, которые получаются разбором $request.
+// Совпадают с именами переменных NGINX, поэтому формат может объявить их и напрямую.
+const (
+	RequestMethod  = "request_method"
+	RequestURI     = "request_uri"
+	ServerProtocol = "server_protocol"
+	Status         = "status"
+	BodyBytesSent  = "body_bytes_sent"
+)
+
+// fieldAliases - логические поля анализатора и переменные формата, из которых их можно получить.
+// Позволяет фильтровать по привычным именам (http_code, resource...) независимо от того,
+// какие именно переменные объявлены в log_format.
+var fieldAliases = map[string][]string{
+	HTTPReq:     {RequestMethod},
+	Resource:    {RequestURI, "uri"},
+	HTTPVersion: {ServerProtocol},
+	HTTPCode:    {Status},
+	BytesSend:   {BodyBytesSent},
+}
+
+// LineParser - разбирает одну строку лога в LogEntry. Реализации лежат в пакете logformats.
+type LineParser interface {
+	// Parse заполняет entry значениями переменных из строки, entry переиспользуется между вызовами.
+	Parse(line string, entry *LogEntry) error
+	// Fields возвращает имена переменных, которые формат кладет в LogEntry.
+	Fields() []string
+}
+
+// LogEntry - одна распарсенная строка лога, значения хранятся по именам переменных формата.
+// Хранение в слайсе, а не в мапе, позволяет переиспользовать запись без аллокаций на каждую строку.
+type LogEntry struct {
+	Time   time.Time
+	fields []entryField
+}
+
+type entryField struct {
+	name  string
+	value string
+}
+
+// Reset очищает запись перед разбором следующей строки.
+func (e *LogEntry) Reset() {
+	e.Time = time.Time{}
+	e.fields = e.fields[:0]
+}
+
+// Set устанавливает значение переменной, перезаписывая предыдущее.
+func (e *LogEntry) Set(name, value string) {
+	for i := range e.fields {
+		if e.fields[i].name == name {
+			e.fields[i].value = value
+
+			return
+		}
+	}
+
+	e.fields = append(e.fields, entryField{name: name, value: value})
+}
+
+// Get возвращает значение переменной по имени, если такой переменной нет - пробует логические псевдонимы.
+func (e *LogEntry) Get(name string) (string, bool) {
+	for i := range e.fields {
+		if e.fields[i].name == name {
+			return e.fields[i].value, true
+		}
+	}
+
+	for _, alias := range fieldAliases[name] {
+		for i := range e.fields {
+			if e.fields[i].name == alias {
+				return e.fields[i].value, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// HasField - проверяет, можно ли получить поле name из записи формата с переменными fields.
+func HasField(fields []string, name string) bool {
+	if slices.Contains(fields, name) {
+		return true
+	}
+
+	for _, alias := range fieldAliases[name] {
+		if slices.Contains(fields, alias) {
+			return true
+		}
+	}
+
+	return false
+}
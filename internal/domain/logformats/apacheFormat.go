@@ -0,0 +1,188 @@
+package logformats
+
+import (
+	"strconv"
+	"strings"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// ApacheCommon - формат common из стандартной конфигурации Apache httpd.
+const ApacheCommon = `%h %l %u %t "%r" %>s %b`
+
+// ApacheCombined - формат combined из стандартной конфигурации Apache httpd.
+const ApacheCombined = ApacheCommon + ` "%{Referer}i" "%{User-agent}i"`
+
+// apacheDirectives - директивы LogFormat без аргумента и соответствующие им переменные NGINX,
+// благодаря этому статистика и фильтры одинаково работают с логами обоих серверов.
+var apacheDirectives = map[byte]string{
+	'a': "remote_addr",
+	'A': "server_addr",
+	'b': domain.BodyBytesSent,
+	'B': domain.BodyBytesSent,
+	'D': varRequestTimeMicros,
+	'f': "request_filename",
+	'h': "remote_addr",
+	'H': domain.ServerProtocol,
+	'I': "request_length",
+	'k': "connection_requests",
+	'l': "remote_logname",
+	'm': domain.RequestMethod,
+	'O': "bytes_sent",
+	'p': "server_port",
+	'P': "pid",
+	'q': "query_string",
+	'r': varRequest,
+	's': domain.Status,
+	'S': "bytes_transferred",
+	'T': "request_time",
+	'u': "remote_user",
+	'U': "uri",
+	'v': "server_name",
+	'V': "server_name",
+	'X': "connection_status",
+}
+
+// apacheHeaderPrefixes - директивы вида %{Name}x и префиксы, с которыми имя попадает в переменную.
+var apacheHeaderPrefixes = map[byte]string{
+	'i': "http_",
+	'o': "sent_http_",
+	'C': "cookie_",
+	'e': "env_",
+	'n': "note_",
+}
+
+// NewApache - строит парсер по строке директивы LogFormat Apache httpd,
+// например `%h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i"`.
+func NewApache(logFormat string) (*Format, error) {
+	segments, err := parseApacheFormat(logFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	return compile(segments)
+}
+
+// parseApacheFormat - разбивает строку LogFormat на литералы и переменные.
+func parseApacheFormat(logFormat string) ([]segment, error) {
+	if strings.TrimSpace(logFormat) == "" {
+		return nil, errors.ErrLogFormatSyntax{Reason: "empty log format"}
+	}
+
+	var (
+		segments []segment
+		literal  strings.Builder
+	)
+
+	flush := func() {
+		if literal.Len() > 0 {
+			segments = append(segments, segment{literal: literal.String()})
+			literal.Reset()
+		}
+	}
+
+	for i := 0; i < len(logFormat); {
+		if logFormat[i] != '%' {
+			literal.WriteByte(logFormat[i])
+			i++
+
+			continue
+		}
+
+		name, width, err := apacheDirective(logFormat[i+1:])
+		if err != nil {
+			return nil, errors.ErrLogFormatSyntax{Reason: err.Error() + " at position " + strconv.Itoa(i)}
+		}
+
+		i += width + 1
+
+		switch name {
+		case "%":
+			literal.WriteByte('%')
+		case varTimeLocal:
+			// %t пишет время вместе с квадратными скобками
+			literal.WriteByte('[')
+			flush()
+
+			segments = append(segments, segment{variable: name})
+
+			literal.WriteByte(']')
+		default:
+			flush()
+
+			segments = append(segments, segment{variable: name})
+		}
+	}
+
+	flush()
+
+	return segments, nil
+}
+
+// apacheDirective - читает одну директиву после '%' вместе с модификаторами, возвращает имя переменной
+// и число прочитанных байт.
+func apacheDirective(s string) (name string, width int, err error) {
+	// Условия по кодам ответа (%400,501{User-agent}i, %!200i) и модификаторы < > на разбор строки не влияют
+	for width < len(s) && strings.IndexByte("!,<>0123456789", s[width]) >= 0 {
+		width++
+	}
+
+	var argument string
+
+	if width < len(s) && s[width] == '{' {
+		end := strings.IndexByte(s[width:], '}')
+		if end < 0 {
+			return "", 0, errors.ErrLogFormatSyntax{Reason: "unclosed directive argument"}
+		}
+
+		argument = s[width+1 : width+end]
+		width += end + 1
+	}
+
+	if width >= len(s) {
+		return "", 0, errors.ErrLogFormatSyntax{Reason: "unfinished directive"}
+	}
+
+	directive := s[width]
+	width++
+
+	name, err = apacheVariable(directive, argument)
+
+	return name, width, err
+}
+
+// apacheVariable - переводит директиву с аргументом в имя переменной.
+func apacheVariable(directive byte, argument string) (string, error) {
+	if directive == '%' {
+		return "%", nil
+	}
+
+	if prefix, ok := apacheHeaderPrefixes[directive]; ok {
+		if argument == "" {
+			return "", errors.ErrLogFormatSyntax{Reason: "directive %" + string(directive) + " requires a name"}
+		}
+
+		return prefix + strings.ToLower(strings.ReplaceAll(argument, "-", "_")), nil
+	}
+
+	switch {
+	case directive == 't' && argument == "":
+		return varTimeLocal, nil
+	case directive == 't' && argument == "sec":
+		return varMsec, nil
+	case directive == 't':
+		return "", errors.ErrLogFormatSyntax{Reason: "unsupported time format %{" + argument + "}t"}
+	case directive == 'T' && argument == "ms":
+		return varRequestTimeMillis, nil
+	case directive == 'T' && argument == "us":
+		return varRequestTimeMicros, nil
+	}
+
+	name, ok := apacheDirectives[directive]
+	if !ok {
+		return "", errors.ErrLogFormatSyntax{Reason: "unsupported directive %" + string(directive)}
+	}
+
+	return name, nil
+}
@@ -0,0 +1,77 @@
+package logformats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/logformats"
+)
+
+func TestNewApache_Combined(t *testing.T) {
+	parser, err := logformats.Resolve(logformats.SyntaxApache, "apache-combined")
+	require.NoError(t, err)
+
+	data := domain.NewDataHolder(parser, nil)
+	logs := []string{
+		`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 ` +
+			`"http://www.example.com/start.html" "Mozilla/4.08 [en] (Win98; I ;Nav)"`,
+		`127.0.0.1 - - [10/Oct/2000:13:55:37 -0700] "HEAD /apache_pb.gif HTTP/1.0" 304 - "-" "curl/8.0"`,
+		`127.0.0.1 - - [10/Oct/2000:13:55:38 -0700] "GET /missing HTTP/1.0" 404 - "-" "say \"hi\""`,
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	assert.Equal(t, 3, data.TotalCounter)
+	assert.Equal(t, 0, data.UnparsedLogs)
+	assert.Equal(t, uint64(3), data.ResponseSizeMoments.Count)
+	assert.Equal(t, uint64(2326), data.ResponseSizeMoments.Sum)
+	assert.Equal(t, map[string]int{"GET": 2, "HEAD": 1}, data.HTTPRequests)
+	assert.Equal(t, map[string]int{"200": 1, "304": 1, "404": 1}, data.CommonAnswers)
+	assert.Equal(t, time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC), data.From.UTC())
+}
+
+func TestNewApache_CustomLogFormat(t *testing.T) {
+	parser, err := logformats.NewApache(`%a %{X-Request-Id}i %t "%m %U%q %H" %>s %O %D`)
+	require.NoError(t, err)
+
+	var entry domain.LogEntry
+
+	err = parser.Parse(`10.1.1.1 abc-123 [10/Oct/2000:13:55:36 -0700] "GET /search?q=go HTTP/1.1" 200 1024 153000`, &entry)
+	require.NoError(t, err)
+
+	expected := map[string]string{
+		"remote_addr":       "10.1.1.1",
+		"http_x_request_id": "abc-123",
+		domain.HTTPReq:      "GET",
+		"uri":               "/search",
+		"query_string":      "?q=go",
+		domain.Resource:     "/search",
+		domain.HTTPVersion:  "HTTP/1.1",
+		domain.HTTPCode:     "200",
+		"bytes_sent":        "1024",
+		"request_time":      "0.153",
+	}
+
+	for name, value := range expected {
+		actual, ok := entry.Get(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, value, actual, name)
+	}
+
+	// %O - размер ответа вместе с заголовками, за размер тела он не выдается
+	_, ok := entry.Get(domain.BytesSend)
+	assert.False(t, ok)
+}
+
+func TestNewApache_Errors(t *testing.T) {
+	for _, logFormat := range []string{"", "%h %Z", "%h %{Referer", "%{%d/%m}t", "%h %"} {
+		_, err := logformats.NewApache(logFormat)
+		assert.Error(t, err, logFormat)
+	}
+}
@@ -0,0 +1,118 @@
+package logformats
+
+import (
+	"strings"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// CombinedFormat - разбор формата combined без регулярных выражений. Строка режется по разделителям
+// формата за один проход, значения остаются подстроками исходной строки, поэтому разбор не аллоцирует память.
+// Результат совпадает с разбором регулярным выражением, построенным по NginxCombined.
+type CombinedFormat struct {
+	fields []string
+}
+
+// NewCombined - создает токенизатор формата combined.
+func NewCombined() *CombinedFormat {
+	return &CombinedFormat{fields: declaredFields([]string{
+		"remote_addr", "remote_user", varTimeLocal, varRequest, domain.Status, domain.BodyBytesSent,
+		domain.HTTPReferer, domain.HTTPUserAgent,
+	})}
+}
+
+func (f *CombinedFormat) Fields() []string {
+	return f.fields
+}
+
+// Parse - разбирает строку вида
+// `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`.
+func (f *CombinedFormat) Parse(line string, entry *domain.LogEntry) error {
+	entry.Reset()
+
+	remoteAddr, rest, ok := strings.Cut(line, " - ")
+	if !ok || strings.IndexByte(remoteAddr, ' ') >= 0 {
+		return errors.ErrLineFormatMismatch{}
+	}
+
+	remoteUser, rest, ok := strings.Cut(rest, " [")
+	if !ok || strings.IndexByte(remoteUser, ' ') >= 0 {
+		return errors.ErrLineFormatMismatch{}
+	}
+
+	timeLocal, rest, ok := strings.Cut(rest, `] "`)
+	if !ok {
+		return errors.ErrLineFormatMismatch{}
+	}
+
+	request, status, bytesSent, rest, ok := cutRequest(rest)
+	if !ok {
+		return errors.ErrLineFormatMismatch{}
+	}
+
+	// Реферер заканчивается на первой последовательности `" "`, агент пользователя - последним символом строки
+	referer, userAgent, ok := strings.Cut(rest, `" "`)
+	if !ok || !strings.HasSuffix(userAgent, `"`) {
+		return errors.ErrLineFormatMismatch{}
+	}
+
+	logTime, err := parseTimeLocal(timeLocal)
+	if err != nil {
+		return err
+	}
+
+	entry.Time = logTime
+	entry.Set("remote_addr", remoteAddr)
+	entry.Set("remote_user", remoteUser)
+	entry.Set(varTimeLocal, timeLocal)
+	entry.Set(varRequest, request)
+	entry.Set(domain.Status, status)
+	entry.Set(domain.BodyBytesSent, bytesSent)
+	entry.Set(domain.HTTPReferer, referer)
+	entry.Set(domain.HTTPUserAgent, userAgent[:len(userAgent)-1])
+
+	return splitRequest(entry, request)
+}
+
+// cutRequest - ищет конец строки запроса: первую кавычку, за которой идут код ответа из трех цифр,
+// размер ответа (число или "-") и открывающая кавычка реферера.
+func cutRequest(s string) (request, status, bytesSent, rest string, ok bool) {
+	for offset := 0; ; {
+		quote := strings.IndexByte(s[offset:], '"')
+		if quote < 0 {
+			return "", "", "", "", false
+		}
+
+		end := offset + quote
+		if status, bytesSent, rest, ok = cutStatusAndBytes(s[end+1:]); ok {
+			return s[:end], status, bytesSent, rest, true
+		}
+
+		offset = end + 1
+	}
+}
+
+// cutStatusAndBytes - разбирает ` 200 1234 "` в начале строки.
+func cutStatusAndBytes(s string) (status, bytesSent, rest string, ok bool) {
+	if len(s) < 5 || s[0] != ' ' || !isDigits(s[1:4]) || s[4] != ' ' {
+		return "", "", "", false
+	}
+
+	bytesSent, rest, ok = strings.Cut(s[5:], ` "`)
+	if !ok || (bytesSent != "-" && !isDigits(bytesSent)) {
+		return "", "", "", false
+	}
+
+	return s[1:4], bytesSent, rest, true
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return s != ""
+}
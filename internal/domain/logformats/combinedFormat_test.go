@@ -0,0 +1,81 @@
+package logformats_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+	"LogAnalyzer/internal/domain/logformats"
+)
+
+// TestCombinedFormat_MatchesRegexFormat - токенизатор должен разбирать строки так же, как регулярное выражение
+// построенное по той же строке формата.
+func TestCombinedFormat_MatchesRegexFormat(t *testing.T) {
+	regexFormat, err := logformats.NewNginx(logformats.NginxCombined)
+	require.NoError(t, err)
+
+	tokenizer := logformats.NewCombined()
+	assert.Equal(t, regexFormat.Fields(), tokenizer.Fields())
+
+	lines := []string{
+		`80.91.33.133 - - [17/May/2015:08:05:24 +0000] "GET /downloads/product_1 HTTP/1.1" 304 0 "-" ` +
+			`"Debian APT-HTTP/1.3 (0.8.16~exp12ubuntu10.17)"`,
+		`2001:db8::1 - alice [29/Feb/2024:23:59:59 -0330] "POST /api/v1/login?next=/ HTTP/2.0" 401 - ` +
+			`"https://example.com/" "Mozilla/5.0 \"quoted\""`,
+		`1.1.1.1 - - [17/May/2015:08:05:24 +0000] "GET /a"b HTTP/1.1" 200 10 "x" "y" "z"`,
+		`1.1.1.1 - - [17/May/2015:08:05:24 +0000] "GET /a HTTP/1.1" 200 10 "" ""`,
+		`1.1.1.1 - - [31/Apr/2015:08:05:24 +0000] "GET /a HTTP/1.1" 200 10 "-" "-"`,
+		`1.1.1.1 - - [17/Foo/2015:08:05:24 +0000] "GET /a HTTP/1.1" 200 10 "-" "-"`,
+		`1.1.1.1 - - [17/May/2015:08:05:24 +0000] "/a HTTP/1.1" 200 10 "-" "-"`,
+		`1.1.1.1 - - [17/May/2015:08:05:24 +0000] "GET /a b HTTP/1.1" 200 10 "-" "-"`,
+		`1.1.1.1 - - [17/May/2015:08:05:24 +0000] "GET /a  HTTP/1.1" 200 10 "-" "-"`,
+		`1.1.1.1 - - [17/May/2015:08:05:24 +0000] "GET /a HTTP/1.1 " 200 10 "-" "-"`,
+		`1.1.1.1 - - [17/May/2015:08:05:24 +0000] "GET /a HTTP/1.1" 2000 10 "-" "-"`,
+		`1.1.1.1 - - [17/May/2015:08:05:24 +0000] "GET /a HTTP/1.1" 200 1x "-" "-"`,
+		`1.1.1.1 - - [17/May/2015:08:05:24 +0000] "GET /a HTTP/1.1" 200 10 "-" "-`,
+		`1.1.1.1 - [17/May/2015:08:05:24 +0000] "GET /a HTTP/1.1" 200 10 "-" "-"`,
+		`1.1.1.1 - a b [17/May/2015:08:05:24 +0000] "GET /a HTTP/1.1" 200 10 "-" "-"`,
+		``,
+	}
+
+	names := append(regexFormat.Fields(), domain.HTTPCode, domain.BytesSend, domain.Resource)
+
+	for _, line := range lines {
+		var expected, actual domain.LogEntry
+
+		expectedErr := regexFormat.Parse(line, &expected)
+		actualErr := tokenizer.Parse(line, &actual)
+
+		assert.Equal(t, expectedErr, actualErr, line)
+
+		if expectedErr != nil {
+			continue
+		}
+
+		assert.True(t, expected.Time.Equal(actual.Time), line)
+
+		for _, name := range names {
+			expectedValue, _ := expected.Get(name)
+			actualValue, _ := actual.Get(name)
+			assert.Equal(t, expectedValue, actualValue, "%s: %s", name, line)
+		}
+	}
+}
+
+// TestCombinedFormat_RequestWithSpaces - как и исходное регулярное выражение, оба парсера не принимают запрос,
+// в котором больше трех частей.
+func TestCombinedFormat_RequestWithSpaces(t *testing.T) {
+	regexFormat, err := logformats.NewNginx(logformats.NginxCombined)
+	require.NoError(t, err)
+
+	line := `1.1.1.1 - - [17/May/2015:08:05:24 +0000] "GET /a b HTTP/1.1" 200 10 "-" "-"`
+
+	for _, parser := range []domain.LineParser{regexFormat, logformats.NewCombined()} {
+		var entry domain.LogEntry
+
+		assert.ErrorAs(t, parser.Parse(line, &entry), &errors.ErrMalformedRequest{})
+	}
+}
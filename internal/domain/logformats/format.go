@@ -0,0 +1,223 @@
+package logformats
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+const (
+	timeLocalLayout = "02/Jan/2006:15:04:05 -0700"
+
+	varRequest     = "request"
+	varTimeLocal   = "time_local"
+	varTimeISO8601 = "time_iso8601"
+	varMsec        = "msec"
+	varRequestTime = "request_time"
+
+	// Время обработки запроса в микро- и миллисекундах (директивы Apache %D и %{ms}T),
+	// при разборе переводится в секунды и сохраняется как request_time.
+	varRequestTimeMicros = "request_time_us"
+	varRequestTimeMillis = "request_time_ms"
+)
+
+// upstreamListPattern - значения $upstream_* содержат по элементу на каждую попытку, разделенные ", " или " : ".
+const upstreamListPattern = `[^\s,]+(?:\s*[,:]\s*[^\s,]+)*`
+
+// variablePatterns - регулярные выражения для переменных с известным видом значения,
+// остальные переменные захватываются до ближайшего литерала.
+var variablePatterns = map[string]string{
+	domain.Status:        `\d{3}`,
+	domain.BodyBytesSent: `\d+|-`,
+	"bytes_sent":         `\d+|-`,
+	"request_length":     `\d+|-`,
+	varRequestTime:       `[\d.]+|-`,
+	varRequestTimeMicros: `\d+`,
+	varRequestTimeMillis: `\d+`,
+	varMsec:              `[\d.]+`,
+	"uri":                `[^\s?]*`,
+	"query_string":       `(?:\?\S*)?`,
+	"is_args":            `\??`,
+}
+
+// timeUnits - множители для перевода времени обработки запроса в секунды.
+var timeUnits = map[string]float64{
+	varRequestTimeMicros: 1e-6,
+	varRequestTimeMillis: 1e-3,
+}
+
+// segment - часть строки формата: либо литерал, либо переменная.
+type segment struct {
+	literal  string
+	variable string
+}
+
+// Format - скомпилированный формат строки лога. Строится из последовательности литералов и переменных,
+// по ней собирается одно регулярное выражение, а значения групп сохраняются в LogEntry по именам переменных.
+type Format struct {
+	re     *regexp.Regexp
+	groups []string
+	fields []string
+}
+
+// compile - собирает регулярное выражение по сегментам формата.
+func compile(segments []segment) (*Format, error) {
+	var (
+		pattern strings.Builder
+		groups  []string
+	)
+
+	pattern.WriteString("^")
+
+	for i, seg := range segments {
+		if seg.variable == "" {
+			pattern.WriteString(regexp.QuoteMeta(seg.literal))
+
+			continue
+		}
+
+		pattern.WriteString("(" + variablePattern(seg.variable, segments[i+1:]) + ")")
+
+		groups = append(groups, seg.variable)
+	}
+
+	pattern.WriteString("$")
+
+	if len(groups) == 0 {
+		return nil, errors.ErrLogFormatSyntax{Reason: "format declares no variables"}
+	}
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, errors.ErrLogFormatSyntax{Reason: err.Error()}
+	}
+
+	return &Format{re: re, groups: groups, fields: declaredFields(groups)}, nil
+}
+
+// variablePattern - подбирает выражение для переменной: известный вид значения, либо "до пробела",
+// если дальше идет пробел или конец строки, либо нежадный захват до следующего литерала.
+func variablePattern(name string, rest []segment) string {
+	if pattern, ok := variablePatterns[name]; ok {
+		return pattern
+	}
+
+	if strings.HasPrefix(name, "upstream_") {
+		return upstreamListPattern
+	}
+
+	if len(rest) == 0 || strings.HasPrefix(rest[0].literal, " ") {
+		return `\S*`
+	}
+
+	return `.*?`
+}
+
+// declaredFields - имена переменных, которые попадут в запись, включая производные от $request.
+func declaredFields(groups []string) []string {
+	fields := make([]string, 0, len(groups)+3)
+
+	for _, name := range groups {
+		fields = append(fields, name)
+
+		if name == varRequest {
+			fields = append(fields, domain.RequestMethod, domain.RequestURI, domain.ServerProtocol)
+		}
+
+		if _, ok := timeUnits[name]; ok {
+			fields = append(fields, varRequestTime)
+		}
+	}
+
+	return fields
+}
+
+func (f *Format) Fields() []string {
+	return f.fields
+}
+
+// Parse - разбирает строку по регулярному выражению формата и раскладывает группы по именам переменных.
+func (f *Format) Parse(line string, entry *domain.LogEntry) error {
+	entry.Reset()
+
+	indices := f.re.FindStringSubmatchIndex(line)
+	if indices == nil {
+		return errors.ErrLineFormatMismatch{}
+	}
+
+	for i, name := range f.groups {
+		start, end := indices[2*i+2], indices[2*i+3]
+
+		var value string
+		if start >= 0 {
+			value = line[start:end]
+		}
+
+		if err := setVariable(entry, name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setVariable - сохраняет значение переменной, попутно разбирая время и строку запроса.
+func setVariable(entry *domain.LogEntry, name, value string) error {
+	entry.Set(name, value)
+
+	switch name {
+	case varTimeLocal:
+		logTime, err := parseTimeLocal(value)
+		if err != nil {
+			return errors.ErrTimeParsing{}
+		}
+
+		entry.Time = logTime
+	case varTimeISO8601:
+		logTime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return errors.ErrTimeParsing{}
+		}
+
+		entry.Time = logTime
+	case varMsec:
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.ErrTimeParsing{}
+		}
+
+		entry.Time = time.UnixMilli(int64(seconds * 1000)).UTC()
+	case varRequest:
+		return splitRequest(entry, value)
+	case varRequestTimeMicros, varRequestTimeMillis:
+		duration, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.ErrLineFormatMismatch{}
+		}
+
+		entry.Set(varRequestTime, strconv.FormatFloat(duration*timeUnits[name], 'f', -1, 64))
+	}
+
+	return nil
+}
+
+// splitRequest - раскладывает строку запроса "GET /path HTTP/1.1" на метод, URI и протокол. Как и исходное
+// регулярное выражение, требует ровно три непустые части: запрос с пробелом в URI считается нераспознанным.
+func splitRequest(entry *domain.LogEntry, request string) error {
+	method, rest, _ := strings.Cut(request, " ")
+	uri, protocol, _ := strings.Cut(rest, " ")
+
+	if method == "" || uri == "" || protocol == "" || strings.IndexByte(protocol, ' ') >= 0 {
+		return errors.ErrMalformedRequest{}
+	}
+
+	entry.Set(domain.RequestMethod, method)
+	entry.Set(domain.RequestURI, uri)
+	entry.Set(domain.ServerProtocol, protocol)
+
+	return nil
+}
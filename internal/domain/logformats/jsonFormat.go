@@ -0,0 +1,256 @@
+package logformats
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// varTime - цель сопоставления JSON, в которую попадает время запроса, вид значения задается конвертером.
+const varTime = "time"
+
+// CaddyJSON - сопоставление полей стандартного JSON лога доступа Caddy 2.
+const CaddyJSON = "remote_addr=request.client_ip|request.remote_ip,remote_user=user_id,time=ts:unix," +
+	"request_method=request.method,request_uri=request.uri,server_protocol=request.proto,host=request.host," +
+	"status=status,body_bytes_sent=size,request_time=duration:s," +
+	"http_referer=request.headers.Referer,http_user_agent=request.headers.User-Agent"
+
+// TraefikJSON - сопоставление полей JSON лога доступа Traefik.
+const TraefikJSON = "remote_addr=ClientHost,remote_user=ClientUsername,time=StartUTC:rfc3339," +
+	"request_method=RequestMethod,request_uri=RequestPath,server_protocol=RequestProtocol,host=RequestHost," +
+	"status=DownstreamStatus,body_bytes_sent=DownstreamContentSize,request_time=Duration:ns," +
+	"upstream_response_time=OriginDuration:ns,http_referer=request_Referer,http_user_agent=request_User-Agent"
+
+// NginxJSON - сопоставление для log_format с escape=json, в котором ключи совпадают с именами переменных.
+const NginxJSON = "remote_addr=remote_addr,remote_user=remote_user,time_local=time_local,time_iso8601=time_iso8601," +
+	"request=request,status=status,body_bytes_sent=body_bytes_sent,bytes_sent=bytes_sent,host=host," +
+	"http_referer=http_referer,http_user_agent=http_user_agent,http_x_forwarded_for=http_x_forwarded_for," +
+	"request_time=request_time,upstream_response_time=upstream_response_time"
+
+// timeConverters - как значение из JSON переводится во время запроса.
+var timeConverters = map[string]func(string) (time.Time, error){
+	"rfc3339": func(value string) (time.Time, error) {
+		return time.Parse(time.RFC3339Nano, value)
+	},
+	"time_local": parseTimeLocal,
+	"unix":       unixConverter(float64(time.Second)),
+	"unix_ms":    unixConverter(float64(time.Millisecond)),
+	"unix_ns":    unixConverter(1),
+}
+
+// durationUnits - единицы длительностей в JSON и их множители для перевода в секунды.
+var durationUnits = map[string]float64{
+	"s":  1,
+	"ms": 1e-3,
+	"us": 1e-6,
+	"ns": 1e-9,
+}
+
+func unixConverter(unit float64) func(string) (time.Time, error) {
+	return func(value string) (time.Time, error) {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		return time.Unix(0, int64(number*unit)).UTC(), nil
+	}
+}
+
+// jsonField - одно правило сопоставления: логическое поле, пути в JSON (берется первый найденный) и конвертер.
+type jsonField struct {
+	target    string
+	paths     [][]string
+	converter string
+}
+
+// JSONFormat - парсер логов, в которых каждая строка - отдельный JSON объект.
+type JSONFormat struct {
+	fields   []jsonField
+	declared []string
+}
+
+// NewJSON - строит парсер по описанию сопоставления вида
+// `remote_addr=request.remote_ip,status=status,time=ts:unix,request_time=duration:ms`.
+// Вложенные ключи разделяются точкой, альтернативные пути - символом '|', после ':' указывается конвертер
+// времени (rfc3339, time_local, unix, unix_ms, unix_ns) или единица длительности (s, ms, us, ns).
+func NewJSON(mapping string) (*JSONFormat, error) {
+	if strings.TrimSpace(mapping) == "" {
+		return nil, errors.ErrLogFormatSyntax{Reason: "empty JSON field mapping"}
+	}
+
+	format := &JSONFormat{}
+
+	for _, rule := range strings.Split(mapping, ",") {
+		field, err := parseJSONRule(strings.TrimSpace(rule))
+		if err != nil {
+			return nil, err
+		}
+
+		format.fields = append(format.fields, field)
+
+		if field.target != varTime {
+			format.declared = append(format.declared, declaredFields([]string{field.target})...)
+		}
+	}
+
+	return format, nil
+}
+
+// parseJSONRule - разбирает одно правило сопоставления target=path[|path...][:converter].
+func parseJSONRule(rule string) (jsonField, error) {
+	target, source, ok := strings.Cut(rule, "=")
+	if !ok || target == "" || source == "" || !isVariableName(target) {
+		return jsonField{}, errors.ErrLogFormatSyntax{Reason: "bad JSON mapping rule " + strconv.Quote(rule)}
+	}
+
+	source, converter, _ := strings.Cut(source, ":")
+
+	switch {
+	case target == varTime && converter == "":
+		converter = "rfc3339"
+	case target == varTime:
+		if _, known := timeConverters[converter]; !known {
+			return jsonField{}, errors.ErrLogFormatSyntax{Reason: "unknown time converter " + strconv.Quote(converter)}
+		}
+	case converter != "":
+		if _, known := durationUnits[converter]; !known {
+			return jsonField{}, errors.ErrLogFormatSyntax{Reason: "unknown duration unit " + strconv.Quote(converter)}
+		}
+	}
+
+	field := jsonField{target: target, converter: converter}
+
+	for _, path := range strings.Split(source, "|") {
+		if path == "" {
+			return jsonField{}, errors.ErrLogFormatSyntax{Reason: "empty JSON path in rule " + strconv.Quote(rule)}
+		}
+
+		field.paths = append(field.paths, strings.Split(path, "."))
+	}
+
+	return field, nil
+}
+
+func (f *JSONFormat) Fields() []string {
+	return f.declared
+}
+
+// Parse - разбирает JSON объект и раскладывает значения по логическим полям согласно сопоставлению.
+// Строка, в которой не нашлось ни одного поля, считается не подходящей под формат.
+func (f *JSONFormat) Parse(line string, entry *domain.LogEntry) error {
+	entry.Reset()
+
+	var document map[string]any
+
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&document); err != nil {
+		return errors.ErrLineFormatMismatch{}
+	}
+
+	// Decode читает только первое значение, мусор после объекта делает строку не подходящей под формат
+	if strings.TrimSpace(line[decoder.InputOffset():]) != "" {
+		return errors.ErrLineFormatMismatch{}
+	}
+
+	found := false
+
+	for _, field := range f.fields {
+		value, ok := lookupJSON(document, field.paths)
+		if !ok {
+			continue
+		}
+
+		found = true
+
+		if err := f.setField(entry, field, value); err != nil {
+			return err
+		}
+	}
+
+	if !found {
+		return errors.ErrLineFormatMismatch{}
+	}
+
+	return nil
+}
+
+// setField - сохраняет значение в записи, применяя конвертер поля.
+func (f *JSONFormat) setField(entry *domain.LogEntry, field jsonField, value string) error {
+	if field.target == varTime {
+		logTime, err := timeConverters[field.converter](value)
+		if err != nil {
+			return errors.ErrTimeParsing{}
+		}
+
+		entry.Time = logTime
+
+		return nil
+	}
+
+	if unit, ok := durationUnits[field.converter]; ok {
+		duration, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return errors.ErrLineFormatMismatch{}
+		}
+
+		value = strconv.FormatFloat(duration*unit, 'f', -1, 64)
+	}
+
+	return setVariable(entry, field.target, value)
+}
+
+// lookupJSON - ищет значение по первому из путей, который есть в документе, и приводит его к строке.
+func lookupJSON(document map[string]any, paths [][]string) (string, bool) {
+	for _, path := range paths {
+		var current any = document
+
+		for _, key := range path {
+			object, ok := current.(map[string]any)
+			if !ok {
+				current = nil
+
+				break
+			}
+
+			current = object[key]
+		}
+
+		if value, ok := jsonString(current); ok {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// jsonString - приводит скалярное значение JSON к строке, у массивов (например заголовков Caddy)
+// значения объединяются через запятую.
+func jsonString(value any) (string, bool) {
+	switch typed := value.(type) {
+	case string:
+		return typed, true
+	case json.Number:
+		return typed.String(), true
+	case bool:
+		return strconv.FormatBool(typed), true
+	case []any:
+		parts := make([]string, 0, len(typed))
+
+		for _, item := range typed {
+			if part, ok := jsonString(item); ok {
+				parts = append(parts, part)
+			}
+		}
+
+		return strings.Join(parts, ", "), len(parts) > 0
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,118 @@
+package logformats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/logformats"
+)
+
+func TestJSONFormat_Presets(t *testing.T) {
+	testCases := []struct {
+		testScenario string
+		format       string
+		line         string
+		time         time.Time
+		expected     map[string]string
+	}{
+		{
+			testScenario: "caddy",
+			format:       "caddy",
+			line: `{"level":"info","ts":1646861401.5,"logger":"http.log.access","msg":"handled request",` +
+				`"request":{"remote_ip":"127.0.0.1","remote_port":"41342","proto":"HTTP/1.1","method":"GET",` +
+				`"host":"localhost","uri":"/index.html","headers":{"User-Agent":["curl/7.82.0"]}},` +
+				`"user_id":"","duration":0.000929675,"size":10900,"status":200}`,
+			time: time.Date(2022, 3, 9, 21, 30, 1, 500000000, time.UTC),
+			expected: map[string]string{
+				"remote_addr":        "127.0.0.1",
+				domain.HTTPReq:       "GET",
+				domain.Resource:      "/index.html",
+				domain.HTTPVersion:   "HTTP/1.1",
+				domain.HTTPCode:      "200",
+				domain.BytesSend:     "10900",
+				domain.HTTPUserAgent: "curl/7.82.0",
+				"request_time":       "0.000929675",
+				"host":               "localhost",
+			},
+		},
+		{
+			testScenario: "traefik",
+			format:       "traefik",
+			line: `{"ClientHost":"10.0.0.7","ClientUsername":"-","DownstreamContentSize":1234,"DownstreamStatus":502,` +
+				`"Duration":1500000,"OriginDuration":1000000,"RequestMethod":"POST","RequestPath":"/api/items",` +
+				`"RequestProtocol":"HTTP/2.0","StartUTC":"2024-05-01T10:00:00.123456Z","request_User-Agent":"Go-http-client/2.0"}`,
+			time: time.Date(2024, 5, 1, 10, 0, 0, 123456000, time.UTC),
+			expected: map[string]string{
+				"remote_addr":            "10.0.0.7",
+				domain.HTTPReq:           "POST",
+				domain.Resource:          "/api/items",
+				domain.HTTPCode:          "502",
+				domain.BytesSend:         "1234",
+				domain.HTTPUserAgent:     "Go-http-client/2.0",
+				"request_time":           "0.0015",
+				"upstream_response_time": "0.001",
+			},
+		},
+		{
+			testScenario: "nginx escape=json",
+			format:       "nginx-json",
+			line: `{"remote_addr":"192.168.0.1","time_local":"17/May/2015:08:05:24 +0000",` +
+				`"request":"GET /downloads/product_1 HTTP/1.1","status":"304","body_bytes_sent":"0","http_user_agent":"Debian APT"}`,
+			time: time.Date(2015, 5, 17, 8, 5, 24, 0, time.UTC),
+			expected: map[string]string{
+				"remote_addr":   "192.168.0.1",
+				domain.Resource: "/downloads/product_1",
+				domain.HTTPCode: "304",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testScenario, func(tt *testing.T) {
+			parser, err := logformats.Resolve(logformats.SyntaxJSON, tc.format)
+			require.NoError(tt, err)
+
+			var entry domain.LogEntry
+
+			require.NoError(tt, parser.Parse(tc.line, &entry))
+			assert.True(tt, tc.time.Equal(entry.Time), entry.Time)
+
+			for name, value := range tc.expected {
+				actual, ok := entry.Get(name)
+				assert.True(tt, ok, name)
+				assert.Equal(tt, value, actual, name)
+			}
+		})
+	}
+}
+
+func TestJSONFormat_CustomMapping(t *testing.T) {
+	parser, err := logformats.NewJSON("remote_addr=client.ip,status=response.code,time=at:unix_ms,request_uri=request.uri")
+	require.NoError(t, err)
+
+	data := domain.NewDataHolder(parser, nil)
+	data.Parse(`{"client":{"ip":"1.2.3.4"},"response":{"code":404},"at":1700000000000,"request":{"uri":"/a"}}`,
+		time.Time{}, time.Time{})
+	data.Parse(`not a json line`, time.Time{}, time.Time{})
+	data.Parse(`{"msg":"server started"}`, time.Time{}, time.Time{})
+	data.Parse(`{"response":{"code":200}} garbage`, time.Time{}, time.Time{})
+	data.Parse(`{"response":{"code":500}} {"response":{"code":500}}`, time.Time{}, time.Time{})
+	data.Parse(`{"response":{"code":404},"at":1700000000000}  `, time.Time{}, time.Time{})
+
+	assert.Equal(t, 2, data.TotalCounter)
+	assert.Equal(t, 4, data.UnparsedLogs)
+	assert.Equal(t, map[string]int{"404": 2}, data.CommonAnswers)
+	assert.Equal(t, map[string]int{"/a": 1}, data.RequestedResources)
+	assert.Equal(t, time.UnixMilli(1700000000000).UTC(), data.From)
+}
+
+func TestJSONFormat_Errors(t *testing.T) {
+	for _, mapping := range []string{"", "status", "=status", "status=", "time=ts:weeks", "request_time=d:hours", "a=b|"} {
+		_, err := logformats.NewJSON(mapping)
+		assert.Error(t, err, mapping)
+	}
+}
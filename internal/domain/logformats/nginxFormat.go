@@ -0,0 +1,97 @@
+package logformats
+
+import (
+	"strconv"
+	"strings"
+
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// NginxCombined - стандартный формат combined, который NGINX использует когда log_format не задан.
+const NginxCombined = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`
+
+// NginxMain - формат main из конфигурации NGINX по умолчанию.
+const NginxMain = NginxCombined + ` "$http_x_forwarded_for"`
+
+// NewNginx - строит парсер по строке директивы log_format, например
+// `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent $request_time`.
+func NewNginx(logFormat string) (*Format, error) {
+	segments, err := parseNginxFormat(logFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	return compile(segments)
+}
+
+// parseNginxFormat - разбивает строку log_format на литералы и переменные вида $name и ${name}.
+func parseNginxFormat(logFormat string) ([]segment, error) {
+	if strings.TrimSpace(logFormat) == "" {
+		return nil, errors.ErrLogFormatSyntax{Reason: "empty log format"}
+	}
+
+	var (
+		segments []segment
+		literal  strings.Builder
+	)
+
+	for i := 0; i < len(logFormat); {
+		if logFormat[i] != '$' {
+			literal.WriteByte(logFormat[i])
+			i++
+
+			continue
+		}
+
+		name, width := nginxVariableName(logFormat[i+1:])
+		if name == "" {
+			return nil, errors.ErrLogFormatSyntax{Reason: "bad variable name at position " + strconv.Itoa(i)}
+		}
+
+		if literal.Len() > 0 {
+			segments = append(segments, segment{literal: literal.String()})
+			literal.Reset()
+		}
+
+		segments = append(segments, segment{variable: name})
+		i += width + 1
+	}
+
+	if literal.Len() > 0 {
+		segments = append(segments, segment{literal: literal.String()})
+	}
+
+	return segments, nil
+}
+
+// nginxVariableName - читает имя переменной сразу после '$', возвращает имя и число прочитанных байт.
+func nginxVariableName(s string) (name string, width int) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 2 || !isVariableName(s[1:end]) {
+			return "", 0
+		}
+
+		return s[1:end], end + 1
+	}
+
+	for width < len(s) && isVariableChar(s[width]) {
+		width++
+	}
+
+	return s[:width], width
+}
+
+func isVariableName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isVariableChar(s[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isVariableChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
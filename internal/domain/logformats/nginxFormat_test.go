@@ -0,0 +1,81 @@
+package logformats_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/logformats"
+)
+
+func TestNewNginx_CustomFormat(t *testing.T) {
+	parser, err := logformats.NewNginx(
+		`$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent $request_time "${upstream_response_time}"`)
+	require.NoError(t, err)
+
+	var entry domain.LogEntry
+
+	err = parser.Parse(`10.0.0.1 - bob [17/May/2015:08:05:24 +0000] "POST /api/v1/items HTTP/2.0" 201 512 0.153 "0.100, 0.050"`,
+		&entry)
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2015, 5, 17, 8, 5, 24, 0, time.UTC), entry.Time.UTC())
+
+	expected := map[string]string{
+		"remote_addr":            "10.0.0.1",
+		"remote_user":            "bob",
+		"request_time":           "0.153",
+		"upstream_response_time": "0.100, 0.050",
+		domain.Status:            "201",
+		domain.HTTPCode:          "201",
+		domain.HTTPReq:           "POST",
+		domain.Resource:          "/api/v1/items",
+		domain.HTTPVersion:       "HTTP/2.0",
+		domain.BytesSend:         "512",
+	}
+
+	for name, value := range expected {
+		actual, ok := entry.Get(name)
+		assert.True(t, ok, name)
+		assert.Equal(t, value, actual, name)
+	}
+
+	assert.True(t, domain.HasField(parser.Fields(), domain.HTTPCode))
+	assert.True(t, domain.HasField(parser.Fields(), "request_time"))
+	assert.False(t, domain.HasField(parser.Fields(), domain.HTTPUserAgent))
+}
+
+func TestNewNginx_Errors(t *testing.T) {
+	testCases := []struct {
+		testScenario string
+		logFormat    string
+	}{
+		{testScenario: "empty format", logFormat: "  "},
+		{testScenario: "no variables", logFormat: "just text"},
+		{testScenario: "bad variable name", logFormat: "$remote_addr $"},
+		{testScenario: "unclosed brace", logFormat: "${remote_addr"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.testScenario, func(tt *testing.T) {
+			_, err := logformats.NewNginx(tc.logFormat)
+			assert.Error(tt, err)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	parser, err := logformats.Resolve(logformats.SyntaxNginx, "main")
+	require.NoError(t, err)
+	assert.Contains(t, parser.Fields(), "http_x_forwarded_for")
+
+	parser, err = logformats.Resolve(logformats.SyntaxNginx, "$remote_addr $status")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"remote_addr", domain.Status}, parser.Fields())
+
+	_, err = logformats.Resolve("unknown", "$remote_addr")
+	assert.Error(t, err)
+}
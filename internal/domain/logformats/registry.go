@@ -0,0 +1,99 @@
+package logformats
+
+import (
+	"slices"
+	"strings"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// Синтаксисы, в которых может быть записана пользовательская строка формата.
+const (
+	SyntaxNginx  = "nginx"
+	SyntaxApache = "apache"
+	SyntaxJSON   = "json"
+)
+
+// DefaultFormat - имя формата, который используется если формат не указан.
+const DefaultFormat = "combined"
+
+// Factory - создает новый парсер формата.
+type Factory func() (domain.LineParser, error)
+
+// registry - зарегистрированные форматы по имени. Регистрация не потокобезопасна
+// и должна выполняться до начала обработки логов.
+var registry = map[string]Factory{
+	"combined": func() (domain.LineParser, error) { return NewCombined(), nil },
+	"main":     nginxFactory(NginxMain),
+
+	"apache-common":   apacheFactory(ApacheCommon),
+	"apache-combined": apacheFactory(ApacheCombined),
+
+	"caddy":      jsonFactory(CaddyJSON),
+	"traefik":    jsonFactory(TraefikJSON),
+	"nginx-json": jsonFactory(NginxJSON),
+}
+
+func nginxFactory(logFormat string) Factory {
+	return func() (domain.LineParser, error) {
+		return NewNginx(logFormat)
+	}
+}
+
+func apacheFactory(logFormat string) Factory {
+	return func() (domain.LineParser, error) {
+		return NewApache(logFormat)
+	}
+}
+
+func jsonFactory(mapping string) Factory {
+	return func() (domain.LineParser, error) {
+		return NewJSON(mapping)
+	}
+}
+
+// Register - добавляет формат в реестр, существующий формат с таким именем будет заменен.
+func Register(name string, factory Factory) {
+	registry[name] = factory
+}
+
+// Names - имена зарегистрированных форматов в алфавитном порядке.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// Resolve - возвращает парсер по имени зарегистрированного формата, а если такого имени нет -
+// строит его из spec как строку формата в синтаксисе syntax.
+func Resolve(syntax, spec string) (domain.LineParser, error) {
+	if spec == "" {
+		spec = DefaultFormat
+	}
+
+	if factory, ok := registry[spec]; ok {
+		return factory()
+	}
+
+	switch syntax {
+	case SyntaxNginx, "":
+		// Для стандартного формата combined есть более быстрый разбор без регулярных выражений
+		if strings.TrimSpace(spec) == NginxCombined {
+			return NewCombined(), nil
+		}
+
+		return NewNginx(spec)
+	case SyntaxApache:
+		return NewApache(spec)
+	case SyntaxJSON:
+		return NewJSON(spec)
+	default:
+		return nil, errors.ErrUnknownLogFormat{}
+	}
+}
@@ -0,0 +1,108 @@
+package logformats
+
+import (
+	"sync"
+	"time"
+
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// zones - кэш часовых поясов по смещению в секундах, чтобы не создавать time.FixedZone на каждой строке.
+var zones sync.Map
+
+// parseTimeLocal - быстрый разбор времени в фиксированном формате $time_local "02/Jan/2006:15:04:05 -0700"
+// без разбора строки шаблона, как это делает time.Parse, и без аллокаций.
+func parseTimeLocal(value string) (time.Time, error) {
+	if len(value) != len(timeLocalLayout) || value[2] != '/' || value[6] != '/' || value[11] != ':' ||
+		value[14] != ':' || value[17] != ':' || value[20] != ' ' {
+		return time.Time{}, errors.ErrTimeParsing{}
+	}
+
+	month := parseMonth(value[3:6])
+	day, okDay := parseDigits(value[0:2])
+	year, okYear := parseDigits(value[7:11])
+	hour, okHour := parseDigits(value[12:14])
+	minute, okMinute := parseDigits(value[15:17])
+	second, okSecond := parseDigits(value[18:20])
+	zoneHours, okZoneHours := parseDigits(value[22:24])
+	zoneMinutes, okZoneMinutes := parseDigits(value[24:26])
+
+	if month == 0 || !okDay || !okYear || !okHour || !okMinute || !okSecond || !okZoneHours || !okZoneMinutes ||
+		(value[21] != '+' && value[21] != '-') {
+		return time.Time{}, errors.ErrTimeParsing{}
+	}
+
+	if day < 1 || day > daysIn(month, year) || hour > 23 || minute > 59 || second > 59 || zoneMinutes > 59 {
+		return time.Time{}, errors.ErrTimeParsing{}
+	}
+
+	offset := zoneHours*3600 + zoneMinutes*60
+	if value[21] == '-' {
+		offset = -offset
+	}
+
+	return time.Date(year, month, day, hour, minute, second, 0, zone(offset)), nil
+}
+
+func zone(offset int) *time.Location {
+	if offset == 0 {
+		return time.UTC
+	}
+
+	if location, ok := zones.Load(offset); ok {
+		return location.(*time.Location)
+	}
+
+	location, _ := zones.LoadOrStore(offset, time.FixedZone("", offset))
+
+	return location.(*time.Location)
+}
+
+func parseDigits(s string) (int, bool) {
+	number := 0
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return 0, false
+		}
+
+		number = number*10 + int(s[i]-'0')
+	}
+
+	return number, true
+}
+
+func parseMonth(s string) time.Month {
+	switch s {
+	case "Jan":
+		return time.January
+	case "Feb":
+		return time.February
+	case "Mar":
+		return time.March
+	case "Apr":
+		return time.April
+	case "May":
+		return time.May
+	case "Jun":
+		return time.June
+	case "Jul":
+		return time.July
+	case "Aug":
+		return time.August
+	case "Sep":
+		return time.September
+	case "Oct":
+		return time.October
+	case "Nov":
+		return time.November
+	case "Dec":
+		return time.December
+	default:
+		return 0
+	}
+}
+
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
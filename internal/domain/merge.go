@@ -0,0 +1,276 @@
+package domain
+
+import (
+	"slices"
+
+	"LogAnalyzer/pkg/sketch"
+)
+
+// Merge - добавляет данные other, разобранные отдельно, например другим обработчиком. Если сливать данные
+// по файлам в том же порядке, в котором их разбирал бы один DataHolder, результат совпадает с разбором подряд.
+// Исключение - переполнение лимита параметров запроса: новые параметры добавляются по имени, а не по порядку
+// появления. Скетчи other переходят к s, после слияния other использовать нельзя.
+func (s *DataHolder) Merge(other *DataHolder) error {
+	s.TotalCounter += other.TotalCounter
+	s.UnparsedLogs += other.UnparsedLogs
+	s.QueryRequests += other.QueryRequests
+	s.BotRequests += other.BotRequests
+	s.TruncatedLines += other.TruncatedLines
+
+	mergeTimeBounds(s, other)
+	addCounts(s.UnparsedReasons, other.UnparsedReasons)
+	addCounts(s.HTTPRequests, other.HTTPRequests)
+	addCounts(s.RequestedResources, other.RequestedResources)
+	addCounts(s.CommonAnswers, other.CommonAnswers)
+	addCounts(s.Browsers, other.Browsers)
+	addCounts(s.OperatingSystems, other.OperatingSystems)
+	addCounts(s.Devices, other.Devices)
+	addCounts(s.Bots, other.Bots)
+	s.mergeSamples(other)
+	s.mergeReadErrors(other)
+	s.mergeSources(other)
+	s.mergeClients(other)
+	s.mergeGeo(other)
+
+	s.ResponseSizeMoments.Merge(other.ResponseSizeMoments)
+
+	for _, merge := range []func() error{
+		func() error { return s.ResponseSizes.Merge(other.ResponseSizes) },
+		func() error { return s.RequestTimes.Merge(other.RequestTimes) },
+		func() error { return s.UpstreamTimes.Merge(other.UpstreamTimes) },
+		func() error { return s.Visitors.Merge(other.Visitors) },
+		func() error {
+			return mergeSketches(s.ResourceLatencies, other.ResourceLatencies, (*sketch.DDSketch).Merge)
+		},
+		func() error { return s.mergeResourceVisitors(other) },
+		func() error { return s.mergeQueryParams(other) },
+		func() error { return s.mergeTraffic(other) },
+	} {
+		if err := merge(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func mergeTimeBounds(s, other *DataHolder) {
+	if !other.From.IsZero() && (s.From.IsZero() || other.From.Before(s.From)) {
+		s.From = other.From
+	}
+
+	if !other.To.IsZero() && (s.To.IsZero() || other.To.After(s.To)) {
+		s.To = other.To
+	}
+
+	if s.location == nil {
+		s.location = other.location
+	}
+}
+
+func addCounts(data, other map[string]int) {
+	for key, count := range other {
+		data[key] += count
+	}
+}
+
+// mergeSketches - сливает скетчи по ключам, скетч ключа, которого еще не было, забирается как есть.
+func mergeSketches[T any](data, other map[string]*T, merge func(*T, *T) error) error {
+	for key, value := range other {
+		current, ok := data[key]
+		if !ok {
+			data[key] = value
+
+			continue
+		}
+
+		if err := merge(current, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeSamples - примеры нераспаршенных строк other дополняют свои, пока их меньше maxSamplesPerReason.
+func (s *DataHolder) mergeSamples(other *DataHolder) {
+	for reason, samples := range other.UnparsedSamples {
+		free := maxSamplesPerReason - len(s.UnparsedSamples[reason])
+		if free > 0 {
+			s.UnparsedSamples[reason] = append(s.UnparsedSamples[reason], samples[:min(free, len(samples))]...)
+		}
+	}
+}
+
+// mergeReadErrors - ошибки чтения other добавляются после своих, пока их меньше maxReadErrors.
+func (s *DataHolder) mergeReadErrors(other *DataHolder) {
+	if free := maxReadErrors - len(s.ReadErrors); free > 0 {
+		s.ReadErrors = append(s.ReadErrors, other.ReadErrors[:min(free, len(other.ReadErrors))]...)
+	}
+}
+
+// mergeSources - источники other добавляются после своих, счетчики одноименных складываются.
+func (s *DataHolder) mergeSources(other *DataHolder) {
+	for _, source := range other.Sources {
+		index := slices.IndexFunc(s.Sources, func(current SourceCounters) bool { return current.Name == source.Name })
+		if index < 0 {
+			s.Sources = append(s.Sources, source)
+
+			continue
+		}
+
+		current := &s.Sources[index]
+		current.Requests += source.Requests
+		current.Unparsed += source.Unparsed
+		current.Errors += source.Errors
+		current.Bytes += source.Bytes
+	}
+}
+
+func (s *DataHolder) mergeClients(other *DataHolder) {
+	for address, client := range other.Clients {
+		counters, ok := s.Clients[address]
+		if !ok {
+			s.Clients[address] = client
+
+			continue
+		}
+
+		counters.Requests += client.Requests
+		counters.Errors += client.Errors
+		counters.Bytes += client.Bytes
+
+		if !client.FirstSeen.IsZero() && (counters.FirstSeen.IsZero() || client.FirstSeen.Before(counters.FirstSeen)) {
+			counters.FirstSeen = client.FirstSeen
+		}
+
+		if client.LastSeen.After(counters.LastSeen) {
+			counters.LastSeen = client.LastSeen
+		}
+
+		s.Clients[address] = counters
+	}
+}
+
+func (s *DataHolder) mergeGeo(other *DataHolder) {
+	for _, pair := range [][2]map[string]GeoCounters{{s.Countries, other.Countries}, {s.ASNs, other.ASNs}} {
+		for key, counters := range pair[1] {
+			current := pair[0][key]
+			current.Requests += counters.Requests
+			current.Bytes += counters.Bytes
+			pair[0][key] = current
+		}
+	}
+
+	for asn, organization := range other.ASNOrganizations {
+		if _, known := s.ASNOrganizations[asn]; !known {
+			s.ASNOrganizations[asn] = organization
+		}
+	}
+}
+
+// mergeResourceVisitors - скетчи известных ресурсов сливаются, новые добавляются пока не достигнут
+// MaxResourceVisitors.
+func (s *DataHolder) mergeResourceVisitors(other *DataHolder) error {
+	resources := make([]string, 0, len(other.ResourceVisitors))
+	for resource := range other.ResourceVisitors {
+		resources = append(resources, resource)
+	}
+
+	slices.Sort(resources)
+
+	for _, resource := range resources {
+		visitors := other.ResourceVisitors[resource]
+
+		current, ok := s.ResourceVisitors[resource]
+		if !ok {
+			if len(s.ResourceVisitors) < MaxResourceVisitors {
+				s.ResourceVisitors[resource] = visitors
+			}
+
+			continue
+		}
+
+		if err := current.Merge(visitors); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// mergeQueryParams - счетчики известных параметров складываются, новые добавляются пока не достигнут лимит.
+func (s *DataHolder) mergeQueryParams(other *DataHolder) error {
+	names := make([]string, 0, len(other.QueryParams))
+	for name := range other.QueryParams {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		counters := other.QueryParams[name]
+
+		current, ok := s.QueryParams[name]
+		if !ok {
+			if len(s.QueryParams) < maxQueryParams {
+				s.QueryParams[name] = counters
+			}
+
+			continue
+		}
+
+		current.Requests += counters.Requests
+
+		if err := current.Values.Merge(counters.Values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *DataHolder) mergeTraffic(other *DataHolder) error {
+	for minute, counters := range other.Traffic {
+		current, ok := s.Traffic[minute]
+		if !ok {
+			s.Traffic[minute] = counters
+
+			continue
+		}
+
+		current.Requests += counters.Requests
+		current.ClientErrors += counters.ClientErrors
+		current.ServerErrors += counters.ServerErrors
+		current.Bytes += counters.Bytes
+		s.Traffic[minute] = current
+	}
+
+	return s.mergeIntervalVisitors(other)
+}
+
+// mergeIntervalVisitors - сливает скетчи клиентов по времени, если шаги разные - по более крупному.
+func (s *DataHolder) mergeIntervalVisitors(other *DataHolder) error {
+	if other.visitorStep() > s.visitorStep() {
+		s.coarsenVisitors(other.visitorStep())
+	}
+
+	for cell, visitors := range other.TrafficVisitors {
+		cell = floorDiv(cell*other.visitorStep(), s.visitorStep())
+
+		current, ok := s.TrafficVisitors[cell]
+		if !ok {
+			s.TrafficVisitors[cell] = visitors
+
+			continue
+		}
+
+		if err := current.Merge(visitors); err != nil {
+			return err
+		}
+	}
+
+	s.limitVisitorCells()
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+const (
+	// ReasonUnknown - причина отказа для ошибок разбора, которые не сообщают свою причину.
+	ReasonUnknown = "unknown"
+	// maxSamplesPerReason - сколько примеров строк на каждую причину попадает в отчет.
+	maxSamplesPerReason = 3
+	// maxSampleLength - длина, до которой обрезаются примеры строк в отчете.
+	maxSampleLength = 200
+	// maxReadErrors - сколько ошибок чтения источников запоминается для отчета.
+	maxReadErrors = 100
+)
+
+// RejectedLine - строка, которую не удалось разобрать, вместе с местом в источнике и причиной.
+type RejectedLine struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+	Text   string `json:"text"`
+}
+
+// RejectSink - получатель отклоненных строк, например файл карантина.
+type RejectSink interface {
+	Reject(line RejectedLine)
+}
+
+// ReadError - ошибка открытия или чтения источника, Line - номер последней прочитанной строки.
+type ReadError struct {
+	Source string
+	Line   int
+	Error  string
+}
+
+// ParseQuality - разбивка нераспаршенных строк по причинам, несколько примеров, число обрезанных длинных строк
+// и ошибки чтения источников для отчета.
+type ParseQuality struct {
+	Reasons    []KeyCount
+	Samples    []RejectedLine
+	Truncated  int
+	ReadErrors []ReadError
+}
+
+// reasoner - ошибки разбора, которые умеют сообщить машиночитаемую причину.
+type reasoner interface {
+	Reason() string
+}
+
+func rejectReason(err error) string {
+	if withReason, ok := err.(reasoner); ok {
+		return withReason.Reason()
+	}
+
+	return ReasonUnknown
+}
+
+// StartSource - сообщает какой источник сейчас разбирается. Для нового источника нумерация строк начинается
+// заново, а при возврате к уже встречавшемуся (в режиме слежения источники чередуются) - продолжается.
+func (s *DataHolder) StartSource(name string) {
+	if current := s.currentSource(); current != nil {
+		current.lines = s.lineNumber
+	}
+
+	s.source = name
+	s.sourceIndex = slices.IndexFunc(s.Sources, func(source SourceCounters) bool { return source.Name == name })
+
+	if s.sourceIndex < 0 {
+		s.sourceIndex = len(s.Sources)
+		s.Sources = append(s.Sources, SourceCounters{Name: name})
+	}
+
+	s.lineNumber = s.Sources[s.sourceIndex].lines
+}
+
+// SetQuarantine - устанавливает получателя строк, которые не удалось разобрать.
+func (s *DataHolder) SetQuarantine(sink RejectSink) {
+	s.quarantine = sink
+}
+
+// RejectLine - учитывает строку, которую не стали разбирать, например слишком длинную, как нераспаршенную.
+func (s *DataHolder) RejectLine(singleLog string, err error) {
+	s.lineNumber++
+	s.reject(singleLog, err)
+}
+
+// CountTruncated - учитывает строку, обрезанную до ограничения длины перед разбором.
+func (s *DataHolder) CountTruncated() {
+	s.TruncatedLines++
+}
+
+// RecordReadError - запоминает ошибку открытия или чтения текущего источника.
+func (s *DataHolder) RecordReadError(err error) {
+	if len(s.ReadErrors) < maxReadErrors {
+		s.ReadErrors = append(s.ReadErrors, ReadError{Source: s.source, Line: s.lineNumber, Error: err.Error()})
+	}
+}
+
+// reject - учитывает нераспаршенную строку: считает причину, сохраняет пример и отдает строку в карантин.
+func (s *DataHolder) reject(singleLog string, err error) {
+	s.UnparsedLogs++
+
+	if source := s.currentSource(); source != nil {
+		source.Unparsed++
+	}
+
+	rejected := RejectedLine{Source: s.source, Line: s.lineNumber, Reason: rejectReason(err), Text: singleLog}
+
+	s.UnparsedReasons[rejected.Reason]++
+
+	if s.quarantine != nil {
+		s.quarantine.Reject(rejected)
+	}
+
+	if len(s.UnparsedSamples[rejected.Reason]) < maxSamplesPerReason {
+		rejected.Text = truncateSample(rejected.Text)
+		s.UnparsedSamples[rejected.Reason] = append(s.UnparsedSamples[rejected.Reason], rejected)
+	}
+}
+
+func truncateSample(text string) string {
+	if len(text) <= maxSampleLength {
+		return text
+	}
+
+	return strings.ToValidUTF8(text[:maxSampleLength], "") + "…"
+}
+
+// fillParseQuality - собирает причины по убыванию количества и примеры в порядке причин.
+func (s *Statistic) fillParseQuality(data *DataHolder) {
+	reasons := make([]KeyCount, 0, len(data.UnparsedReasons))
+	for reason, count := range data.UnparsedReasons {
+		reasons = append(reasons, KeyCount{Value: reason, Count: count})
+	}
+
+	sortKeyCounts(reasons)
+
+	samples := make([]RejectedLine, 0, len(reasons)*maxSamplesPerReason)
+	for _, reason := range reasons {
+		samples = append(samples, data.UnparsedSamples[reason.Value]...)
+	}
+
+	s.ParseQuality = ParseQuality{
+		Reasons: reasons, Samples: samples, Truncated: data.TruncatedLines, ReadErrors: slices.Clone(data.ReadErrors),
+	}
+}
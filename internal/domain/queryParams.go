@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"cmp"
+	"net/url"
+	"slices"
+	"strings"
+
+	"LogAnalyzer/pkg/sketch"
+)
+
+// Поля, на которые раскладывается $request_uri: путь и строка запроса, оба раскодированы из percent-encoding.
+const (
+	Path  = "path"
+	Query = "query"
+)
+
+const (
+	// maxQueryParams - сколько различных имен параметров отслеживается, имена сверх этого числа не учитываются,
+	// чтобы мусорные запросы не раздували память.
+	maxQueryParams = 1024
+	// Параметр считается сбросом кеша (cache-buster), если он встретился хотя бы в cacheBusterMinRequests запросах
+	// и почти каждый раз со своим значением: уникальных значений не меньше cacheBusterRatio от числа запросов.
+	cacheBusterMinRequests = 20
+	cacheBusterRatio       = 0.9
+)
+
+// QueryParamCounters - число запросов с параметром и скетч его различных значений.
+type QueryParamCounters struct {
+	Requests int
+	Values   *sketch.HyperLogLog
+}
+
+// QueryParamStats - строка рейтинга параметров запроса. Share - процент от запросов со строкой запроса,
+// Cardinality - оценка числа различных значений. Other отмечает строку, в которую просуммировано все,
+// что не вошло в рейтинг.
+type QueryParamStats struct {
+	Name        string
+	Requests    int
+	Share       float32
+	Cardinality uint64
+	CacheBuster bool
+	Other       bool
+}
+
+// SplitURI - делит URI на путь и строку запроса и раскодирует их. Путь раскодируется по сегментам, и
+// закодированный слеш (%2F) остается закодированным, чтобы не менять деление пути на сегменты: /files/a%2Fb и
+// /files/a/b - разные ресурсы. Если в URI некорректный percent-encoding, соответствующая часть возвращается как есть.
+func SplitURI(uri string) (path, query string) {
+	path, query, _ = strings.Cut(uri, "?")
+	path = unescapePath(path)
+
+	if decoded, err := url.QueryUnescape(query); err == nil {
+		query = decoded
+	}
+
+	return path, query
+}
+
+// unescapePath - раскодирует каждый сегмент пути, слеш внутри сегмента снова кодируется как %2F.
+func unescapePath(path string) string {
+	if !strings.Contains(path, "%") {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return path
+		}
+
+		segments[i] = strings.ReplaceAll(decoded, "/", "%2F")
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// collectQueryParams - учитывает имена и значения параметров из строки запроса $request_uri. Повторенный
+// в запросе параметр (?a=1&a=2) считается в одном запросе один раз, а все его значения попадают в скетч.
+func (s *DataHolder) collectQueryParams(uri string) {
+	_, query, ok := strings.Cut(uri, "?")
+	if !ok || query == "" {
+		return
+	}
+
+	s.QueryRequests++
+
+	// Параметры, уже учтенные в этом запросе, параметров в запросе обычно немного, поэтому хватает перебора
+	var buf [16]*QueryParamCounters
+
+	seen := buf[:0]
+
+	for query != "" {
+		var pair string
+
+		pair, query, _ = strings.Cut(query, "&")
+		if pair == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(pair, "=")
+
+		counters, ok := s.QueryParams[unescapeQuery(name)]
+		if !ok {
+			if len(s.QueryParams) >= maxQueryParams {
+				continue
+			}
+
+			name = strings.Clone(unescapeQuery(name))
+			counters = &QueryParamCounters{Values: sketch.NewHyperLogLog(breakdownPrecision)}
+			s.QueryParams[name] = counters
+		}
+
+		counters.Values.Add(sketch.Hash(unescapeQuery(value)))
+
+		if !slices.Contains(seen, counters) {
+			seen = append(seen, counters)
+			counters.Requests++
+		}
+	}
+}
+
+// unescapeQuery - раскодирует имя или значение параметра, без аллокаций если раскодировать нечего.
+func unescapeQuery(value string) string {
+	if !strings.ContainsAny(value, "%+") {
+		return value
+	}
+
+	if decoded, err := url.QueryUnescape(value); err == nil {
+		return decoded
+	}
+
+	return value
+}
+
+// fillQueryParams - рейтинг параметров по числу запросов, при равенстве - по имени.
+func (s *Statistic) fillQueryParams(data *DataHolder) {
+	params := make([]QueryParamStats, 0, len(data.QueryParams))
+	for name, counters := range data.QueryParams {
+		params = append(params, queryParamStats(name, counters, data.QueryRequests))
+	}
+
+	slices.SortFunc(params, func(a, b QueryParamStats) int {
+		if c := cmp.Compare(b.Requests, a.Requests); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	limit := s.Top.For(TopParams)
+	if len(params) > limit {
+		other := QueryParamStats{Other: true}
+		for _, param := range params[limit:] {
+			other.Requests += param.Requests
+		}
+
+		params = append(params[:limit:limit], other)
+	}
+
+	s.QueryParams = params
+}
+
+func queryParamStats(name string, counters *QueryParamCounters, total int) QueryParamStats {
+	stats := QueryParamStats{Name: name, Requests: counters.Requests, Cardinality: counters.Values.Estimate()}
+
+	if total > 0 {
+		stats.Share = float32(counters.Requests) / float32(total) * 100
+	}
+
+	// Оценка HyperLogLog может немного превышать число запросов, поэтому сравнивается с запасом, а не на равенство
+	stats.CacheBuster = counters.Requests >= cacheBusterMinRequests &&
+		float64(stats.Cardinality) >= cacheBusterRatio*float64(counters.Requests)
+
+	return stats
+}
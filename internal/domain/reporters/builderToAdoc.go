@@ -9,6 +9,12 @@ import (
 	"LogAnalyzer/internal/domain/errors"
 )
 
+// Начало и конец таблицы AsciiDoc.
+const (
+	adocHeader    = "[options=\"header\"]\n|=================\n"
+	adocHeaderEnd = "|=================\n\n"
+)
+
 type ReportADoc struct{}
 
 func (r *ReportADoc) Build(s *domain.Statistic, filepath string) (err error) {
@@ -32,72 +38,349 @@ func (r *ReportADoc) Build(s *domain.Statistic, filepath string) (err error) {
 }
 
 func (r *ReportADoc) buildMessage(stat *domain.Statistic) string {
-	const (
-		header    = "[options=\"header\"]\n|=================\n"
-		headerEnd = "|=================\n\n"
-	)
-
 	var builder strings.Builder
 
 	// Основной заголовок
 	builder.WriteString("= Log Analyzer Report\n\n")
 	builder.WriteString("== Общая информация\n\n")
-	builder.WriteString(header)
+	builder.WriteString(adocHeader)
 	builder.WriteString("| Метрика | Значение\n")
 	builder.WriteString(fmt.Sprintf("| Начальная дата | %s\n", stat.TimeRange.From.Format("02.01.2006 15:04:05")))
 	builder.WriteString(fmt.Sprintf("| Конечная дата | %s\n", stat.TimeRange.To.Format("02.01.2006 15:04:05")))
 	builder.WriteString(fmt.Sprintf("| Количество запросов | %d\n", stat.LogsMetrics.ProcessedLogs))
 	builder.WriteString(fmt.Sprintf("| Средний размер ответа | %.2f\n", stat.LogsMetrics.AverageAnswerSize))
 	builder.WriteString(fmt.Sprintf("| Нераспаршенных логов | %d\n", stat.LogsMetrics.UnparsedLogs))
-	builder.WriteString(fmt.Sprintf("| 95-й перцентиль размера ответа | %.2f\n", stat.NinetyFivePercentile))
-	builder.WriteString(fmt.Sprintf("| Медиана размера ответа | %.2f\n", stat.Median))
 	builder.WriteString(fmt.Sprintf("| Всего кодов ошибок | %d\n", stat.LogsMetrics.TotalError))
 	builder.WriteString(fmt.Sprintf("| Процент кодов ошибок от общего числа | %.2f\n", stat.ErrorRate))
-	builder.WriteString(headerEnd)
+
+	if hasVisitors(stat) {
+		builder.WriteString(fmt.Sprintf("| Уникальных клиентов (IP) | %d\n", stat.Visitors.ByIP))
+		builder.WriteString(fmt.Sprintf("| Уникальных клиентов (IP + User-Agent) | %d\n", stat.Visitors.ByIPAgent))
+	}
+
+	builder.WriteString(adocHeaderEnd)
+
+	r.buildResponseSize(&builder, stat)
 
 	// Топ HTTP запросов
 	builder.WriteString("== Топ HTTP запросов\n\n")
-	builder.WriteString(header)
+	builder.WriteString(adocHeader)
 	builder.WriteString("| Запрос | Количество\n")
 
 	for _, req := range stat.CommonStats.HTTPRequest {
-		builder.WriteString(fmt.Sprintf("| %s | %d\n", req.Value, req.Count))
+		builder.WriteString(fmt.Sprintf("| %s | %d\n", keyLabel(req), req.Count))
 	}
 
-	builder.WriteString(headerEnd)
+	builder.WriteString(adocHeaderEnd)
 
 	// Топ запрашиваемых ресурсов
 	builder.WriteString("== Топ запрашиваемых ресурсов\n\n")
-	builder.WriteString(header)
-	builder.WriteString("| Ресурс | Количество\n")
-
-	for _, res := range stat.CommonStats.Resource {
-		builder.WriteString(fmt.Sprintf("| %s | %d\n", res.Value, res.Count))
-	}
-
-	builder.WriteString(headerEnd)
+	r.buildResources(&builder, stat)
 
 	// Коды ответа
 	builder.WriteString("== Коды ответа\n\n")
-	builder.WriteString(header)
+	builder.WriteString(adocHeader)
 	builder.WriteString("| Категория | Количество\n")
 	builder.WriteString(fmt.Sprintf("| Информационные | %d\n", stat.ResponseCodes[domain.Informational]))
 	builder.WriteString(fmt.Sprintf("| Успешные | %d\n", stat.ResponseCodes[domain.Success]))
 	builder.WriteString(fmt.Sprintf("| Перенаправления | %d\n", stat.ResponseCodes[domain.Redirection]))
 	builder.WriteString(fmt.Sprintf("| Ошибки клиента | %d\n", stat.ResponseCodes[domain.ClientError]))
 	builder.WriteString(fmt.Sprintf("| Ошибки сервера | %d\n", stat.ResponseCodes[domain.ServerError]))
-	builder.WriteString(headerEnd)
+	builder.WriteString(adocHeaderEnd)
 
 	// Топ HTTP кодов ответа
 	builder.WriteString("== Топ HTTP кодов ответа\n\n")
-	builder.WriteString(header)
+	builder.WriteString(adocHeader)
 	builder.WriteString("| Код ответа | Количество\n")
 
 	for _, code := range stat.CommonStats.HTTPCode {
-		builder.WriteString(fmt.Sprintf("| %s | %d\n", code.Value, code.Count))
+		builder.WriteString(fmt.Sprintf("| %s | %d\n", keyLabel(code), code.Count))
 	}
 
-	builder.WriteString(headerEnd)
+	builder.WriteString(adocHeaderEnd)
+
+	r.buildSources(&builder, stat)
+	r.buildQueryParams(&builder, stat)
+	r.buildTopClients(&builder, stat)
+	r.buildUserAgents(&builder, stat)
+	r.buildGeo(&builder, stat)
+	r.buildLatency(&builder, stat)
+	r.buildTimeline(&builder, stat)
+	r.buildParseQuality(&builder, stat)
 
 	return builder.String()
 }
+
+// buildParseQuality - разбивка нераспаршенных строк по причинам с примерами, обрезанные строки и ошибки чтения.
+func (r *ReportADoc) buildParseQuality(builder *strings.Builder, stat *domain.Statistic) {
+	quality := stat.ParseQuality
+	if stat.LogsMetrics.UnparsedLogs == 0 && quality.Truncated == 0 && len(quality.ReadErrors) == 0 {
+		return
+	}
+
+	builder.WriteString("== Качество парсинга\n\n")
+
+	if quality.Truncated > 0 {
+		builder.WriteString(fmt.Sprintf("Обрезано длинных строк: %d\n\n", quality.Truncated))
+	}
+
+	if len(quality.Reasons) > 0 {
+		builder.WriteString(adocHeader)
+		builder.WriteString("| Причина | Количество\n")
+
+		for _, reason := range quality.Reasons {
+			builder.WriteString(fmt.Sprintf("| %s | %d\n", reason.Value, reason.Count))
+		}
+
+		builder.WriteString(adocHeaderEnd)
+		builder.WriteString(adocHeader)
+		builder.WriteString("| Источник | Строка | Причина | Пример\n")
+
+		for _, sample := range quality.Samples {
+			builder.WriteString(fmt.Sprintf("| %s | %d | %s | `+%s+`\n", escapeCell(sample.Source), sample.Line, sample.Reason,
+				escapeCell(sample.Text)))
+		}
+
+		builder.WriteString(adocHeaderEnd)
+	}
+
+	if len(quality.ReadErrors) > 0 {
+		builder.WriteString("=== Ошибки чтения\n\n")
+		builder.WriteString(adocHeader)
+		builder.WriteString("| Источник | Строка | Ошибка\n")
+
+		for _, readErr := range quality.ReadErrors {
+			builder.WriteString(fmt.Sprintf("| %s | %d | %s\n", escapeCell(readErr.Source), readErr.Line, escapeCell(readErr.Error)))
+		}
+
+		builder.WriteString(adocHeaderEnd)
+	}
+}
+
+// buildResponseSize - распределение размеров ответа, перцентили приблизительные с относительной ошибкой до 1%.
+func (r *ReportADoc) buildResponseSize(builder *strings.Builder, stat *domain.Statistic) {
+	builder.WriteString("== Размер ответа\n\n")
+	builder.WriteString(adocHeader)
+	builder.WriteString("| Метрика | Значение, байт\n")
+	builder.WriteString(fmt.Sprintf("| Минимум | %.0f\n", stat.ResponseSize.Min))
+
+	for _, percentile := range stat.ResponseSize.Percentiles {
+		builder.WriteString(fmt.Sprintf("| %s | %.2f\n", percentile.Label(), percentile.Value))
+	}
+
+	builder.WriteString(fmt.Sprintf("| Максимум | %.0f\n", stat.ResponseSize.Max))
+	builder.WriteString(fmt.Sprintf("| Среднее | %.2f\n", stat.ResponseSize.Mean))
+	builder.WriteString(fmt.Sprintf("| Стандартное отклонение | %.2f\n", stat.ResponseSize.StdDev))
+	builder.WriteString(adocHeaderEnd)
+}
+
+// buildResources - топ ресурсов, если известны клиенты - с оценкой уникальных клиентов для каждого ресурса.
+func (r *ReportADoc) buildResources(builder *strings.Builder, stat *domain.Statistic) {
+	builder.WriteString(adocHeader)
+
+	if !hasVisitors(stat) {
+		builder.WriteString("| Ресурс | Количество\n")
+
+		for _, res := range stat.CommonStats.Resource {
+			builder.WriteString(fmt.Sprintf("| %s | %d\n", keyLabel(res), res.Count))
+		}
+
+		builder.WriteString(adocHeaderEnd)
+
+		return
+	}
+
+	builder.WriteString("| Ресурс | Количество | Клиентов (IP) | Клиентов (IP + UA)\n")
+
+	for _, res := range stat.CommonStats.Resource {
+		byIP, byIPAgent := resourceVisitors(stat, res)
+		builder.WriteString(fmt.Sprintf("| %s | %d | %s | %s\n", keyLabel(res), res.Count, byIP, byIPAgent))
+	}
+
+	builder.WriteString(adocHeaderEnd)
+}
+
+// buildTopClients - топ клиентов с объемом ответов, долей ошибок и временем первого и последнего запроса.
+func (r *ReportADoc) buildTopClients(builder *strings.Builder, stat *domain.Statistic) {
+	if len(stat.TopClients) == 0 {
+		return
+	}
+
+	builder.WriteString("== " + clientsTitle(stat) + "\n\n")
+	builder.WriteString(adocHeader)
+	builder.WriteString("| Клиент | Запросов | Байт | Ошибок, % | Первый запрос | Последний запрос\n")
+
+	for _, client := range stat.TopClients {
+		builder.WriteString(fmt.Sprintf("| %s | %d | %d | %.2f | %s | %s\n", clientLabel(client), client.Requests,
+			client.Bytes, client.ErrorRate, seen(client.FirstSeen), seen(client.LastSeen)))
+	}
+
+	builder.WriteString(adocHeaderEnd)
+}
+
+// buildSources - разбивка по источникам, если она включена.
+func (r *ReportADoc) buildSources(builder *strings.Builder, stat *domain.Statistic) {
+	if len(stat.Sources) == 0 {
+		return
+	}
+
+	builder.WriteString("== Источники\n\n")
+	builder.WriteString(adocHeader)
+	builder.WriteString("| Источник | Запросов | Доля, % | Нераспаршенных | Ошибок, % | Байт\n")
+
+	for _, source := range stat.Sources {
+		builder.WriteString(fmt.Sprintf("| %s | %d | %.2f | %d | %.2f | %d\n", escapeCell(source.Name), source.Requests, source.Share,
+			source.Unparsed, source.ErrorRate, source.Bytes))
+	}
+
+	builder.WriteString(adocHeaderEnd)
+}
+
+// buildQueryParams - самые частые параметры строки запроса, параметры для сброса кеша отмечаются.
+func (r *ReportADoc) buildQueryParams(builder *strings.Builder, stat *domain.Statistic) {
+	if len(stat.QueryParams) == 0 {
+		return
+	}
+
+	builder.WriteString("== Параметры запроса\n\n")
+	builder.WriteString(adocHeader)
+	builder.WriteString("| Параметр | Запросов | Доля, % | Уникальных значений | Сброс кеша\n")
+
+	for _, param := range stat.QueryParams {
+		share, cardinality := paramStats(param)
+		builder.WriteString(fmt.Sprintf("| %s | %d | %s | %s | %s\n", paramLabel(param), param.Requests, share, cardinality,
+			cacheBuster(param)))
+	}
+
+	builder.WriteString(adocHeaderEnd)
+}
+
+// buildUserAgents - распределение по браузерам, ОС и устройствам и доля ботов, если включен классификатор User-Agent.
+func (r *ReportADoc) buildUserAgents(builder *strings.Builder, stat *domain.Statistic) {
+	userAgents := stat.UserAgents
+	if len(userAgents.Devices) == 0 {
+		return
+	}
+
+	r.buildKeyCounts(builder, "Браузеры", "Браузер", userAgents.Browsers)
+	r.buildKeyCounts(builder, "Операционные системы", "ОС", userAgents.OperatingSystems)
+	r.buildKeyCounts(builder, "Типы устройств", "Устройство", userAgents.Devices)
+
+	builder.WriteString(fmt.Sprintf("Запросов от ботов: %d (%.2f%%)\n\n", userAgents.BotRequests, userAgents.BotShare))
+	r.buildKeyCounts(builder, "Топ ботов", "Бот", userAgents.Bots)
+}
+
+// buildGeo - трафик по странам и ASN, если заданы базы MaxMind.
+func (r *ReportADoc) buildGeo(builder *strings.Builder, stat *domain.Statistic) {
+	if len(stat.Geo.Countries) == 0 {
+		return
+	}
+
+	builder.WriteString("== Трафик по странам\n\n")
+	builder.WriteString(adocHeader)
+	builder.WriteString("| Страна | Запросов | Доля, % | Байт\n")
+
+	for _, country := range stat.Geo.Countries {
+		builder.WriteString(fmt.Sprintf("| %s | %d | %.2f | %d\n", geoLabel(country), country.Requests, country.Share, country.Bytes))
+	}
+
+	builder.WriteString(adocHeaderEnd)
+	builder.WriteString("== Трафик по ASN\n\n")
+	builder.WriteString(adocHeader)
+	builder.WriteString("| ASN | Организация | Запросов | Доля, % | Байт\n")
+
+	for _, asn := range stat.Geo.ASNs {
+		builder.WriteString(fmt.Sprintf("| %s | %s | %d | %.2f | %d\n", geoLabel(asn), escapeCell(asn.Name), asn.Requests,
+			asn.Share, asn.Bytes))
+	}
+
+	builder.WriteString(adocHeaderEnd)
+}
+
+// buildKeyCounts - секция с рейтингом значений.
+func (r *ReportADoc) buildKeyCounts(builder *strings.Builder, title, column string, items []domain.KeyCount) {
+	if len(items) == 0 {
+		return
+	}
+
+	builder.WriteString(fmt.Sprintf("== %s\n\n", title))
+	builder.WriteString(adocHeader)
+	builder.WriteString(fmt.Sprintf("| %s | Количество\n", column))
+
+	for _, item := range items {
+		builder.WriteString(fmt.Sprintf("| %s | %d\n", keyLabel(item), item.Count))
+	}
+
+	builder.WriteString(adocHeaderEnd)
+}
+
+// buildTimeline - трафик по интервалам, интервал с наибольшим числом запросов отмечается как пик.
+func (r *ReportADoc) buildTimeline(builder *strings.Builder, stat *domain.Statistic) {
+	if len(stat.Timeline.Buckets) == 0 {
+		return
+	}
+
+	builder.WriteString(fmt.Sprintf("== Трафик по интервалам (%s)\n\n", stat.Timeline.BucketLabel()))
+	builder.WriteString(adocHeader)
+	builder.WriteString("| Начало интервала | Запросов | 4xx | 5xx | Ошибок, % | Байт")
+
+	if hasTimelineVisitors(stat) {
+		builder.WriteString(" | Клиентов (IP) | Клиентов (IP + UA)")
+	}
+
+	builder.WriteString("\n")
+
+	for i, bucket := range stat.Timeline.Buckets {
+		start := bucket.Start.Format(timelineLayout)
+		if i == stat.Timeline.Peak {
+			start += " *пик*"
+		}
+
+		builder.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %.2f | %d", start, bucket.Requests,
+			bucket.ClientErrors, bucket.ServerErrors, bucket.ErrorRate, bucket.Bytes))
+
+		if hasTimelineVisitors(stat) {
+			builder.WriteString(fmt.Sprintf(" | %d | %d", bucket.Visitors.ByIP, bucket.Visitors.ByIPAgent))
+		}
+
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString(adocHeaderEnd)
+}
+
+// buildLatency - секции времени обработки запросов, выводятся только если формат логов содержит время обработки.
+func (r *ReportADoc) buildLatency(builder *strings.Builder, stat *domain.Statistic) {
+	if stat.RequestLatency.Count == 0 && stat.UpstreamLatency.Count == 0 {
+		return
+	}
+
+	builder.WriteString("== Время обработки запросов\n\n")
+	builder.WriteString(adocHeader)
+	builder.WriteString("| Перцентиль | request_time, с | upstream_response_time, с\n")
+	builder.WriteString(fmt.Sprintf("| p50 | %.3f | %.3f\n", stat.RequestLatency.P50, stat.UpstreamLatency.P50))
+	builder.WriteString(fmt.Sprintf("| p90 | %.3f | %.3f\n", stat.RequestLatency.P90, stat.UpstreamLatency.P90))
+	builder.WriteString(fmt.Sprintf("| p95 | %.3f | %.3f\n", stat.RequestLatency.P95, stat.UpstreamLatency.P95))
+	builder.WriteString(fmt.Sprintf("| p99 | %.3f | %.3f\n", stat.RequestLatency.P99, stat.UpstreamLatency.P99))
+	builder.WriteString(fmt.Sprintf("| max | %.3f | %.3f\n", stat.RequestLatency.Max, stat.UpstreamLatency.Max))
+	builder.WriteString(adocHeaderEnd)
+
+	builder.WriteString("== Время обработки топ ресурсов\n\n")
+	r.buildEndpointLatency(builder, stat.ResourceLatency)
+
+	builder.WriteString("== Самые медленные ресурсы\n\n")
+	builder.WriteString(fmt.Sprintf("Учитываются ресурсы не меньше чем с %d запросами.\n\n", domain.MinSlowestRequests))
+	r.buildEndpointLatency(builder, stat.SlowestEndpoints)
+}
+
+func (r *ReportADoc) buildEndpointLatency(builder *strings.Builder, endpoints []domain.EndpointLatency) {
+	builder.WriteString(adocHeader)
+	builder.WriteString("| Ресурс | Запросов | p50, с | p95, с | p99, с | max, с\n")
+
+	for _, endpoint := range endpoints {
+		builder.WriteString(fmt.Sprintf("| %s | %d | %.3f | %.3f | %.3f | %.3f\n", escapeCell(endpoint.Resource),
+			endpoint.Latency.Count, endpoint.Latency.P50, endpoint.Latency.P95, endpoint.Latency.P99, endpoint.Latency.Max))
+	}
+
+	builder.WriteString(adocHeaderEnd)
+}
@@ -0,0 +1,583 @@
+package reporters
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+//go:embed report.html.tmpl
+var htmlTemplateSource string
+
+var htmlTemplate = template.Must(template.New("report").Parse(htmlTemplateSource))
+
+// ReportHTML - отчет одним файлом HTML для тех, кто смотрит его в браузере. Стили, скрипт сортировки таблиц
+// и диаграммы SVG встроены в файл, поэтому отчет открывается без сети и внешних ресурсов. Секции, для которых
+// в логах нет данных, в отчет не попадают.
+type ReportHTML struct{}
+
+func (r *ReportHTML) Build(s *domain.Statistic, filepath string) (err error) {
+	filepath += ".html"
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return errors.ErrFileCreation{}
+	}
+
+	defer file.Close()
+
+	if err = htmlTemplate.Execute(file, r.newReport(s, time.Now())); err != nil {
+		return errors.ErrFileWrite{}
+	}
+
+	return nil
+}
+
+type htmlReport struct {
+	Period      string
+	GeneratedAt string
+	Run         domain.RunInfo
+	Summary     []htmlFact
+	RunFacts    []htmlFact
+	Sections    []htmlSection
+}
+
+type htmlFact struct {
+	Name  string
+	Value string
+}
+
+// htmlSection - секция отчета: пояснения, диаграмма и таблицы, ID - якорь для оглавления.
+type htmlSection struct {
+	ID     string
+	Title  string
+	Notes  []string
+	Chart  template.HTML
+	Tables []htmlTable
+}
+
+// htmlTable - таблица секции, Sortable - столбцы сортируются щелчком по заголовку.
+type htmlTable struct {
+	Title    string
+	Sortable bool
+	Columns  []htmlColumn
+	Rows     []htmlRow
+}
+
+// htmlColumn - столбец таблицы, числовой столбец выравнивается вправо и сортируется по значению.
+type htmlColumn struct {
+	Name    string
+	Numeric bool
+}
+
+// htmlRow - строка таблицы, строка Other при сортировке остается последней.
+type htmlRow struct {
+	Cells []htmlCell
+	Other bool
+	Peak  bool
+}
+
+// htmlCell - ячейка таблицы, Sort - значение для сортировки, если оно отличается от текста.
+type htmlCell struct {
+	Text    string
+	Sort    string
+	Numeric bool
+	Code    bool
+}
+
+func (r *ReportHTML) newReport(stat *domain.Statistic, now time.Time) htmlReport {
+	report := htmlReport{
+		Period:      period(stat.TimeRange),
+		GeneratedAt: now.Format(seenLayout),
+		Run:         stat.Run,
+		Summary:     r.summary(stat),
+		RunFacts:    r.runFacts(stat.Run),
+	}
+
+	report.add(
+		r.buildResponseSize(stat),
+		r.buildKeyCounts("Топ HTTP запросов", "Запрос", stat.CommonStats.HTTPRequest),
+		r.buildResources(stat),
+		r.buildResponseCodes(stat),
+		r.buildSources(stat),
+		r.buildQueryParams(stat),
+		r.buildTopClients(stat),
+	)
+	report.add(r.buildUserAgents(stat)...)
+	report.add(r.buildGeo(stat)...)
+	report.add(r.buildLatency(stat), r.buildTimeline(stat), r.buildParseQuality(stat))
+
+	return report
+}
+
+// add - добавляет секции, пропуская пустые таблицы и секции, в которых ничего не осталось.
+func (h *htmlReport) add(sections ...htmlSection) {
+	for _, section := range sections {
+		tables := section.Tables[:0]
+
+		for _, table := range section.Tables {
+			if len(table.Rows) > 0 {
+				tables = append(tables, table)
+			}
+		}
+
+		section.Tables = tables
+
+		if len(section.Tables) == 0 && section.Chart == "" && len(section.Notes) == 0 {
+			continue
+		}
+
+		section.ID = "section-" + strconv.Itoa(len(h.Sections)+1)
+		h.Sections = append(h.Sections, section)
+	}
+}
+
+// addRow - добавляет строку, выравнивание ячеек берется из столбцов.
+func (t *htmlTable) addRow(row htmlRow) {
+	for i := range row.Cells {
+		row.Cells[i].Numeric = i < len(t.Columns) && t.Columns[i].Numeric
+	}
+
+	t.Rows = append(t.Rows, row)
+}
+
+// summary - основные показатели в карточках в начале отчета.
+func (r *ReportHTML) summary(stat *domain.Statistic) []htmlFact {
+	facts := []htmlFact{
+		{Name: "Запросов", Value: strconv.Itoa(stat.LogsMetrics.ProcessedLogs)},
+		{Name: "Ошибок (4xx и 5xx)", Value: fmt.Sprintf("%.2f%%", stat.ErrorRate)},
+		{Name: "Средний размер ответа", Value: fmt.Sprintf("%.2f байт", stat.LogsMetrics.AverageAnswerSize)},
+		{Name: "Нераспаршенных строк", Value: strconv.Itoa(stat.LogsMetrics.UnparsedLogs)},
+	}
+
+	if hasVisitors(stat) {
+		facts = append(facts, htmlFact{Name: "Уникальных клиентов (IP)", Value: strconv.FormatUint(stat.Visitors.ByIP, 10)})
+	}
+
+	return facts
+}
+
+// runFacts - параметры запуска, незаданные параметры не выводятся.
+func (r *ReportHTML) runFacts(run domain.RunInfo) []htmlFact {
+	var facts []htmlFact
+
+	for _, fact := range []htmlFact{
+		{Name: "Версия", Value: run.Version},
+		{Name: "Источники", Value: strings.Join(run.Sources, ", ")},
+		{Name: "Файлы", Value: strings.Join(run.Files, ", ")},
+		{Name: "Формат логов", Value: strings.TrimSpace(run.LogFormat + " " + run.LogSyntax)},
+		{Name: "Фильтр", Value: run.Filter},
+		{Name: "Границы", Value: period(domain.TimeRange{From: run.From, To: run.To})},
+	} {
+		if fact.Value != "" {
+			facts = append(facts, fact)
+		}
+	}
+
+	return facts
+}
+
+// buildResponseSize - гистограмма и распределение размеров ответа.
+func (r *ReportHTML) buildResponseSize(stat *domain.Statistic) htmlSection {
+	size := stat.ResponseSize
+	section := htmlSection{Title: "Размер ответа"}
+
+	if len(size.Histogram) > 0 {
+		bars := make([]chartBar, 0, len(size.Histogram))
+
+		for _, bucket := range size.Histogram {
+			bars = append(bars, chartBar{Label: "≤ " + byteLabel(bucket.To), Value: float64(bucket.Count)})
+		}
+
+		section.Chart = barChart("Гистограмма размеров ответа", bars)
+		section.Notes = append(section.Notes, "Столбец включает ответы больше предыдущей границы и не больше своей.")
+	}
+
+	table := htmlTable{Columns: []htmlColumn{{Name: "Метрика"}, {Name: "Значение, байт", Numeric: true}}}
+	table.addRow(htmlRow{Cells: []htmlCell{textCell("Минимум"), fixedCell(size.Min, 0)}})
+
+	for _, percentile := range size.Percentiles {
+		table.addRow(htmlRow{Cells: []htmlCell{textCell(percentile.Label()), fixedCell(percentile.Value, 2)}})
+	}
+
+	table.addRow(htmlRow{Cells: []htmlCell{textCell("Максимум"), fixedCell(size.Max, 0)}})
+	table.addRow(htmlRow{Cells: []htmlCell{textCell("Среднее"), fixedCell(size.Mean, 2)}})
+	table.addRow(htmlRow{Cells: []htmlCell{textCell("Стандартное отклонение"), fixedCell(size.StdDev, 2)}})
+
+	section.Tables = []htmlTable{table}
+
+	return section
+}
+
+// buildResources - топ ресурсов, если известны клиенты - с оценкой уникальных клиентов для каждого ресурса.
+func (r *ReportHTML) buildResources(stat *domain.Statistic) htmlSection {
+	table := htmlTable{Sortable: true, Columns: []htmlColumn{{Name: "Ресурс"}, {Name: "Количество", Numeric: true}}}
+
+	if hasVisitors(stat) {
+		table.Columns = append(table.Columns, htmlColumn{Name: "Клиентов (IP)", Numeric: true},
+			htmlColumn{Name: "Клиентов (IP + UA)", Numeric: true})
+	}
+
+	for _, res := range stat.CommonStats.Resource {
+		row := htmlRow{Cells: []htmlCell{textCell(label(res.Value, res.Other)), countCell(res.Count)}, Other: res.Other}
+
+		if hasVisitors(stat) {
+			byIP, byIPAgent := resourceVisitors(stat, res)
+			row.Cells = append(row.Cells, textCell(byIP), textCell(byIPAgent))
+		}
+
+		table.addRow(row)
+	}
+
+	return htmlSection{Title: "Топ запрашиваемых ресурсов", Tables: []htmlTable{table}}
+}
+
+// buildResponseCodes - диаграмма классов кодов ответа и топ кодов.
+func (r *ReportHTML) buildResponseCodes(stat *domain.Statistic) htmlSection {
+	classes := []struct{ key, name, class string }{
+		{domain.Informational, "Информационные", "informational"},
+		{domain.Success, "Успешные", "success"},
+		{domain.Redirection, "Перенаправления", "redirection"},
+		{domain.ClientError, "Ошибки клиента", "client-error"},
+		{domain.ServerError, "Ошибки сервера", "server-error"},
+	}
+
+	total := 0
+
+	for _, class := range classes {
+		total += stat.ResponseCodes[class.key]
+	}
+
+	bars := make([]chartBar, 0, len(classes))
+	table := htmlTable{Title: "По категориям", Sortable: true, Columns: []htmlColumn{
+		{Name: "Категория"}, {Name: "Количество", Numeric: true}, {Name: "Доля, %", Numeric: true},
+	}}
+
+	for _, class := range classes {
+		count := stat.ResponseCodes[class.key]
+		bars = append(bars, chartBar{Label: class.name, Value: float64(count), Class: class.class})
+		table.addRow(htmlRow{Cells: []htmlCell{textCell(class.name), countCell(count), fixedCell(percent(count, total), 2)}})
+	}
+
+	codes := htmlTable{Title: "Топ HTTP кодов ответа", Sortable: true, Columns: []htmlColumn{
+		{Name: "Код ответа"}, {Name: "Количество", Numeric: true},
+	}}
+
+	for _, code := range stat.CommonStats.HTTPCode {
+		codes.addRow(htmlRow{Cells: []htmlCell{textCell(label(code.Value, code.Other)), countCell(code.Count)}, Other: code.Other})
+	}
+
+	return htmlSection{Title: "Коды ответа", Chart: barChart("Коды ответа по категориям", bars), Tables: []htmlTable{table, codes}}
+}
+
+// buildSources - разбивка по источникам, если она включена.
+func (r *ReportHTML) buildSources(stat *domain.Statistic) htmlSection {
+	table := htmlTable{Sortable: true, Columns: []htmlColumn{
+		{Name: "Источник"}, {Name: "Запросов", Numeric: true}, {Name: "Доля, %", Numeric: true},
+		{Name: "Нераспаршенных", Numeric: true}, {Name: "Ошибок, %", Numeric: true}, {Name: "Байт", Numeric: true},
+	}}
+
+	for _, source := range stat.Sources {
+		table.addRow(htmlRow{Cells: []htmlCell{
+			textCell(source.Name), countCell(source.Requests), fixedCell(float64(source.Share), 2), countCell(source.Unparsed),
+			fixedCell(float64(source.ErrorRate), 2), countCell(source.Bytes),
+		}})
+	}
+
+	return htmlSection{Title: "Источники", Tables: []htmlTable{table}}
+}
+
+// buildQueryParams - самые частые параметры строки запроса, параметры для сброса кеша отмечаются.
+func (r *ReportHTML) buildQueryParams(stat *domain.Statistic) htmlSection {
+	table := htmlTable{Sortable: true, Columns: []htmlColumn{
+		{Name: "Параметр"}, {Name: "Запросов", Numeric: true}, {Name: "Доля, %", Numeric: true},
+		{Name: "Уникальных значений", Numeric: true}, {Name: "Сброс кеша"},
+	}}
+
+	for _, param := range stat.QueryParams {
+		share, cardinality := paramStats(param)
+		table.addRow(htmlRow{Cells: []htmlCell{
+			textCell(label(param.Name, param.Other)), countCell(param.Requests), textCell(share), textCell(cardinality),
+			textCell(cacheBuster(param)),
+		}, Other: param.Other})
+	}
+
+	return htmlSection{Title: "Параметры запроса", Tables: []htmlTable{table}}
+}
+
+// buildTopClients - топ клиентов с объемом ответов, долей ошибок и временем первого и последнего запроса.
+func (r *ReportHTML) buildTopClients(stat *domain.Statistic) htmlSection {
+	table := htmlTable{Sortable: true, Columns: []htmlColumn{
+		{Name: "Клиент"}, {Name: "Запросов", Numeric: true}, {Name: "Байт", Numeric: true}, {Name: "Ошибок, %", Numeric: true},
+		{Name: "Первый запрос", Numeric: true}, {Name: "Последний запрос", Numeric: true},
+	}}
+
+	for _, client := range stat.TopClients {
+		table.addRow(htmlRow{Cells: []htmlCell{
+			textCell(label(client.Address, client.Other)), countCell(client.Requests), countCell(client.Bytes),
+			fixedCell(float64(client.ErrorRate), 2), timeCell(client.FirstSeen, seenLayout), timeCell(client.LastSeen, seenLayout),
+		}, Other: client.Other})
+	}
+
+	return htmlSection{Title: clientsTitle(stat), Tables: []htmlTable{table}}
+}
+
+// buildUserAgents - распределение по браузерам, ОС и устройствам и доля ботов, если включен классификатор User-Agent.
+func (r *ReportHTML) buildUserAgents(stat *domain.Statistic) []htmlSection {
+	userAgents := stat.UserAgents
+	if len(userAgents.Devices) == 0 {
+		return nil
+	}
+
+	bots := r.buildKeyCounts("Боты", "Бот", userAgents.Bots)
+	bots.Notes = []string{fmt.Sprintf("Запросов от ботов: %d (%.2f%%).", userAgents.BotRequests, userAgents.BotShare)}
+
+	return []htmlSection{
+		r.buildKeyCounts("Браузеры", "Браузер", userAgents.Browsers),
+		r.buildKeyCounts("Операционные системы", "ОС", userAgents.OperatingSystems),
+		r.buildKeyCounts("Типы устройств", "Устройство", userAgents.Devices),
+		bots,
+	}
+}
+
+// buildGeo - трафик по странам и ASN, если заданы базы MaxMind.
+func (r *ReportHTML) buildGeo(stat *domain.Statistic) []htmlSection {
+	countries := htmlTable{Sortable: true, Columns: []htmlColumn{
+		{Name: "Страна"}, {Name: "Запросов", Numeric: true}, {Name: "Доля, %", Numeric: true}, {Name: "Байт", Numeric: true},
+	}}
+
+	for _, country := range stat.Geo.Countries {
+		countries.addRow(htmlRow{Cells: []htmlCell{
+			textCell(label(country.Value, country.Other)), countCell(country.Requests), fixedCell(float64(country.Share), 2),
+			countCell(country.Bytes),
+		}, Other: country.Other})
+	}
+
+	asns := htmlTable{Sortable: true, Columns: []htmlColumn{
+		{Name: "ASN"}, {Name: "Организация"}, {Name: "Запросов", Numeric: true}, {Name: "Доля, %", Numeric: true},
+		{Name: "Байт", Numeric: true},
+	}}
+
+	for _, asn := range stat.Geo.ASNs {
+		asns.addRow(htmlRow{Cells: []htmlCell{
+			textCell(label(asn.Value, asn.Other)), textCell(asn.Name), countCell(asn.Requests), fixedCell(float64(asn.Share), 2),
+			countCell(asn.Bytes),
+		}, Other: asn.Other})
+	}
+
+	return []htmlSection{
+		{Title: "Трафик по странам", Tables: []htmlTable{countries}},
+		{Title: "Трафик по ASN", Tables: []htmlTable{asns}},
+	}
+}
+
+// buildKeyCounts - секция с рейтингом значений.
+func (r *ReportHTML) buildKeyCounts(title, column string, items []domain.KeyCount) htmlSection {
+	table := htmlTable{Sortable: true, Columns: []htmlColumn{{Name: column}, {Name: "Количество", Numeric: true}}}
+
+	for _, item := range items {
+		table.addRow(htmlRow{Cells: []htmlCell{textCell(label(item.Value, item.Other)), countCell(item.Count)}, Other: item.Other})
+	}
+
+	return htmlSection{Title: title, Tables: []htmlTable{table}}
+}
+
+// buildLatency - время обработки запросов, выводится только если формат логов содержит время обработки.
+func (r *ReportHTML) buildLatency(stat *domain.Statistic) htmlSection {
+	if stat.RequestLatency.Count == 0 && stat.UpstreamLatency.Count == 0 {
+		return htmlSection{}
+	}
+
+	overall := htmlTable{Columns: []htmlColumn{
+		{Name: "Перцентиль"}, {Name: "request_time, с", Numeric: true}, {Name: "upstream_response_time, с", Numeric: true},
+	}}
+
+	for _, percentile := range []struct {
+		name              string
+		request, upstream float64
+	}{
+		{"p50", stat.RequestLatency.P50, stat.UpstreamLatency.P50},
+		{"p90", stat.RequestLatency.P90, stat.UpstreamLatency.P90},
+		{"p95", stat.RequestLatency.P95, stat.UpstreamLatency.P95},
+		{"p99", stat.RequestLatency.P99, stat.UpstreamLatency.P99},
+		{"max", stat.RequestLatency.Max, stat.UpstreamLatency.Max},
+	} {
+		overall.addRow(htmlRow{Cells: []htmlCell{
+			textCell(percentile.name), fixedCell(percentile.request, 3), fixedCell(percentile.upstream, 3),
+		}})
+	}
+
+	return htmlSection{
+		Title: "Время обработки запросов",
+		Notes: []string{fmt.Sprintf("В самые медленные ресурсы попадают ресурсы не меньше чем с %d запросами.", domain.MinSlowestRequests)},
+		Tables: []htmlTable{
+			overall,
+			r.buildEndpointLatency("Время обработки топ ресурсов", stat.ResourceLatency),
+			r.buildEndpointLatency("Самые медленные ресурсы", stat.SlowestEndpoints),
+		},
+	}
+}
+
+func (r *ReportHTML) buildEndpointLatency(title string, endpoints []domain.EndpointLatency) htmlTable {
+	table := htmlTable{Title: title, Sortable: true, Columns: []htmlColumn{
+		{Name: "Ресурс"}, {Name: "Запросов", Numeric: true}, {Name: "p50, с", Numeric: true}, {Name: "p95, с", Numeric: true},
+		{Name: "p99, с", Numeric: true}, {Name: "max, с", Numeric: true},
+	}}
+
+	for _, endpoint := range endpoints {
+		latency := endpoint.Latency
+		table.addRow(htmlRow{Cells: []htmlCell{
+			textCell(endpoint.Resource), countCell(latency.Count), fixedCell(latency.P50, 3), fixedCell(latency.P95, 3),
+			fixedCell(latency.P99, 3), fixedCell(latency.Max, 3),
+		}})
+	}
+
+	return table
+}
+
+// buildTimeline - график и таблица трафика по интервалам, интервал с наибольшим числом запросов отмечается как пик.
+func (r *ReportHTML) buildTimeline(stat *domain.Statistic) htmlSection {
+	buckets := stat.Timeline.Buckets
+	if len(buckets) == 0 {
+		return htmlSection{}
+	}
+
+	labels := make([]string, 0, len(buckets))
+	requests := make([]float64, 0, len(buckets))
+	failures := make([]float64, 0, len(buckets))
+	table := htmlTable{Sortable: true, Columns: []htmlColumn{
+		{Name: "Начало интервала", Numeric: true}, {Name: "Запросов", Numeric: true}, {Name: "4xx", Numeric: true},
+		{Name: "5xx", Numeric: true}, {Name: "Ошибок, %", Numeric: true}, {Name: "Байт", Numeric: true},
+	}}
+
+	if hasTimelineVisitors(stat) {
+		table.Columns = append(table.Columns, htmlColumn{Name: "Клиентов (IP)", Numeric: true},
+			htmlColumn{Name: "Клиентов (IP + UA)", Numeric: true})
+	}
+
+	for i, bucket := range buckets {
+		labels = append(labels, bucket.Start.Format(timelineLayout))
+		requests = append(requests, float64(bucket.Requests))
+		failures = append(failures, float64(bucket.ClientErrors+bucket.ServerErrors))
+
+		row := htmlRow{Peak: i == stat.Timeline.Peak, Cells: []htmlCell{
+			timeCell(bucket.Start, timelineLayout), countCell(bucket.Requests), countCell(bucket.ClientErrors),
+			countCell(bucket.ServerErrors), fixedCell(float64(bucket.ErrorRate), 2), countCell(bucket.Bytes),
+		}}
+
+		if hasTimelineVisitors(stat) {
+			row.Cells = append(row.Cells, countCell(bucket.Visitors.ByIP), countCell(bucket.Visitors.ByIPAgent))
+		}
+
+		table.addRow(row)
+	}
+
+	chart := lineChart("Трафик по интервалам", labels, []chartSeries{
+		{Name: "Запросов", Class: "requests", Values: requests},
+		{Name: "Ошибок (4xx и 5xx)", Class: "errors", Values: failures},
+	}, stat.Timeline.Peak)
+
+	return htmlSection{
+		Title: fmt.Sprintf("Трафик по интервалам (%s)", stat.Timeline.BucketLabel()), Chart: chart, Tables: []htmlTable{table},
+		Notes: []string{"Интервал с наибольшим числом запросов отмечен как пик."},
+	}
+}
+
+// buildParseQuality - разбивка нераспаршенных строк по причинам с примерами, обрезанные строки и ошибки чтения.
+func (r *ReportHTML) buildParseQuality(stat *domain.Statistic) htmlSection {
+	quality := stat.ParseQuality
+	section := htmlSection{Title: "Качество парсинга"}
+
+	if quality.Truncated > 0 {
+		section.Notes = append(section.Notes, fmt.Sprintf("Обрезано длинных строк: %d.", quality.Truncated))
+	}
+
+	reasons := htmlTable{Title: "Причины", Sortable: true, Columns: []htmlColumn{{Name: "Причина"}, {Name: "Количество", Numeric: true}}}
+
+	for _, reason := range quality.Reasons {
+		reasons.addRow(htmlRow{Cells: []htmlCell{textCell(reason.Value), countCell(reason.Count)}})
+	}
+
+	samples := htmlTable{Title: "Примеры", Sortable: true, Columns: []htmlColumn{
+		{Name: "Источник"}, {Name: "Строка", Numeric: true}, {Name: "Причина"}, {Name: "Пример"},
+	}}
+
+	for _, sample := range quality.Samples {
+		samples.addRow(htmlRow{Cells: []htmlCell{
+			textCell(sample.Source), countCell(sample.Line), textCell(sample.Reason), {Text: sample.Text, Code: true},
+		}})
+	}
+
+	readErrors := htmlTable{Title: "Ошибки чтения", Sortable: true, Columns: []htmlColumn{
+		{Name: "Источник"}, {Name: "Строка", Numeric: true}, {Name: "Ошибка"},
+	}}
+
+	for _, readErr := range quality.ReadErrors {
+		readErrors.addRow(htmlRow{Cells: []htmlCell{textCell(readErr.Source), countCell(readErr.Line), textCell(readErr.Error)}})
+	}
+
+	section.Tables = []htmlTable{reasons, samples, readErrors}
+
+	return section
+}
+
+// label - подпись строки рейтинга без экранирования: в HTML его делает шаблон.
+func label(value string, other bool) string {
+	if other {
+		return otherLabel
+	}
+
+	return value
+}
+
+// period - временной диапазон для заголовка, пустая строка если время неизвестно.
+func period(timeRange domain.TimeRange) string {
+	switch {
+	case timeRange.From.IsZero() && timeRange.To.IsZero():
+		return ""
+	case timeRange.To.IsZero():
+		return "с " + timeRange.From.Format(seenLayout)
+	case timeRange.From.IsZero():
+		return "по " + timeRange.To.Format(seenLayout)
+	default:
+		return timeRange.From.Format(seenLayout) + " - " + timeRange.To.Format(seenLayout)
+	}
+}
+
+func percent(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(count) / float64(total) * 100
+}
+
+func textCell(text string) htmlCell {
+	return htmlCell{Text: text}
+}
+
+func countCell[T int | uint64](count T) htmlCell {
+	text := fmt.Sprint(count)
+
+	return htmlCell{Text: text, Sort: text}
+}
+
+func fixedCell(value float64, precision int) htmlCell {
+	return htmlCell{Text: strconv.FormatFloat(value, 'f', precision, 64), Sort: strconv.FormatFloat(value, 'g', -1, 64)}
+}
+
+// timeCell - время в заданном формате, сортируется по Unix-времени, нулевое время - прочерк.
+func timeCell(t time.Time, layout string) htmlCell {
+	if t.IsZero() {
+		return htmlCell{Text: "-"}
+	}
+
+	return htmlCell{Text: t.Format(layout), Sort: strconv.FormatInt(t.Unix(), 10)}
+}
@@ -0,0 +1,72 @@
+package reporters_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/logformats"
+	"LogAnalyzer/internal/domain/reporters"
+)
+
+// buildHTML - строит отчет в формате HTML и возвращает его содержимое.
+func buildHTML(t *testing.T, stat *domain.Statistic) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "report")
+	require.NoError(t, (&reporters.ReportHTML{}).Build(stat, path))
+
+	data, err := os.ReadFile(path + ".html")
+	require.NoError(t, err)
+
+	return string(data)
+}
+
+func TestReportHTML_SelfContained(t *testing.T) {
+	report := buildHTML(t, fullStatistic())
+
+	assert.Empty(t, regexp.MustCompile(`(?i)(src|href)\s*=\s*"(https?:)?//`).FindAllString(report, -1), "no external resources")
+	assert.NotContains(t, report, "<link")
+	assert.Contains(t, report, "<style>")
+	assert.Contains(t, report, "<script>")
+
+	for _, chart := range []string{"Гистограмма размеров ответа", "Коды ответа по категориям", "Трафик по интервалам"} {
+		assert.Contains(t, report, `<svg class="chart" viewBox="0 0 720 260" role="img" aria-label="`+chart+`">`)
+	}
+
+	assert.Contains(t, report, `<table class="sortable">`)
+	assert.Contains(t, report, `<tr class="other">`, "rows with the rest stay last when sorted")
+	assert.Contains(t, report, `<tr class="peak">`)
+	assert.Contains(t, report, `data-sort="1431839100"`, "time columns are sorted by unix time")
+}
+
+func TestReportHTML_EscapesLogText(t *testing.T) {
+	stat := fullStatistic()
+	stat.CommonStats.Resource = []domain.KeyCount{{Value: `/search?q=<script>alert(1)</script>`, Count: 1}}
+	stat.ParseQuality.Samples[0].Text = `"><img src=x onerror=alert(1)>`
+
+	report := buildHTML(t, stat)
+
+	assert.NotContains(t, report, "<script>alert(1)</script>")
+	assert.NotContains(t, report, "<img")
+	assert.Contains(t, report, "/search?q=&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.Contains(t, report, "&#34;&gt;&lt;img src=x onerror=alert(1)&gt;")
+}
+
+func TestReportHTML_EmptySections(t *testing.T) {
+	stat := &domain.Statistic{}
+	stat.Fill(domain.NewDataHolder(logformats.NewCombined(), nil))
+
+	report := buildHTML(t, stat)
+
+	assert.Contains(t, report, "Коды ответа по категориям")
+	assert.NotContains(t, report, "Трафик по интервалам")
+	assert.NotContains(t, report, "Качество парсинга")
+	assert.Equal(t, strings.Count(report, "<section"), strings.Count(report, "</section>"))
+}
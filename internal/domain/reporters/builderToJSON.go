@@ -0,0 +1,394 @@
+package reporters
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// JSONSchemaVersion - версия схемы отчета в формате JSON, схема лежит рядом в report.schema.json. Версия
+// повышается при несовместимых изменениях: удалении, переименовании или смене типа поля. Новые поля добавляются
+// в схему необязательными и без смены версии.
+const JSONSchemaVersion = 1
+
+// ReportJSON - отчет в формате JSON для дашбордов и скриптов. Отчет содержит всю статистику и параметры запуска,
+// а его структура описана схемой версии JSONSchemaVersion. Секции, которых нет в логах, например время обработки
+// без $request_time, остаются нулевыми или пустыми, но не пропадают.
+type ReportJSON struct{}
+
+func (r *ReportJSON) Build(s *domain.Statistic, filepath string) (err error) {
+	filepath += ".json"
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return errors.ErrFileCreation{}
+	}
+
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	if err = encoder.Encode(newJSONReport(s, time.Now())); err != nil {
+		return errors.ErrFileWrite{}
+	}
+
+	return nil
+}
+
+type jsonReport struct {
+	SchemaVersion int              `json:"schema_version"`
+	GeneratedAt   time.Time        `json:"generated_at"`
+	Run           jsonRun          `json:"run"`
+	TimeRange     jsonTimeRange    `json:"time_range"`
+	Metrics       jsonMetrics      `json:"metrics"`
+	ResponseCodes jsonCodeClasses  `json:"response_codes"`
+	Top           jsonTop          `json:"top"`
+	ResponseSize  jsonSize         `json:"response_size"`
+	Latency       jsonLatency      `json:"latency"`
+	ParseQuality  jsonParseQuality `json:"parse_quality"`
+	Visitors      jsonVisitors     `json:"visitors"`
+	Clients       jsonClients      `json:"clients"`
+	UserAgents    jsonUserAgents   `json:"user_agents"`
+	Sources       []jsonSource     `json:"sources"`
+	QueryParams   []jsonQueryParam `json:"query_params"`
+	Geo           jsonGeo          `json:"geo"`
+	Timeline      jsonTimeline     `json:"timeline"`
+}
+
+type jsonRun struct {
+	ToolVersion string     `json:"tool_version"`
+	Sources     []string   `json:"sources"`
+	Files       []string   `json:"files"`
+	LogFormat   string     `json:"log_format"`
+	LogSyntax   string     `json:"log_syntax"`
+	Filter      string     `json:"filter"`
+	From        *time.Time `json:"from"`
+	To          *time.Time `json:"to"`
+}
+
+type jsonTimeRange struct {
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+}
+
+type jsonMetrics struct {
+	Requests            int     `json:"requests"`
+	Unparsed            int     `json:"unparsed"`
+	AverageResponseSize float32 `json:"average_response_size"`
+	Errors              int     `json:"errors"`
+	ErrorRate           float32 `json:"error_rate"`
+}
+
+type jsonCodeClasses struct {
+	Informational int `json:"informational"`
+	Success       int `json:"success"`
+	Redirection   int `json:"redirection"`
+	ClientError   int `json:"client_error"`
+	ServerError   int `json:"server_error"`
+}
+
+type jsonKeyCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+	Other bool   `json:"other"`
+}
+
+type jsonTop struct {
+	Requests  []jsonKeyCount `json:"requests"`
+	Resources []jsonKeyCount `json:"resources"`
+	Codes     []jsonKeyCount `json:"codes"`
+}
+
+type jsonPercentile struct {
+	Quantile float64 `json:"quantile"`
+	Value    float64 `json:"value"`
+}
+
+type jsonSize struct {
+	Min         float64          `json:"min"`
+	Max         float64          `json:"max"`
+	Mean        float64          `json:"mean"`
+	StdDev      float64          `json:"std_dev"`
+	Percentiles []jsonPercentile `json:"percentiles"`
+	Histogram   []jsonSizeBucket `json:"histogram"`
+}
+
+type jsonSizeBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count uint64  `json:"count"`
+}
+
+type jsonLatencyStats struct {
+	Count int     `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+	Max   float64 `json:"max"`
+}
+
+type jsonEndpointLatency struct {
+	Resource string `json:"resource"`
+	jsonLatencyStats
+}
+
+type jsonLatency struct {
+	Request   jsonLatencyStats      `json:"request"`
+	Upstream  jsonLatencyStats      `json:"upstream"`
+	Resources []jsonEndpointLatency `json:"resources"`
+	Slowest   []jsonEndpointLatency `json:"slowest"`
+}
+
+type jsonReadError struct {
+	Source string `json:"source"`
+	Line   int    `json:"line"`
+	Error  string `json:"error"`
+}
+
+type jsonParseQuality struct {
+	Reasons    []jsonKeyCount        `json:"reasons"`
+	Samples    []domain.RejectedLine `json:"samples"`
+	Truncated  int                   `json:"truncated"`
+	ReadErrors []jsonReadError       `json:"read_errors"`
+}
+
+type jsonVisitorCount struct {
+	ByIP      uint64 `json:"by_ip"`
+	ByIPAgent uint64 `json:"by_ip_agent"`
+}
+
+type jsonVisitors struct {
+	jsonVisitorCount
+	Resources map[string]jsonVisitorCount `json:"resources"`
+}
+
+type jsonClient struct {
+	Address   string     `json:"address"`
+	Requests  int        `json:"requests"`
+	Bytes     uint64     `json:"bytes"`
+	ErrorRate float32    `json:"error_rate"`
+	FirstSeen *time.Time `json:"first_seen"`
+	LastSeen  *time.Time `json:"last_seen"`
+	Other     bool       `json:"other"`
+}
+
+type jsonClients struct {
+	RankedBy string       `json:"ranked_by"`
+	Top      []jsonClient `json:"top"`
+}
+
+type jsonUserAgents struct {
+	Browsers         []jsonKeyCount `json:"browsers"`
+	OperatingSystems []jsonKeyCount `json:"operating_systems"`
+	Devices          []jsonKeyCount `json:"devices"`
+	Bots             []jsonKeyCount `json:"bots"`
+	BotRequests      int            `json:"bot_requests"`
+	BotShare         float32        `json:"bot_share"`
+}
+
+type jsonSource struct {
+	Name      string  `json:"name"`
+	Requests  int     `json:"requests"`
+	Unparsed  int     `json:"unparsed"`
+	Bytes     uint64  `json:"bytes"`
+	Share     float32 `json:"share"`
+	ErrorRate float32 `json:"error_rate"`
+}
+
+type jsonQueryParam struct {
+	Name        string  `json:"name"`
+	Requests    int     `json:"requests"`
+	Share       float32 `json:"share"`
+	Cardinality uint64  `json:"cardinality"`
+	CacheBuster bool    `json:"cache_buster"`
+	Other       bool    `json:"other"`
+}
+
+type jsonGeoTraffic struct {
+	Value    string  `json:"value"`
+	Name     string  `json:"name"`
+	Requests int     `json:"requests"`
+	Bytes    uint64  `json:"bytes"`
+	Share    float32 `json:"share"`
+	Other    bool    `json:"other"`
+}
+
+type jsonGeo struct {
+	Countries []jsonGeoTraffic `json:"countries"`
+	ASNs      []jsonGeoTraffic `json:"asns"`
+}
+
+type jsonBucket struct {
+	Start        time.Time        `json:"start"`
+	Requests     int              `json:"requests"`
+	ClientErrors int              `json:"client_errors"`
+	ServerErrors int              `json:"server_errors"`
+	ErrorRate    float32          `json:"error_rate"`
+	Bytes        uint64           `json:"bytes"`
+	Visitors     jsonVisitorCount `json:"visitors"`
+}
+
+type jsonTimeline struct {
+	Bucket        string       `json:"bucket"`
+	BucketSeconds int64        `json:"bucket_seconds"`
+	Peak          int          `json:"peak"`
+	HasVisitors   bool         `json:"has_visitors"`
+	Buckets       []jsonBucket `json:"buckets"`
+}
+
+// newJSONReport - раскладывает статистику по структуре схемы. Пустые списки выводятся как [], а не null,
+// чтобы потребителям не приходилось различать эти случаи.
+func newJSONReport(stat *domain.Statistic, now time.Time) jsonReport {
+	return jsonReport{
+		SchemaVersion: JSONSchemaVersion,
+		GeneratedAt:   now,
+		Run: jsonRun{
+			ToolVersion: stat.Run.Version, Sources: nonNil(stat.Run.Sources), Files: nonNil(stat.Run.Files),
+			LogFormat: stat.Run.LogFormat, LogSyntax: stat.Run.LogSyntax, Filter: stat.Run.Filter,
+			From: optionalTime(stat.Run.From), To: optionalTime(stat.Run.To),
+		},
+		TimeRange: jsonTimeRange{From: optionalTime(stat.TimeRange.From), To: optionalTime(stat.TimeRange.To)},
+		Metrics: jsonMetrics{
+			Requests: stat.LogsMetrics.ProcessedLogs, Unparsed: stat.LogsMetrics.UnparsedLogs,
+			AverageResponseSize: stat.LogsMetrics.AverageAnswerSize, Errors: stat.LogsMetrics.TotalError,
+			ErrorRate: stat.ErrorRate,
+		},
+		ResponseCodes: jsonCodeClasses{
+			Informational: stat.ResponseCodes[domain.Informational], Success: stat.ResponseCodes[domain.Success],
+			Redirection: stat.ResponseCodes[domain.Redirection], ClientError: stat.ResponseCodes[domain.ClientError],
+			ServerError: stat.ResponseCodes[domain.ServerError],
+		},
+		Top: jsonTop{
+			Requests: keyCounts(stat.CommonStats.HTTPRequest), Resources: keyCounts(stat.CommonStats.Resource),
+			Codes: keyCounts(stat.CommonStats.HTTPCode),
+		},
+		ResponseSize: jsonSize{
+			Min: stat.ResponseSize.Min, Max: stat.ResponseSize.Max, Mean: stat.ResponseSize.Mean,
+			StdDev: stat.ResponseSize.StdDev, Percentiles: mapSlice(stat.ResponseSize.Percentiles, newJSONPercentile),
+			Histogram: mapSlice(stat.ResponseSize.Histogram, newJSONSizeBucket),
+		},
+		Latency: jsonLatency{
+			Request: jsonLatencyStats(stat.RequestLatency), Upstream: jsonLatencyStats(stat.UpstreamLatency),
+			Resources: mapSlice(stat.ResourceLatency, newJSONEndpointLatency),
+			Slowest:   mapSlice(stat.SlowestEndpoints, newJSONEndpointLatency),
+		},
+		ParseQuality: jsonParseQuality{
+			Reasons: keyCounts(stat.ParseQuality.Reasons), Samples: nonNil(stat.ParseQuality.Samples),
+			Truncated: stat.ParseQuality.Truncated, ReadErrors: mapSlice(stat.ParseQuality.ReadErrors, newJSONReadError),
+		},
+		Visitors:    newJSONVisitors(stat),
+		Clients:     jsonClients{RankedBy: clientsRanking(stat), Top: mapSlice(stat.TopClients, newJSONClient)},
+		UserAgents:  newJSONUserAgents(stat.UserAgents),
+		Sources:     mapSlice(stat.Sources, func(source domain.SourceStats) jsonSource { return jsonSource(source) }),
+		QueryParams: mapSlice(stat.QueryParams, func(param domain.QueryParamStats) jsonQueryParam { return jsonQueryParam(param) }),
+		Geo: jsonGeo{
+			Countries: mapSlice(stat.Geo.Countries, newJSONGeoTraffic), ASNs: mapSlice(stat.Geo.ASNs, newJSONGeoTraffic),
+		},
+		Timeline: jsonTimeline{
+			Bucket: stat.Timeline.BucketLabel(), BucketSeconds: int64(stat.Timeline.Bucket / time.Second),
+			Peak: stat.Timeline.Peak, HasVisitors: stat.Timeline.HasVisitors, Buckets: mapSlice(stat.Timeline.Buckets, newJSONBucket),
+		},
+	}
+}
+
+// mapSlice - преобразует каждый элемент, nil превращается в пустой слайс.
+func mapSlice[T, R any](items []T, convert func(T) R) []R {
+	result := make([]R, 0, len(items))
+	for _, item := range items {
+		result = append(result, convert(item))
+	}
+
+	return result
+}
+
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+
+	return items
+}
+
+// optionalTime - время или null, если оно не задано.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+func keyCounts(items []domain.KeyCount) []jsonKeyCount {
+	return mapSlice(items, func(item domain.KeyCount) jsonKeyCount { return jsonKeyCount(item) })
+}
+
+func newJSONPercentile(percentile domain.Percentile) jsonPercentile {
+	return jsonPercentile(percentile)
+}
+
+func newJSONSizeBucket(bucket domain.SizeBucket) jsonSizeBucket {
+	return jsonSizeBucket(bucket)
+}
+
+func newJSONEndpointLatency(endpoint domain.EndpointLatency) jsonEndpointLatency {
+	return jsonEndpointLatency{Resource: endpoint.Resource, jsonLatencyStats: jsonLatencyStats(endpoint.Latency)}
+}
+
+func newJSONReadError(readErr domain.ReadError) jsonReadError {
+	return jsonReadError(readErr)
+}
+
+func newJSONVisitors(stat *domain.Statistic) jsonVisitors {
+	visitors := jsonVisitors{
+		jsonVisitorCount: jsonVisitorCount(stat.Visitors),
+		Resources:        make(map[string]jsonVisitorCount, len(stat.ResourceVisitors)),
+	}
+
+	for resource, count := range stat.ResourceVisitors {
+		visitors.Resources[resource] = jsonVisitorCount(count)
+	}
+
+	return visitors
+}
+
+// clientsRanking - по какому показателю построен топ клиентов, по умолчанию - по числу запросов.
+func clientsRanking(stat *domain.Statistic) string {
+	if stat.ClientsBy == "" {
+		return domain.ClientsByRequests
+	}
+
+	return stat.ClientsBy
+}
+
+func newJSONClient(client domain.ClientStats) jsonClient {
+	return jsonClient{
+		Address: client.Address, Requests: client.Requests, Bytes: client.Bytes, ErrorRate: client.ErrorRate,
+		FirstSeen: optionalTime(client.FirstSeen), LastSeen: optionalTime(client.LastSeen), Other: client.Other,
+	}
+}
+
+func newJSONUserAgents(agents domain.UserAgentStats) jsonUserAgents {
+	return jsonUserAgents{
+		Browsers: keyCounts(agents.Browsers), OperatingSystems: keyCounts(agents.OperatingSystems),
+		Devices: keyCounts(agents.Devices), Bots: keyCounts(agents.Bots), BotRequests: agents.BotRequests,
+		BotShare: agents.BotShare,
+	}
+}
+
+func newJSONGeoTraffic(traffic domain.GeoTraffic) jsonGeoTraffic {
+	return jsonGeoTraffic(traffic)
+}
+
+func newJSONBucket(bucket domain.TrafficBucket) jsonBucket {
+	return jsonBucket{
+		Start: bucket.Start, Requests: bucket.Requests, ClientErrors: bucket.ClientErrors,
+		ServerErrors: bucket.ServerErrors, ErrorRate: bucket.ErrorRate, Bytes: bucket.Bytes,
+		Visitors: jsonVisitorCount(bucket.Visitors),
+	}
+}
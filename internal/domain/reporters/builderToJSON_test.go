@@ -0,0 +1,208 @@
+package reporters_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/logformats"
+	"LogAnalyzer/internal/domain/reporters"
+)
+
+const schemaPath = "report.schema.json"
+
+// fullStatistic - статистика, в которой заполнены все секции отчета.
+func fullStatistic() *domain.Statistic {
+	at := time.Date(2015, time.May, 17, 8, 5, 0, 0, time.FixedZone("", 3*60*60))
+	latency := domain.LatencyStats{Count: 10, P50: 0.1, P90: 0.4, P95: 0.5, P99: 0.9, Max: 1.2}
+	top := []domain.KeyCount{{Value: "GET", Count: 7}, {Value: "other", Count: 3, Other: true}}
+
+	return &domain.Statistic{
+		Run: domain.RunInfo{
+			Version: "v1.2.3", Sources: []string{"access.log*"}, Files: []string{"access.log", "access.log.1"},
+			LogFormat: "combined", LogSyntax: "nginx", Filter: "http_code >= 500", From: at,
+		},
+		LogsMetrics: domain.Metrics{ProcessedLogs: 10, UnparsedLogs: 2, AverageAnswerSize: 512.5, TotalError: 3},
+		CommonStats: domain.CommonStats{HTTPRequest: top, Resource: top, HTTPCode: top},
+		TimeRange:   domain.TimeRange{From: at, To: at.Add(time.Hour)},
+		ResponseSize: domain.SizeStats{
+			Min: 1, Max: 1000, Mean: 512.5, StdDev: 20, Percentiles: []domain.Percentile{{Quantile: 0.5, Value: 500}},
+			Histogram: []domain.SizeBucket{{From: 0, To: 512, Count: 4}, {From: 512, To: 1024, Count: 6}},
+		},
+		ErrorRate:      30,
+		ResponseCodes:  map[string]int{domain.Success: 7, domain.ClientError: 2, domain.ServerError: 1},
+		RequestLatency: latency, UpstreamLatency: latency,
+		ResourceLatency:  []domain.EndpointLatency{{Resource: "/a", Latency: latency}},
+		SlowestEndpoints: []domain.EndpointLatency{{Resource: "/a", Latency: latency}},
+		ParseQuality: domain.ParseQuality{
+			Reasons:    []domain.KeyCount{{Value: "format_mismatch", Count: 2}},
+			Samples:    []domain.RejectedLine{{Source: "access.log", Line: 3, Reason: "format_mismatch", Text: "garbage"}},
+			Truncated:  1,
+			ReadErrors: []domain.ReadError{{Source: "access.log.1", Line: 5, Error: "unexpected EOF"}},
+		},
+		Visitors:         domain.Visitors{ByIP: 4, ByIPAgent: 5},
+		ResourceVisitors: map[string]domain.Visitors{"/a": {ByIP: 2, ByIPAgent: 2}},
+		ClientsBy:        domain.ClientsByBytes,
+		TopClients: []domain.ClientStats{
+			{Address: "10.0.0.1", Requests: 7, Bytes: 700, ErrorRate: 10, FirstSeen: at, LastSeen: at.Add(time.Minute)},
+			{Requests: 3, Bytes: 300, Other: true},
+		},
+		UserAgents: domain.UserAgentStats{Browsers: top, OperatingSystems: top, Devices: top, Bots: top, BotRequests: 1, BotShare: 10},
+		Sources:    []domain.SourceStats{{Name: "access.log", Requests: 10, Unparsed: 2, Bytes: 1000, Share: 100, ErrorRate: 30}},
+		QueryParams: []domain.QueryParamStats{
+			{Name: "page", Requests: 4, Share: 40, Cardinality: 3}, {Name: "_", Requests: 2, Share: 20, Cardinality: 2, CacheBuster: true},
+		},
+		Geo: domain.GeoStats{
+			Countries: []domain.GeoTraffic{{Value: "RU", Name: "Russia", Requests: 10, Bytes: 1000, Share: 100}},
+			ASNs:      []domain.GeoTraffic{{Value: "AS13238", Name: "YANDEX LLC", Requests: 10, Bytes: 1000, Share: 100}},
+		},
+		Timeline: domain.Timeline{
+			Bucket: time.Hour, Peak: 0,
+			Buckets: []domain.TrafficBucket{
+				{
+					Start: at, Requests: 10, ClientErrors: 2, ServerErrors: 1, ErrorRate: 30, Bytes: 1000,
+					Visitors: domain.Visitors{ByIP: 4, ByIPAgent: 5},
+				},
+			},
+		},
+	}
+}
+
+// buildJSON - строит отчет в формате JSON и возвращает его содержимое.
+func buildJSON(t *testing.T, stat *domain.Statistic) []byte {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "report")
+	require.NoError(t, (&reporters.ReportJSON{}).Build(stat, path))
+
+	data, err := os.ReadFile(path + ".json")
+	require.NoError(t, err)
+
+	return data
+}
+
+// compileSchema - компилирует схему отчета. В строгом режиме объектам схемы запрещаются поля, которых в ней нет:
+// так тест требует описать в схеме каждое новое поле отчета, а опубликованная схема остается открытой
+// для новых полей.
+func compileSchema(t *testing.T, strict bool) *jsonschema.Schema {
+	t.Helper()
+
+	data, err := os.ReadFile(schemaPath)
+	require.NoError(t, err)
+
+	var document any
+	require.NoError(t, json.Unmarshal(data, &document))
+
+	if strict {
+		closeObjects(document)
+	}
+
+	data, err = json.Marshal(document)
+	require.NoError(t, err)
+
+	compiler := jsonschema.NewCompiler()
+	compiler.AssertFormat = true
+	require.NoError(t, compiler.AddResource(schemaPath, bytes.NewReader(data)))
+
+	schema, err := compiler.Compile(schemaPath)
+	require.NoError(t, err)
+
+	return schema
+}
+
+func closeObjects(node any) {
+	switch value := node.(type) {
+	case map[string]any:
+		if _, ok := value["properties"]; ok {
+			if _, ok := value["additionalProperties"]; !ok {
+				value["additionalProperties"] = false
+			}
+		}
+
+		for _, child := range value {
+			closeObjects(child)
+		}
+	case []any:
+		for _, child := range value {
+			closeObjects(child)
+		}
+	}
+}
+
+func validate(t *testing.T, schema *jsonschema.Schema, data []byte) {
+	t.Helper()
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var report any
+	require.NoError(t, decoder.Decode(&report))
+	assert.NoError(t, schema.Validate(report))
+}
+
+func TestReportJSON_MatchesSchema(t *testing.T) {
+	schema := compileSchema(t, true)
+
+	empty := &domain.Statistic{}
+	empty.Fill(domain.NewDataHolder(logformats.NewCombined(), nil))
+
+	for _, stat := range []*domain.Statistic{fullStatistic(), empty} {
+		validate(t, schema, buildJSON(t, stat))
+	}
+}
+
+func TestReportJSON_Content(t *testing.T) {
+	var report map[string]any
+	require.NoError(t, json.Unmarshal(buildJSON(t, fullStatistic()), &report))
+
+	assert.EqualValues(t, reporters.JSONSchemaVersion, report["schema_version"])
+	assert.Equal(t, "v1.2.3", report["run"].(map[string]any)["tool_version"])
+	assert.Nil(t, report["run"].(map[string]any)["to"], "unset time bound is null")
+	assert.Equal(t, "2015-05-17T08:05:00+03:00", report["time_range"].(map[string]any)["from"])
+	assert.EqualValues(t, 3600, report["timeline"].(map[string]any)["bucket_seconds"])
+	assert.Equal(t, "1h", report["timeline"].(map[string]any)["bucket"])
+
+	var empty map[string]any
+	require.NoError(t, json.Unmarshal(buildJSON(t, &domain.Statistic{}), &empty))
+	assert.Equal(t, []any{}, empty["sources"], "empty lists are [] rather than null")
+}
+
+func TestReportJSON_SchemaVersion(t *testing.T) {
+	data, err := os.ReadFile(schemaPath)
+	require.NoError(t, err)
+
+	var schema struct {
+		ID         string `json:"$id"`
+		Properties struct {
+			SchemaVersion struct {
+				Const int `json:"const"`
+			} `json:"schema_version"`
+		} `json:"properties"`
+	}
+
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, reporters.JSONSchemaVersion, schema.Properties.SchemaVersion.Const)
+	assert.True(t, strings.HasSuffix(schema.ID, ":v"+strconv.Itoa(reporters.JSONSchemaVersion)), schema.ID)
+}
+
+// Отчеты, построенные прежними версиями программы с той же версией схемы, должны проходить проверку текущей
+// схемой: поля нельзя удалять, переименовывать и делать обязательными новые. Для несовместимого изменения
+// повышается JSONSchemaVersion и добавляется отчет новой версии в testdata.
+func TestReportJSON_BackwardCompatible(t *testing.T) {
+	schema := compileSchema(t, false)
+
+	data, err := os.ReadFile(filepath.Join("testdata", "report.v"+strconv.Itoa(reporters.JSONSchemaVersion)+".json"))
+	require.NoError(t, err)
+
+	validate(t, schema, data)
+}
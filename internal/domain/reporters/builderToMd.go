@@ -41,26 +41,27 @@ func (r *ReportMd) buildMessage(stat *domain.Statistic) string {
 	builder.WriteString(fmt.Sprintf("|  Количество запросов  |  %d  |\n", stat.LogsMetrics.ProcessedLogs))
 	builder.WriteString(fmt.Sprintf("| Средний размер ответа | %.2f |\n", stat.LogsMetrics.AverageAnswerSize))
 	builder.WriteString(fmt.Sprintf("| Нераспаршенных логов  |  %d  |\n", stat.LogsMetrics.UnparsedLogs))
-	builder.WriteString(fmt.Sprintf("|   95p размера ответа  | %.2f |\n", stat.NinetyFivePercentile))
-	builder.WriteString(fmt.Sprintf("| Медиана размера ответа | %.2f |\n", stat.Median))
 	builder.WriteString(fmt.Sprintf("|  Всего кодов ошибок   |  %d  |\n", stat.LogsMetrics.TotalError))
 	builder.WriteString(fmt.Sprintf("| Процент кодов ошибок от общего числа| %.2f |\n", stat.ErrorRate))
 
+	if hasVisitors(stat) {
+		builder.WriteString(fmt.Sprintf("| Уникальных клиентов (IP) | %d |\n", stat.Visitors.ByIP))
+		builder.WriteString(fmt.Sprintf("| Уникальных клиентов (IP + User-Agent) | %d |\n", stat.Visitors.ByIPAgent))
+	}
+
+	r.buildResponseSize(&builder, stat)
+
 	// Топ HTTP запросов
 	builder.WriteString("\n#### Топ HTTP запросов\n\n")
 	builder.WriteString("|   Запрос   | Количество |\n|:----------:|-----------:|\n")
 
 	for _, req := range stat.CommonStats.HTTPRequest {
-		builder.WriteString(fmt.Sprintf("| %-10s | %10d |\n", req.Value, req.Count))
+		builder.WriteString(fmt.Sprintf("| %-10s | %10d |\n", keyLabel(req), req.Count))
 	}
 
 	// Топ запрашиваемых ресурсов
 	builder.WriteString("\n#### Топ запрашиваемых ресурсов\n\n")
-	builder.WriteString("|   Ресурс   | Количество |\n|:----------:|-----------:|\n")
-
-	for _, res := range stat.CommonStats.Resource {
-		builder.WriteString(fmt.Sprintf("| %-10s | %10d |\n", res.Value, res.Count))
-	}
+	r.buildResources(&builder, stat)
 
 	// Коды ответа
 	builder.WriteString("\n#### Коды ответа\n\n")
@@ -76,8 +77,258 @@ func (r *ReportMd) buildMessage(stat *domain.Statistic) string {
 	builder.WriteString("| Код ответа | Количество |\n|:----------:|-----------:|\n")
 
 	for _, code := range stat.CommonStats.HTTPCode {
-		builder.WriteString(fmt.Sprintf("| %-10s | %10d |\n", code.Value, code.Count))
+		builder.WriteString(fmt.Sprintf("| %-10s | %10d |\n", keyLabel(code), code.Count))
 	}
 
+	r.buildSources(&builder, stat)
+	r.buildQueryParams(&builder, stat)
+	r.buildTopClients(&builder, stat)
+	r.buildUserAgents(&builder, stat)
+	r.buildGeo(&builder, stat)
+	r.buildLatency(&builder, stat)
+	r.buildTimeline(&builder, stat)
+	r.buildParseQuality(&builder, stat)
+
 	return builder.String()
 }
+
+// buildParseQuality - разбивка нераспаршенных строк по причинам с примерами, обрезанные строки и ошибки чтения.
+func (r *ReportMd) buildParseQuality(builder *strings.Builder, stat *domain.Statistic) {
+	quality := stat.ParseQuality
+	if stat.LogsMetrics.UnparsedLogs == 0 && quality.Truncated == 0 && len(quality.ReadErrors) == 0 {
+		return
+	}
+
+	builder.WriteString("\n#### Качество парсинга\n\n")
+
+	if quality.Truncated > 0 {
+		builder.WriteString(fmt.Sprintf("Обрезано длинных строк: %d\n\n", quality.Truncated))
+	}
+
+	if len(quality.Reasons) > 0 {
+		builder.WriteString("|      Причина      | Количество |\n|:-----------------:|-----------:|\n")
+
+		for _, reason := range quality.Reasons {
+			builder.WriteString(fmt.Sprintf("| %-17s | %10d |\n", reason.Value, reason.Count))
+		}
+
+		builder.WriteString("\n| Источник | Строка | Причина | Пример |\n|:--------:|-------:|:-------:|:-------|\n")
+
+		for _, sample := range quality.Samples {
+			builder.WriteString(fmt.Sprintf("| %s | %d | %s | `%s` |\n", escapeCell(sample.Source), sample.Line, sample.Reason,
+				escapeCell(strings.ReplaceAll(sample.Text, "`", "'"))))
+		}
+	}
+
+	if len(quality.ReadErrors) > 0 {
+		builder.WriteString("\n##### Ошибки чтения\n\n| Источник | Строка | Ошибка |\n|:--------:|-------:|:-------|\n")
+
+		for _, readErr := range quality.ReadErrors {
+			builder.WriteString(fmt.Sprintf("| %s | %d | %s |\n", escapeCell(readErr.Source), readErr.Line, escapeCell(readErr.Error)))
+		}
+	}
+}
+
+// buildResponseSize - распределение размеров ответа, перцентили приблизительные с относительной ошибкой до 1%.
+func (r *ReportMd) buildResponseSize(builder *strings.Builder, stat *domain.Statistic) {
+	builder.WriteString("\n#### Размер ответа\n\n")
+	builder.WriteString("|        Метрика         | Значение, байт |\n|:----------------------:|---------------:|\n")
+	builder.WriteString(fmt.Sprintf("|        Минимум         | %14.0f |\n", stat.ResponseSize.Min))
+
+	for _, percentile := range stat.ResponseSize.Percentiles {
+		builder.WriteString(fmt.Sprintf("| %-22s | %14.2f |\n", percentile.Label(), percentile.Value))
+	}
+
+	builder.WriteString(fmt.Sprintf("|        Максимум        | %14.0f |\n", stat.ResponseSize.Max))
+	builder.WriteString(fmt.Sprintf("|        Среднее         | %14.2f |\n", stat.ResponseSize.Mean))
+	builder.WriteString(fmt.Sprintf("| Стандартное отклонение | %14.2f |\n", stat.ResponseSize.StdDev))
+}
+
+// buildResources - топ ресурсов, если известны клиенты - с оценкой уникальных клиентов для каждого ресурса.
+func (r *ReportMd) buildResources(builder *strings.Builder, stat *domain.Statistic) {
+	if !hasVisitors(stat) {
+		builder.WriteString("|   Ресурс   | Количество |\n|:----------:|-----------:|\n")
+
+		for _, res := range stat.CommonStats.Resource {
+			builder.WriteString(fmt.Sprintf("| %-10s | %10d |\n", keyLabel(res), res.Count))
+		}
+
+		return
+	}
+
+	builder.WriteString("|   Ресурс   | Количество | Клиентов (IP) | Клиентов (IP + UA) |\n")
+	builder.WriteString("|:----------:|-----------:|--------------:|-------------------:|\n")
+
+	for _, res := range stat.CommonStats.Resource {
+		byIP, byIPAgent := resourceVisitors(stat, res)
+		builder.WriteString(fmt.Sprintf("| %-10s | %10d | %13s | %18s |\n", keyLabel(res), res.Count, byIP, byIPAgent))
+	}
+}
+
+// buildSources - разбивка по источникам, если она включена.
+func (r *ReportMd) buildSources(builder *strings.Builder, stat *domain.Statistic) {
+	if len(stat.Sources) == 0 {
+		return
+	}
+
+	builder.WriteString("\n#### Источники\n\n")
+	builder.WriteString("|      Источник      | Запросов | Доля, % | Нераспаршенных | Ошибок, % |    Байт    |\n")
+	builder.WriteString("|:------------------:|---------:|--------:|---------------:|----------:|-----------:|\n")
+
+	for _, source := range stat.Sources {
+		builder.WriteString(fmt.Sprintf("| %-18s | %8d | %7.2f | %14d | %9.2f | %10d |\n", escapeCell(source.Name), source.Requests,
+			source.Share, source.Unparsed, source.ErrorRate, source.Bytes))
+	}
+}
+
+// buildQueryParams - самые частые параметры строки запроса, параметры для сброса кеша отмечаются.
+func (r *ReportMd) buildQueryParams(builder *strings.Builder, stat *domain.Statistic) {
+	if len(stat.QueryParams) == 0 {
+		return
+	}
+
+	builder.WriteString("\n#### Параметры запроса\n\n")
+	builder.WriteString("|  Параметр  | Запросов | Доля, % | Уникальных значений | Сброс кеша |\n")
+	builder.WriteString("|:----------:|---------:|--------:|--------------------:|:----------:|\n")
+
+	for _, param := range stat.QueryParams {
+		share, cardinality := paramStats(param)
+		builder.WriteString(fmt.Sprintf("| %-10s | %8d | %7s | %19s | %-10s |\n", paramLabel(param), param.Requests, share,
+			cardinality, cacheBuster(param)))
+	}
+}
+
+// buildTopClients - топ клиентов с объемом ответов, долей ошибок и временем первого и последнего запроса.
+func (r *ReportMd) buildTopClients(builder *strings.Builder, stat *domain.Statistic) {
+	if len(stat.TopClients) == 0 {
+		return
+	}
+
+	builder.WriteString("\n#### " + clientsTitle(stat) + "\n\n")
+	builder.WriteString("|     Клиент      | Запросов |    Байт    | Ошибок, % |   Первый запрос    |  Последний запрос  |\n")
+	builder.WriteString("|:---------------:|---------:|-----------:|----------:|:------------------:|:------------------:|\n")
+
+	for _, client := range stat.TopClients {
+		builder.WriteString(fmt.Sprintf("| %-15s | %8d | %10d | %9.2f | %s | %s |\n", clientLabel(client), client.Requests,
+			client.Bytes, client.ErrorRate, seen(client.FirstSeen), seen(client.LastSeen)))
+	}
+}
+
+// buildUserAgents - распределение по браузерам, ОС и устройствам и доля ботов, если включен классификатор User-Agent.
+func (r *ReportMd) buildUserAgents(builder *strings.Builder, stat *domain.Statistic) {
+	userAgents := stat.UserAgents
+	if len(userAgents.Devices) == 0 {
+		return
+	}
+
+	r.buildKeyCounts(builder, "Браузеры", "Браузер", userAgents.Browsers)
+	r.buildKeyCounts(builder, "Операционные системы", "ОС", userAgents.OperatingSystems)
+	r.buildKeyCounts(builder, "Типы устройств", "Устройство", userAgents.Devices)
+
+	builder.WriteString(fmt.Sprintf("\nЗапросов от ботов: %d (%.2f%%)\n", userAgents.BotRequests, userAgents.BotShare))
+	r.buildKeyCounts(builder, "Топ ботов", "Бот", userAgents.Bots)
+}
+
+// buildGeo - трафик по странам и ASN, если заданы базы MaxMind.
+func (r *ReportMd) buildGeo(builder *strings.Builder, stat *domain.Statistic) {
+	if len(stat.Geo.Countries) == 0 {
+		return
+	}
+
+	builder.WriteString("\n#### Трафик по странам\n\n")
+	builder.WriteString("| Страна  | Запросов | Доля, % |    Байт    |\n|:-------:|---------:|--------:|-----------:|\n")
+
+	for _, country := range stat.Geo.Countries {
+		builder.WriteString(fmt.Sprintf("| %-7s | %8d | %7.2f | %10d |\n", geoLabel(country), country.Requests, country.Share, country.Bytes))
+	}
+
+	builder.WriteString("\n#### Трафик по ASN\n\n")
+	builder.WriteString("|    ASN    |     Организация      | Запросов | Доля, % |    Байт    |\n")
+	builder.WriteString("|:---------:|:--------------------:|---------:|--------:|-----------:|\n")
+
+	for _, asn := range stat.Geo.ASNs {
+		builder.WriteString(fmt.Sprintf("| %-9s | %-20s | %8d | %7.2f | %10d |\n", geoLabel(asn), escapeCell(asn.Name),
+			asn.Requests, asn.Share, asn.Bytes))
+	}
+}
+
+// buildKeyCounts - секция с рейтингом значений.
+func (r *ReportMd) buildKeyCounts(builder *strings.Builder, title, column string, items []domain.KeyCount) {
+	if len(items) == 0 {
+		return
+	}
+
+	builder.WriteString(fmt.Sprintf("\n#### %s\n\n", title))
+	builder.WriteString(fmt.Sprintf("| %-18s | Количество |\n|:------------------:|-----------:|\n", column))
+
+	for _, item := range items {
+		builder.WriteString(fmt.Sprintf("| %-18s | %10d |\n", keyLabel(item), item.Count))
+	}
+}
+
+// buildTimeline - трафик по интервалам, интервал с наибольшим числом запросов отмечается как пик.
+func (r *ReportMd) buildTimeline(builder *strings.Builder, stat *domain.Statistic) {
+	if len(stat.Timeline.Buckets) == 0 {
+		return
+	}
+
+	builder.WriteString(fmt.Sprintf("\n#### Трафик по интервалам (%s)\n\n", stat.Timeline.BucketLabel()))
+	header := "| Начало интервала | Запросов | 4xx | 5xx | Ошибок, % | Байт |"
+	separator := "|:----------------:|---------:|----:|----:|----------:|-----:|"
+
+	if hasTimelineVisitors(stat) {
+		header += " Клиентов (IP) | Клиентов (IP + UA) |"
+		separator += "--------------:|-------------------:|"
+	}
+
+	builder.WriteString(header + "\n" + separator + "\n")
+
+	for i, bucket := range stat.Timeline.Buckets {
+		start := bucket.Start.Format(timelineLayout)
+		if i == stat.Timeline.Peak {
+			start += " **пик**"
+		}
+
+		builder.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %.2f | %d |", start, bucket.Requests,
+			bucket.ClientErrors, bucket.ServerErrors, bucket.ErrorRate, bucket.Bytes))
+
+		if hasTimelineVisitors(stat) {
+			builder.WriteString(fmt.Sprintf(" %d | %d |", bucket.Visitors.ByIP, bucket.Visitors.ByIPAgent))
+		}
+
+		builder.WriteString("\n")
+	}
+}
+
+// buildLatency - секции времени обработки запросов, выводятся только если формат логов содержит время обработки.
+func (r *ReportMd) buildLatency(builder *strings.Builder, stat *domain.Statistic) {
+	if stat.RequestLatency.Count == 0 && stat.UpstreamLatency.Count == 0 {
+		return
+	}
+
+	builder.WriteString("\n#### Время обработки запросов\n\n")
+	builder.WriteString("| Перцентиль | request_time, с | upstream_response_time, с |\n")
+	builder.WriteString("|:----------:|----------------:|--------------------------:|\n")
+	builder.WriteString(fmt.Sprintf("|    p50     | %15.3f | %25.3f |\n", stat.RequestLatency.P50, stat.UpstreamLatency.P50))
+	builder.WriteString(fmt.Sprintf("|    p90     | %15.3f | %25.3f |\n", stat.RequestLatency.P90, stat.UpstreamLatency.P90))
+	builder.WriteString(fmt.Sprintf("|    p95     | %15.3f | %25.3f |\n", stat.RequestLatency.P95, stat.UpstreamLatency.P95))
+	builder.WriteString(fmt.Sprintf("|    p99     | %15.3f | %25.3f |\n", stat.RequestLatency.P99, stat.UpstreamLatency.P99))
+	builder.WriteString(fmt.Sprintf("|    max     | %15.3f | %25.3f |\n", stat.RequestLatency.Max, stat.UpstreamLatency.Max))
+
+	builder.WriteString("\n#### Время обработки топ ресурсов\n\n")
+	r.buildEndpointLatency(builder, stat.ResourceLatency)
+
+	builder.WriteString("\n#### Самые медленные ресурсы\n\n")
+	builder.WriteString(fmt.Sprintf("Учитываются ресурсы не меньше чем с %d запросами.\n\n", domain.MinSlowestRequests))
+	r.buildEndpointLatency(builder, stat.SlowestEndpoints)
+}
+
+func (r *ReportMd) buildEndpointLatency(builder *strings.Builder, endpoints []domain.EndpointLatency) {
+	builder.WriteString("|   Ресурс   | Запросов | p50, с | p95, с | p99, с | max, с |\n")
+	builder.WriteString("|:----------:|---------:|-------:|-------:|-------:|-------:|\n")
+
+	for _, endpoint := range endpoints {
+		builder.WriteString(fmt.Sprintf("| %-10s | %8d | %6.3f | %6.3f | %6.3f | %6.3f |\n", escapeCell(endpoint.Resource),
+			endpoint.Latency.Count, endpoint.Latency.P50, endpoint.Latency.P95, endpoint.Latency.P99, endpoint.Latency.Max))
+	}
+}
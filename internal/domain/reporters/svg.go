@@ -0,0 +1,215 @@
+package reporters
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	chartWidth  = 720
+	chartHeight = 260
+	// chartLeft, chartRight, chartTop и chartBottom - поля вокруг области построения под подписи осей и легенду.
+	chartLeft   = 64
+	chartRight  = 16
+	chartTop    = 32
+	chartBottom = 32
+	plotWidth   = chartWidth - chartLeft - chartRight
+	plotHeight  = chartHeight - chartTop - chartBottom
+	// maxAxisLabels - сколько подписей интервалов не больше выводится под осью линейного графика.
+	maxAxisLabels = 6
+	// maxPointMarkers - до скольких точек на линии у каждой точки есть маркер с подсказкой.
+	maxPointMarkers = 120
+)
+
+// chartBar - столбец диаграммы, Class - класс CSS, задающий цвет.
+type chartBar struct {
+	Label string
+	Value float64
+	Class string
+}
+
+// chartSeries - линия графика, значения идут по тем же интервалам, что и подписи оси.
+type chartSeries struct {
+	Name   string
+	Class  string
+	Values []float64
+}
+
+// barChart - столбчатая диаграмма SVG: над столбцом - значение, под ним - подпись, точное значение
+// показывается в подсказке.
+func barChart(title string, bars []chartBar) template.HTML {
+	var (
+		builder strings.Builder
+		highest float64
+	)
+
+	for _, bar := range bars {
+		highest = math.Max(highest, bar.Value)
+	}
+
+	top := niceCeil(highest)
+
+	openChart(&builder, title)
+	writeAxis(&builder, top)
+
+	slot := float64(plotWidth) / float64(max(len(bars), 1))
+
+	for i, bar := range bars {
+		x := chartLeft + slot*float64(i)
+		y := scaleY(bar.Value, top)
+		label, value := html.EscapeString(bar.Label), formatValue(bar.Value)
+
+		fmt.Fprintf(&builder, `<rect class="bar %s" x="%.1f" y="%.1f" width="%.1f" height="%.1f"><title>%s: %s</title></rect>`,
+			bar.Class, x+slot*0.15, y, slot*0.7, chartTop+plotHeight-y, label, value)
+		fmt.Fprintf(&builder, `<text class="value" x="%.1f" y="%.1f" text-anchor="middle">%s</text>`, x+slot/2, y-4, value)
+		fmt.Fprintf(&builder, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`, x+slot/2, chartHeight-chartBottom+18, label)
+	}
+
+	return closeChart(&builder)
+}
+
+// lineChart - линейный график SVG по интервалам с легендой, peak - индекс интервала, отмеченного как пик,
+// -1 - без отметки.
+func lineChart(title string, labels []string, series []chartSeries, peak int) template.HTML {
+	var (
+		builder strings.Builder
+		highest float64
+	)
+
+	for _, line := range series {
+		for _, value := range line.Values {
+			highest = math.Max(highest, value)
+		}
+	}
+
+	top := niceCeil(highest)
+
+	openChart(&builder, title)
+	writeAxis(&builder, top)
+
+	step := max(1, (len(labels)+maxAxisLabels-1)/maxAxisLabels)
+
+	for i := 0; i < len(labels); i += step {
+		anchor := "middle"
+
+		switch {
+		case i == 0 && len(labels) > 1:
+			anchor = "start"
+		case i == len(labels)-1 && i > 0:
+			anchor = "end"
+		}
+
+		fmt.Fprintf(&builder, `<text x="%.1f" y="%d" text-anchor="%s">%s</text>`, scaleX(i, len(labels)), chartHeight-chartBottom+18,
+			anchor, html.EscapeString(labels[i]))
+	}
+
+	for k, line := range series {
+		writeLine(&builder, line, labels, top)
+
+		fmt.Fprintf(&builder, `<rect class="%s" x="%d" y="10" width="12" height="12"/><text x="%d" y="20">%s</text>`,
+			line.Class, chartLeft+k*160, chartLeft+k*160+18, html.EscapeString(line.Name))
+	}
+
+	if len(series) > 0 && peak >= 0 && peak < len(series[0].Values) {
+		value := series[0].Values[peak]
+		fmt.Fprintf(&builder, `<circle class="peak" cx="%.1f" cy="%.1f" r="5"><title>Пик, %s: %s</title></circle>`,
+			scaleX(peak, len(labels)), scaleY(value, top), html.EscapeString(labels[peak]), formatValue(value))
+	}
+
+	return closeChart(&builder)
+}
+
+// writeLine - линия графика, при небольшом числе точек - с маркерами и подсказками у каждой точки.
+func writeLine(builder *strings.Builder, line chartSeries, labels []string, top float64) {
+	points := make([]string, 0, len(line.Values))
+
+	for i, value := range line.Values {
+		points = append(points, fmt.Sprintf("%.1f,%.1f", scaleX(i, len(labels)), scaleY(value, top)))
+	}
+
+	fmt.Fprintf(builder, `<polyline class="line %s" points="%s"/>`, line.Class, strings.Join(points, " "))
+
+	if len(line.Values) > maxPointMarkers {
+		return
+	}
+
+	for i, value := range line.Values {
+		fmt.Fprintf(builder, `<circle class="point %s" cx="%.1f" cy="%.1f" r="3"><title>%s, %s: %s</title></circle>`,
+			line.Class, scaleX(i, len(labels)), scaleY(value, top), html.EscapeString(labels[i]),
+			html.EscapeString(line.Name), formatValue(value))
+	}
+}
+
+// openChart - начало SVG с заголовком для экранных дикторов.
+func openChart(builder *strings.Builder, title string) {
+	fmt.Fprintf(builder, `<svg class="chart" viewBox="0 0 %d %d" role="img" aria-label="%s"><title>%s</title>`,
+		chartWidth, chartHeight, html.EscapeString(title), html.EscapeString(title))
+}
+
+// closeChart - конец SVG. Все подписи в диаграммах экранируются при записи, поэтому разметку можно вставлять
+// в шаблон как есть.
+func closeChart(builder *strings.Builder) template.HTML {
+	builder.WriteString("</svg>")
+
+	return template.HTML(builder.String()) //nolint:gosec // подписи экранированы при записи
+}
+
+// writeAxis - линии сетки с подписями значений на нуле, середине и верхней границе шкалы.
+func writeAxis(builder *strings.Builder, top float64) {
+	for _, value := range []float64{0, top / 2, top} {
+		y := scaleY(value, top)
+
+		fmt.Fprintf(builder, `<line class="grid" x1="%d" y1="%.1f" x2="%d" y2="%.1f"/>`, chartLeft, y, chartWidth-chartRight, y)
+		fmt.Fprintf(builder, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`, chartLeft-8, y+4, formatValue(value))
+	}
+}
+
+func scaleX(i, count int) float64 {
+	if count <= 1 {
+		return chartLeft + plotWidth/2
+	}
+
+	return chartLeft + float64(plotWidth)*float64(i)/float64(count-1)
+}
+
+func scaleY(value, top float64) float64 {
+	return chartTop + float64(plotHeight)*(1-value/top)
+}
+
+// niceCeil - верхняя граница шкалы: ближайшее сверху число вида 1, 2 или 5, умноженное на степень десяти.
+func niceCeil(value float64) float64 {
+	if value <= 0 {
+		return 1
+	}
+
+	magnitude := math.Pow(10, math.Floor(math.Log10(value)))
+
+	for _, multiplier := range []float64{1, 2, 5} {
+		if multiplier*magnitude >= value {
+			return multiplier * magnitude
+		}
+	}
+
+	return 10 * magnitude
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// byteLabel - размер в байтах с двоичной приставкой: 512 Б, 4 КБ, 1 МБ.
+func byteLabel(size float64) string {
+	units := []string{"Б", "КБ", "МБ", "ГБ", "ТБ"}
+	unit := 0
+
+	for size >= 1024 && unit < len(units)-1 {
+		size /= 1024
+		unit++
+	}
+
+	return strconv.FormatFloat(size, 'f', -1, 64) + " " + units[unit]
+}
@@ -0,0 +1,117 @@
+package reporters
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"LogAnalyzer/internal/domain"
+)
+
+const (
+	// otherLabel - подпись строки рейтинга, в которую просуммировано все, что не вошло в рейтинг.
+	otherLabel = "Остальные"
+	// timelineLayout - формат начала интервала временного ряда.
+	timelineLayout = "02.01.2006 15:04"
+	// seenLayout - формат времени первого и последнего запроса клиента.
+	seenLayout = "02.01.2006 15:04:05"
+)
+
+// escapeCell - экранирует разделитель столбцов, чтобы строки логов в примерах не ломали таблицы
+// Markdown и AsciiDoc.
+func escapeCell(value string) string {
+	return strings.ReplaceAll(value, "|", "\\|")
+}
+
+// keyLabel - подпись строки рейтинга.
+func keyLabel(item domain.KeyCount) string {
+	if item.Other {
+		return otherLabel
+	}
+
+	return escapeCell(item.Value)
+}
+
+// hasVisitors - есть ли в отчете оценка уникальных клиентов, ее нет если формат логов не содержит $remote_addr.
+func hasVisitors(stat *domain.Statistic) bool {
+	return stat.Visitors.ByIP > 0
+}
+
+// hasTimelineVisitors - есть ли оценка уникальных клиентов у интервалов временного ряда.
+func hasTimelineVisitors(stat *domain.Statistic) bool {
+	return hasVisitors(stat) && stat.Timeline.HasVisitors
+}
+
+// resourceVisitors - оценка уникальных клиентов ресурса для таблицы, для строки "Остальные" - прочерк.
+func resourceVisitors(stat *domain.Statistic, item domain.KeyCount) (byIP, byIPAgent string) {
+	visitors, ok := stat.ResourceVisitors[item.Value]
+	if item.Other || !ok {
+		return "-", "-"
+	}
+
+	return strconv.FormatUint(visitors.ByIP, 10), strconv.FormatUint(visitors.ByIPAgent, 10)
+}
+
+// clientsTitle - заголовок топа клиентов с указанием, по какому показателю он построен.
+func clientsTitle(stat *domain.Statistic) string {
+	if stat.ClientsBy == domain.ClientsByBytes {
+		return "Топ клиентов по объему ответов"
+	}
+
+	return "Топ клиентов по числу запросов"
+}
+
+// clientLabel - подпись строки топа клиентов.
+func clientLabel(client domain.ClientStats) string {
+	if client.Other {
+		return otherLabel
+	}
+
+	return escapeCell(client.Address)
+}
+
+// seen - время запроса клиента, прочерк если в формате логов нет времени.
+func seen(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	return t.Format(seenLayout)
+}
+
+// geoLabel - подпись строки рейтинга стран или ASN.
+func geoLabel(item domain.GeoTraffic) string {
+	if item.Other {
+		return otherLabel
+	}
+
+	return escapeCell(item.Value)
+}
+
+// paramLabel - подпись строки рейтинга параметров запроса.
+func paramLabel(param domain.QueryParamStats) string {
+	if param.Other {
+		return otherLabel
+	}
+
+	return escapeCell(param.Name)
+}
+
+// paramStats - доля запросов и число различных значений параметра, для строки "Остальные" - прочерки: параметры
+// встречаются в одних и тех же запросах, поэтому их доли и значения не суммируются.
+func paramStats(param domain.QueryParamStats) (share, cardinality string) {
+	if param.Other {
+		return "-", "-"
+	}
+
+	return strconv.FormatFloat(float64(param.Share), 'f', 2, 32), strconv.FormatUint(param.Cardinality, 10)
+}
+
+// cacheBuster - отметка параметра, который похож на сброс кеша.
+func cacheBuster(param domain.QueryParamStats) string {
+	if param.CacheBuster {
+		return "да"
+	}
+
+	return ""
+}
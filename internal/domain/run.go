@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// RunInfo - параметры запуска, с которыми построен отчет: версия программы, источники и условия отбора.
+// Заполняется приложением, Fill его не меняет.
+type RunInfo struct {
+	Version string
+	// Sources - источники так, как они заданы при запуске, Files - имена разобранных файлов в отчете.
+	Sources []string
+	Files   []string
+	// LogFormat и LogSyntax - формат строк лога, Filter - выражение фильтра, пустая строка - без фильтра.
+	LogFormat string
+	LogSyntax string
+	Filter    string
+	// From и To - заданные временные границы, нулевое время - граница не задана.
+	From time.Time
+	To   time.Time
+}
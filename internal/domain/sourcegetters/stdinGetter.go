@@ -0,0 +1,19 @@
+package sourcegetters
+
+// Stdin - имя источника, которое означает стандартный ввод, например `zcat access.log.*.gz | LogAnalyzer -sourcegetters=-`.
+const Stdin = "-"
+
+// StdinLabel - как стандартный ввод называется в отчете.
+const StdinLabel = "stdin"
+
+type GetStdin struct{}
+
+// FilePaths метод возвращает единственный источник - стандартный ввод, его читает приложение.
+func (c *GetStdin) FilePaths() ([]string, error) {
+	return []string{Stdin}, nil
+}
+
+// Label - имя стандартного ввода в отчете.
+func (c *GetStdin) Label(string) string {
+	return StdinLabel
+}
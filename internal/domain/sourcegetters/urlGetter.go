@@ -11,6 +11,8 @@ import (
 
 type GetURL struct {
 	URL string
+	// temp - временный файл с телом ответа, удаляется в Close.
+	temp string
 }
 
 // FilePaths метод котоый позволяет обработать и вернуть слайс с именами ресурсов, работает с провалидированной ссылкой,
@@ -34,12 +36,33 @@ func (c *GetURL) FilePaths() ([]string, error) {
 		return nil, errors.ErrFileCreation{}
 	}
 
-	defer file.Close()
+	c.temp = file.Name()
 
 	_, err = io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = errors.ErrCloseFile{}
+	}
+
 	if err != nil {
 		return nil, err
 	}
 
-	return []string{file.Name()}, nil
+	return []string{c.temp}, nil
+}
+
+// Close - удаляет временный файл с телом ответа.
+func (c *GetURL) Close() error {
+	if c.temp == "" {
+		return nil
+	}
+
+	err := os.Remove(c.temp)
+	c.temp = ""
+
+	return err
+}
+
+// Label - имя источника в отчете: ссылка, а не временный файл, в который сохранен ответ.
+func (c *GetURL) Label(string) string {
+	return c.URL
 }
@@ -0,0 +1,70 @@
+package domain
+
+// SourceCounters - счетчики одного источника логов.
+type SourceCounters struct {
+	Name     string
+	Requests int
+	Unparsed int
+	Errors   int
+	Bytes    uint64
+	// lines - сколько строк источника уже прочитано, нужно чтобы продолжить нумерацию при возврате к источнику.
+	lines int
+}
+
+// SourceStats - строка разбивки по источникам. Share - процент запросов источника от общего числа,
+// ErrorRate - процент ответов 4xx и 5xx среди запросов источника.
+type SourceStats struct {
+	Name      string
+	Requests  int
+	Unparsed  int
+	Bytes     uint64
+	Share     float32
+	ErrorRate float32
+}
+
+// currentSource - счетчики источника, который сейчас разбирается, nil если StartSource не вызывался.
+func (s *DataHolder) currentSource() *SourceCounters {
+	if len(s.Sources) == 0 {
+		return nil
+	}
+
+	return &s.Sources[s.sourceIndex]
+}
+
+// collectSource - учитывает запрос в счетчиках текущего источника.
+func (s *DataHolder) collectSource(code, bytes int) {
+	source := s.currentSource()
+	if source == nil {
+		return
+	}
+
+	source.Requests++
+	source.Bytes += uint64(max(bytes, 0))
+
+	if code >= 400 {
+		source.Errors++
+	}
+}
+
+// fillSources - разбивка по источникам в порядке разбора, заполняется если она включена.
+func (s *Statistic) fillSources(data *DataHolder) {
+	if !s.BySource {
+		return
+	}
+
+	s.Sources = make([]SourceStats, 0, len(data.Sources))
+
+	for _, source := range data.Sources {
+		stats := SourceStats{Name: source.Name, Requests: source.Requests, Unparsed: source.Unparsed, Bytes: source.Bytes}
+
+		if data.TotalCounter > 0 {
+			stats.Share = float32(source.Requests) / float32(data.TotalCounter) * 100
+		}
+
+		if source.Requests > 0 {
+			stats.ErrorRate = float32(source.Errors) / float32(source.Requests) * 100
+		}
+
+		s.Sources = append(s.Sources, stats)
+	}
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// savedDataHolder - DataHolder без методов, чтобы gob кодировал его поля, а не вызывал MarshalBinary рекурсивно.
+// Парсер, фильтр, обогащения и карантин - настройки запуска, они не сохраняются.
+type savedDataHolder DataHolder
+
+// MarshalBinary - сохраняет накопленные счетчики и скетчи, чтобы следующий запуск продолжил с того же места.
+func (s *DataHolder) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode((*savedDataHolder)(s)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary - восстанавливает счетчики из MarshalBinary в DataHolder, созданный NewDataHolder,
+// настройки разбора остаются текущими.
+func (s *DataHolder) UnmarshalBinary(data []byte) error {
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode((*savedDataHolder)(s)); err != nil {
+		return err
+	}
+
+	// Интервалы временного ряда выравниваются по часовому поясу записей, он совпадает с поясом первой из них
+	if s.location == nil && !s.From.IsZero() {
+		s.location = s.From.Location()
+	}
+
+	return nil
+}
+
+// SkipLines - сообщает, что первые n строк текущего источника разобраны в прошлый запуск, чтобы номера строк
+// в карантине совпадали с номерами строк в файле.
+func (s *DataHolder) SkipLines(n int) {
+	s.lineNumber = n
+}
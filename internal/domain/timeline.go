@@ -0,0 +1,237 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+const (
+	// minuteSeconds - шаг, с которым копятся счетчики трафика, интервалы отчета собираются из минут.
+	minuteSeconds = int64(time.Minute / time.Second)
+	// maxAutoBuckets - сколько интервалов не больше должно получиться при автоматическом выборе размера.
+	maxAutoBuckets = 60
+	// MaxTimelineBuckets - сколько интервалов не больше может быть во временном ряду. Если заданный размер дает
+	// больше, например -bucket 1m за несколько лет или одна запись с неверными часами, интервал увеличивается.
+	MaxTimelineBuckets = 10000
+)
+
+// AutoBucketSizes - размеры интервалов, из которых выбирается наименьший, дающий не больше maxAutoBuckets интервалов.
+var AutoBucketSizes = []time.Duration{
+	time.Minute, 5 * time.Minute, 15 * time.Minute, time.Hour, 6 * time.Hour, 24 * time.Hour,
+}
+
+// TrafficCounters - счетчики трафика за минуту. Уникальные клиенты считаются отдельно и крупнее,
+// см. DataHolder.TrafficVisitors.
+type TrafficCounters struct {
+	Requests     int
+	ClientErrors int
+	ServerErrors int
+	Bytes        uint64
+}
+
+// TrafficBucket - строка временного ряда, ErrorRate - процент ответов 4xx и 5xx.
+type TrafficBucket struct {
+	Start        time.Time
+	Requests     int
+	ClientErrors int
+	ServerErrors int
+	ErrorRate    float32
+	Bytes        uint64
+	Visitors     Visitors
+}
+
+// Timeline - трафик по интервалам одинакового размера, пустые интервалы внутри диапазона тоже выводятся,
+// чтобы были видны провалы. Peak - индекс интервала с наибольшим числом запросов, -1 если интервалов нет.
+// Requested - размер интервала до увеличения из-за MaxTimelineBuckets, 0 если размер не менялся. HasVisitors -
+// у интервалов есть оценка уникальных клиентов: ее нет, если в формате нет $remote_addr или интервал не кратен
+// шагу скетчей клиентов (см. DataHolder.VisitorStep).
+type Timeline struct {
+	Bucket      time.Duration
+	Buckets     []TrafficBucket
+	Peak        int
+	Requested   time.Duration
+	HasVisitors bool
+}
+
+// BucketLabel - размер интервала для отчета: 1m, 5m, 1h, 1d.
+func (t Timeline) BucketLabel() string {
+	switch {
+	case t.Bucket%(24*time.Hour) == 0:
+		return strconv.FormatInt(int64(t.Bucket/(24*time.Hour)), 10) + "d"
+	case t.Bucket%time.Hour == 0:
+		return strconv.FormatInt(int64(t.Bucket/time.Hour), 10) + "h"
+	default:
+		return strconv.FormatInt(int64(t.Bucket/time.Minute), 10) + "m"
+	}
+}
+
+// collectTraffic - учитывает запрос в счетчиках его минуты, client - nil если клиент неизвестен.
+// Записи без времени во временной ряд не попадают.
+func (s *DataHolder) collectTraffic(logTime time.Time, code int, bytes int, client *clientHashes) {
+	if logTime.IsZero() {
+		return
+	}
+
+	if s.location == nil {
+		s.location = logTime.Location()
+	}
+
+	minute := floorDiv(logTime.Unix(), minuteSeconds)
+	counters := s.Traffic[minute]
+
+	counters.Requests++
+	counters.Bytes += uint64(max(bytes, 0))
+
+	switch {
+	case code >= 500:
+		counters.ServerErrors++
+	case code >= 400:
+		counters.ClientErrors++
+	}
+
+	s.Traffic[minute] = counters
+
+	if client != nil {
+		s.collectIntervalVisitors(minute, *client)
+	}
+}
+
+// fillTimeline - собирает минутные счетчики в интервалы размера s.Bucket, либо подобранного автоматически.
+// Интервалы выравниваются по часовому поясу первой записи, чтобы сутки начинались в полночь по времени логов.
+// Если интервалов получается больше MaxTimelineBuckets, размер увеличивается.
+func (s *Statistic) fillTimeline(data *DataHolder) {
+	s.Timeline = Timeline{Bucket: s.Bucket, Peak: -1}
+
+	if len(data.Traffic) == 0 {
+		return
+	}
+
+	first, last := int64(0), int64(0)
+	started := false
+
+	for minute := range data.Traffic {
+		if !started || minute < first {
+			first = minute
+		}
+
+		if !started || minute > last {
+			last = minute
+		}
+
+		started = true
+	}
+
+	_, zoneOffset := time.Unix(first*minuteSeconds, 0).In(data.location).Zone()
+	offset := int64(zoneOffset) / minuteSeconds
+
+	if s.Timeline.Bucket <= 0 {
+		s.Timeline.Bucket = autoBucket(time.Duration(last-first) * time.Minute)
+	}
+
+	size := int64(s.Timeline.Bucket / time.Minute)
+	count := func() int64 { return floorDiv(last+offset, size) - floorDiv(first+offset, size) + 1 }
+
+	for count() > MaxTimelineBuckets {
+		if s.Timeline.Requested == 0 {
+			s.Timeline.Requested = s.Timeline.Bucket
+		}
+
+		size = roundBucket(size * ((count() + MaxTimelineBuckets - 1) / MaxTimelineBuckets))
+		s.Timeline.Bucket = time.Duration(size) * time.Minute
+	}
+
+	firstBucket := floorDiv(first+offset, size)
+	buckets := make([]TrafficBucket, count())
+
+	for i := range buckets {
+		start := ((firstBucket+int64(i))*size - offset) * minuteSeconds
+		buckets[i].Start = time.Unix(start, 0).In(data.location)
+	}
+
+	index := func(minute int64) int64 { return floorDiv(minute+offset, size) - firstBucket }
+	addTraffic(buckets, data.Traffic, index)
+
+	// Скетч клиентов целиком попадает в один интервал, только если его шаг делит размер интервала и смещение пояса
+	step := data.visitorStep()
+	if len(data.TrafficVisitors) > 0 && size%step == 0 && offset%step == 0 {
+		addIntervalVisitors(buckets, data.TrafficVisitors, step, index)
+		s.Timeline.HasVisitors = true
+	}
+
+	for i := range buckets {
+		if buckets[i].Requests > 0 {
+			buckets[i].ErrorRate = float32(buckets[i].ClientErrors+buckets[i].ServerErrors) / float32(buckets[i].Requests) * 100
+		}
+
+		if s.Timeline.Peak < 0 || buckets[i].Requests > buckets[s.Timeline.Peak].Requests {
+			s.Timeline.Peak = i
+		}
+	}
+
+	s.Timeline.Buckets = buckets
+}
+
+// addTraffic - суммирует минутные счетчики в интервалы, index - номер интервала для минуты.
+func addTraffic(buckets []TrafficBucket, traffic map[int64]TrafficCounters, index func(minute int64) int64) {
+	for minute, counters := range traffic {
+		i := index(minute)
+		buckets[i].Requests += counters.Requests
+		buckets[i].ClientErrors += counters.ClientErrors
+		buckets[i].ServerErrors += counters.ServerErrors
+		buckets[i].Bytes += counters.Bytes
+	}
+}
+
+// addIntervalVisitors - сливает скетчи клиентов шага step в интервалы, index - номер интервала для минуты.
+func addIntervalVisitors(buckets []TrafficBucket, cells map[int64]*VisitorSketch, step int64, index func(minute int64) int64) {
+	visitors := make([]*VisitorSketch, len(buckets))
+
+	for cell, sketch := range cells {
+		i := index(cell * step)
+		if visitors[i] == nil {
+			visitors[i] = newVisitorSketch(breakdownPrecision)
+		}
+
+		_ = visitors[i].Merge(sketch) // точность у всех скетчей интервалов одинаковая
+	}
+
+	for i := range buckets {
+		buckets[i].Visitors = visitors[i].Estimate()
+	}
+}
+
+// roundBucket - округляет увеличенный размер интервала в минутах вверх до целых суток или часов, чтобы подпись
+// интервала была читаемой.
+func roundBucket(size int64) int64 {
+	day, hour := int64(24*time.Hour/time.Minute), int64(time.Hour/time.Minute)
+
+	switch {
+	case size > day:
+		return (size + day - 1) / day * day
+	case size > hour:
+		return (size + hour - 1) / hour * hour
+	default:
+		return size
+	}
+}
+
+// autoBucket - наименьший размер из AutoBucketSizes, при котором диапазон укладывается в maxAutoBuckets интервалов.
+func autoBucket(span time.Duration) time.Duration {
+	for _, size := range AutoBucketSizes {
+		if span/size < maxAutoBuckets {
+			return size
+		}
+	}
+
+	return AutoBucketSizes[len(AutoBucketSizes)-1]
+}
+
+// floorDiv - деление с округлением вниз, в отличие от оператора / для отрицательных чисел.
+func floorDiv(a, b int64) int64 {
+	quotient := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		quotient--
+	}
+
+	return quotient
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+)
+
+// Секции отчета, для которых можно отдельно задать размер рейтинга.
+const (
+	TopRequests  = "requests"
+	TopResources = "resources"
+	TopCodes     = "codes"
+	TopSlowest   = "slowest"
+	TopClients   = "clients"
+	TopBrowsers  = "browsers"
+	TopOS        = "os"
+	TopDevices   = "devices"
+	TopBots      = "bots"
+	TopCountries = "countries"
+	TopASNs      = "asns"
+	TopParams    = "params"
+)
+
+// DefaultTop - размер рейтинга по умолчанию.
+const DefaultTop = 3
+
+// TopSections - все секции с рейтингами, нужны для проверки флага -top.
+var TopSections = []string{
+	TopRequests, TopResources, TopCodes, TopSlowest, TopClients, TopBrowsers, TopOS, TopDevices, TopBots,
+	TopCountries, TopASNs, TopParams,
+}
+
+// TopLimits - сколько строк выводить в рейтингах: Default для всех секций, Sections переопределяет отдельные секции.
+// Нулевое значение означает DefaultTop.
+type TopLimits struct {
+	Default  int
+	Sections map[string]int
+}
+
+// For - размер рейтинга для секции.
+func (t TopLimits) For(section string) int {
+	if limit, ok := t.Sections[section]; ok && limit > 0 {
+		return limit
+	}
+
+	if t.Default > 0 {
+		return t.Default
+	}
+
+	return DefaultTop
+}
+
+// sortKeyCounts - сортирует по убыванию количества, при равенстве - по значению, чтобы два запуска
+// на одних и тех же данных давали одинаковый отчет.
+func sortKeyCounts(items []KeyCount) {
+	slices.SortFunc(items, func(a, b KeyCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+
+		return strings.Compare(a.Value, b.Value)
+	})
+}
+
+// findTop - функция, которая помогает найти limit самых используемых значений в мапе. Все, что не вошло в рейтинг,
+// суммируется в строку "остальные", поэтому сумма по таблице совпадает с общим количеством.
+func findTop(data map[string]int, limit int) []KeyCount {
+	items := make([]KeyCount, 0, len(data))
+	for value, count := range data {
+		items = append(items, KeyCount{Value: value, Count: count})
+	}
+
+	sortKeyCounts(items)
+
+	if len(items) <= limit {
+		return items
+	}
+
+	other := KeyCount{Other: true}
+	for _, item := range items[limit:] {
+		other.Count += item.Count
+	}
+
+	return append(items[:limit:limit], other)
+}
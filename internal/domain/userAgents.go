@@ -0,0 +1,52 @@
+package domain
+
+// UserAgentStats - распределение клиентов по User-Agent. Браузеры и ОС считаются только по запросам людей,
+// типы устройств - по всем запросам, включая ботов.
+type UserAgentStats struct {
+	Browsers         []KeyCount
+	OperatingSystems []KeyCount
+	Devices          []KeyCount
+	Bots             []KeyCount
+	BotRequests      int
+	// BotShare - процент запросов ботов от общего числа.
+	BotShare float32
+}
+
+// collectUserAgent - учитывает класс User-Agent, если запись обогащена классификатором.
+func (s *DataHolder) collectUserAgent() {
+	browser, ok := s.entry.Get(UABrowser)
+	if !ok {
+		return
+	}
+
+	if device, ok := s.entry.Get(UADevice); ok {
+		s.Devices[device]++
+	}
+
+	if bot, _ := s.entry.Get(UABot); bot == "true" {
+		s.BotRequests++
+		s.Bots[browser]++
+
+		return
+	}
+
+	s.Browsers[browser]++
+
+	if os, ok := s.entry.Get(UAOS); ok {
+		s.OperatingSystems[os]++
+	}
+}
+
+func (s *Statistic) fillUserAgents(data *DataHolder) {
+	s.UserAgents = UserAgentStats{
+		Browsers:         findTop(data.Browsers, s.Top.For(TopBrowsers)),
+		OperatingSystems: findTop(data.OperatingSystems, s.Top.For(TopOS)),
+		Devices:          findTop(data.Devices, s.Top.For(TopDevices)),
+		Bots:             findTop(data.Bots, s.Top.For(TopBots)),
+		BotRequests:      data.BotRequests,
+	}
+
+	if data.TotalCounter > 0 {
+		s.UserAgents.BotShare = float32(data.BotRequests) / float32(data.TotalCounter) * 100
+	}
+}
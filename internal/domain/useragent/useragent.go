@@ -0,0 +1,226 @@
+// Package useragent - классификатор User-Agent по базе регулярных выражений: браузер и его версия, ОС,
+// тип устройства и признак бота.
+package useragent
+
+import (
+	_ "embed"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// OtherFamily - браузер или ОС, которые не нашлись в базе.
+const OtherFamily = "Other"
+
+// maxCacheSize - сколько различных User-Agent запоминается. User-Agent в логах сильно повторяются,
+// поэтому регулярные выражения выполняются для каждого из них один раз, кеш сбрасывается при переполнении.
+const maxCacheSize = 4096
+
+// embeddedDatabase - база по умолчанию, ее можно заменить своей в том же формате через Load.
+//
+//go:embed regexes.json
+var embeddedDatabase []byte
+
+// Result - класс User-Agent.
+type Result struct {
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+	Device         string
+	Bot            bool
+}
+
+// database - формат файла базы: правила проверяются по порядку, срабатывает первое подошедшее.
+// В family и version можно ссылаться на группы регулярного выражения: $1, $2...
+type database struct {
+	Bots     []ruleSpec `json:"bots"`
+	Browsers []ruleSpec `json:"browsers"`
+	OS       []ruleSpec `json:"os"`
+	Devices  []ruleSpec `json:"devices"`
+}
+
+type ruleSpec struct {
+	Regex   string `json:"regex"`
+	Family  string `json:"family"`
+	Version string `json:"version"`
+	Type    string `json:"type"`
+}
+
+type rule struct {
+	regex   *regexp.Regexp
+	family  string
+	version string
+}
+
+// Classifier - классификатор User-Agent, реализует domain.Enricher. Безопасен для одновременного
+// использования из нескольких горутин.
+type Classifier struct {
+	bots     []rule
+	browsers []rule
+	os       []rule
+	devices  []rule
+	mu       sync.RWMutex
+	cache    map[string]Result
+}
+
+// Default - классификатор со встроенной базой.
+func Default() *Classifier {
+	classifier, err := Load(embeddedDatabase)
+	if err != nil {
+		panic("embedded user agent database: " + err.Error())
+	}
+
+	return classifier
+}
+
+// Load - создает классификатор по базе в формате JSON, см. regexes.json.
+func Load(data []byte) (*Classifier, error) {
+	var spec database
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, errors.ErrUserAgentDatabase{Reason: err.Error()}
+	}
+
+	classifier := &Classifier{cache: make(map[string]Result)}
+
+	sections := []struct {
+		specs []ruleSpec
+		rules *[]rule
+		types bool
+	}{
+		{spec.Bots, &classifier.bots, false},
+		{spec.Browsers, &classifier.browsers, false},
+		{spec.OS, &classifier.os, false},
+		{spec.Devices, &classifier.devices, true},
+	}
+
+	for _, section := range sections {
+		for _, ruleSpec := range section.specs {
+			regex, err := regexp.Compile(ruleSpec.Regex)
+			if err != nil {
+				return nil, errors.ErrUserAgentDatabase{Reason: err.Error()}
+			}
+
+			family := ruleSpec.Family
+			if section.types {
+				family = ruleSpec.Type
+			}
+
+			if family == "" {
+				return nil, errors.ErrUserAgentDatabase{Reason: "rule " + ruleSpec.Regex + " has no family or type"}
+			}
+
+			*section.rules = append(*section.rules, rule{regex: regex, family: family, version: ruleSpec.Version})
+		}
+	}
+
+	return classifier, nil
+}
+
+// Fields - поля, которые добавляет Enrich.
+func (c *Classifier) Fields() []string {
+	return []string{domain.UABrowser, domain.UABrowserVersion, domain.UAOS, domain.UAOSVersion, domain.UADevice, domain.UABot}
+}
+
+// Enrich - классифицирует $http_user_agent записи, если его нет - запись не меняется.
+func (c *Classifier) Enrich(entry *domain.LogEntry) {
+	userAgent, ok := entry.Get(domain.HTTPUserAgent)
+	if !ok {
+		return
+	}
+
+	result := c.Classify(userAgent)
+
+	entry.Set(domain.UABrowser, result.Browser)
+	entry.Set(domain.UABrowserVersion, result.BrowserVersion)
+	entry.Set(domain.UAOS, result.OS)
+	entry.Set(domain.UAOSVersion, result.OSVersion)
+	entry.Set(domain.UADevice, result.Device)
+
+	if result.Bot {
+		entry.Set(domain.UABot, "true")
+	} else {
+		entry.Set(domain.UABot, "false")
+	}
+}
+
+// Classify - определяет класс User-Agent. Для ботов Browser содержит имя бота, а Device - domain.DeviceBot.
+func (c *Classifier) Classify(userAgent string) Result {
+	c.mu.RLock()
+	result, ok := c.cache[userAgent]
+	c.mu.RUnlock()
+
+	if ok {
+		return result
+	}
+
+	result = c.classify(userAgent)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.cache) >= maxCacheSize {
+		clear(c.cache)
+	}
+
+	c.cache[strings.Clone(userAgent)] = result
+
+	return result
+}
+
+func (c *Classifier) classify(userAgent string) Result {
+	var result Result
+
+	result.OS, result.OSVersion = match(c.os, userAgent)
+	if result.OS == "" {
+		result.OS = OtherFamily
+	}
+
+	if family, version := match(c.bots, userAgent); family != "" {
+		result.Browser, result.BrowserVersion, result.Device, result.Bot = family, version, domain.DeviceBot, true
+
+		return result
+	}
+
+	result.Browser, result.BrowserVersion = match(c.browsers, userAgent)
+	if result.Browser == "" {
+		result.Browser = OtherFamily
+	}
+
+	result.Device, _ = match(c.devices, userAgent)
+	if result.Device == "" {
+		result.Device = domain.DeviceOther
+	}
+
+	return result
+}
+
+// match - семейство и версия по первому подошедшему правилу, пустые строки если ни одно не подошло.
+func match(rules []rule, userAgent string) (family, version string) {
+	for _, rule := range rules {
+		submatches := rule.regex.FindStringSubmatchIndex(userAgent)
+		if submatches == nil {
+			continue
+		}
+
+		family = expand(rule.regex, rule.family, userAgent, submatches)
+		version = expand(rule.regex, rule.version, userAgent, submatches)
+
+		return family, version
+	}
+
+	return "", ""
+}
+
+// expand - подставляет группы в шаблон, шаблон без $ возвращается как есть, без аллокаций.
+func expand(regex *regexp.Regexp, template, userAgent string, submatches []int) string {
+	if !strings.Contains(template, "$") {
+		return template
+	}
+
+	return string(regex.ExpandString(nil, template, userAgent, submatches))
+}
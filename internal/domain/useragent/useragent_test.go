@@ -0,0 +1,117 @@
+package useragent_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+	"LogAnalyzer/internal/domain/useragent"
+)
+
+func TestClassifier_Classify(t *testing.T) {
+	testCases := []struct {
+		userAgent string
+		expected  useragent.Result
+	}{
+		{
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected:  useragent.Result{Browser: "Chrome", BrowserVersion: "120", OS: "Windows", OSVersion: "10", Device: domain.DeviceDesktop},
+		},
+		{
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 " +
+				"Edg/120.0.2210.91",
+			expected: useragent.Result{Browser: "Edge", BrowserVersion: "120", OS: "Windows", OSVersion: "10", Device: domain.DeviceDesktop},
+		},
+		{
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) " +
+				"Version/17.1 Mobile/15E148 Safari/604.1",
+			expected: useragent.Result{Browser: "Safari", BrowserVersion: "17", OS: "iOS", OSVersion: "17", Device: domain.DeviceMobile},
+		},
+		{
+			userAgent: "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+			expected:  useragent.Result{Browser: "Chrome", BrowserVersion: "119", OS: "Android", OSVersion: "13", Device: domain.DeviceTablet},
+		},
+		{
+			userAgent: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			expected:  useragent.Result{Browser: "Firefox", BrowserVersion: "121", OS: "Linux", Device: domain.DeviceDesktop},
+		},
+		{
+			userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			expected:  useragent.Result{Browser: "Googlebot", BrowserVersion: "2", OS: useragent.OtherFamily, Device: domain.DeviceBot, Bot: true},
+		},
+		{
+			userAgent: "Mozilla/5.0 (compatible; MegaIndex.ru/2.0; +http://megaindex.com/crawler)",
+			expected:  useragent.Result{Browser: "crawler", OS: useragent.OtherFamily, Device: domain.DeviceBot, Bot: true},
+		},
+		{
+			userAgent: "Mozilla/5.0 (compatible; SiteAuditBot/0.97; +http://www.semrush.com/bot.html)",
+			expected: useragent.Result{
+				Browser: "SiteAuditBot", BrowserVersion: "0", OS: useragent.OtherFamily, Device: domain.DeviceBot, Bot: true,
+			},
+		},
+		{
+			userAgent: "Mozilla/5.0 (compatible; YisouSpider)",
+			expected:  useragent.Result{Browser: "YisouSpider", OS: useragent.OtherFamily, Device: domain.DeviceBot, Bot: true},
+		},
+		{
+			// "bot" внутри названия устройства - не признак бота
+			userAgent: "Mozilla/5.0 (Linux; Android 10; CUBOT X30) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 " +
+				"Mobile Safari/537.36",
+			expected: useragent.Result{Browser: "Chrome", BrowserVersion: "120", OS: "Android", OSVersion: "10", Device: domain.DeviceMobile},
+		},
+		{
+			userAgent: "Debian APT-HTTP/1.3 (0.8.16~exp12ubuntu10.21)",
+			expected:  useragent.Result{Browser: "APT", BrowserVersion: "1", OS: "Linux", Device: domain.DeviceOther},
+		},
+		{
+			userAgent: "curl/8.4.0",
+			expected:  useragent.Result{Browser: "curl", BrowserVersion: "8", OS: useragent.OtherFamily, Device: domain.DeviceOther},
+		},
+		{
+			userAgent: "-",
+			expected: useragent.Result{
+				Browser: useragent.OtherFamily, OS: useragent.OtherFamily, Device: domain.DeviceOther,
+			},
+		},
+	}
+
+	classifier := useragent.Default()
+
+	for _, tc := range testCases {
+		t.Run(tc.userAgent, func(tt *testing.T) {
+			assert.Equal(tt, tc.expected, classifier.Classify(tc.userAgent))
+			// Повторный вызов берет результат из кеша
+			assert.Equal(tt, tc.expected, classifier.Classify(tc.userAgent))
+		})
+	}
+}
+
+func TestClassifier_Enrich(t *testing.T) {
+	var entry domain.LogEntry
+
+	entry.Set(domain.HTTPUserAgent, "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)")
+	useragent.Default().Enrich(&entry)
+
+	browser, _ := entry.Get(domain.UABrowser)
+	bot, _ := entry.Get(domain.UABot)
+
+	assert.Equal(t, "Bingbot", browser)
+	assert.Equal(t, "true", bot)
+}
+
+func TestLoad_CustomDatabase(t *testing.T) {
+	classifier, err := useragent.Load([]byte(`{"browsers": [{"regex": "MyApp/(\\d+)", "family": "MyApp", "version": "$1"}]}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, useragent.Result{Browser: "MyApp", BrowserVersion: "3", OS: useragent.OtherFamily, Device: domain.DeviceOther},
+		classifier.Classify("MyApp/3.2"))
+
+	_, err = useragent.Load([]byte(`{"bots": [{"regex": "(", "family": "x"}]}`))
+	assert.ErrorAs(t, err, &errors.ErrUserAgentDatabase{})
+
+	_, err = useragent.Load([]byte(`{"devices": [{"regex": "x"}]}`))
+	assert.ErrorAs(t, err, &errors.ErrUserAgentDatabase{})
+}
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"LogAnalyzer/pkg/sketch"
+)
+
+// breakdownPrecision - точность скетчей уникальных клиентов для интервалов и ресурсов: их много, поэтому
+// берется 2^10 регистров (не больше 1 КБ на скетч) со стандартной ошибкой ~3%. Общая оценка считается
+// с sketch.DefaultPrecision.
+const breakdownPrecision = 10
+
+// MaxResourceVisitors - для скольких различных ресурсов заводятся скетчи клиентов, до 2 КБ каждый. Ресурсы,
+// впервые встреченные после этого, не получают оценки клиентов, чтобы память не росла с числом различных путей.
+const MaxResourceVisitors = 4096
+
+// maxVisitorCells - сколько скетчей клиентов по времени хранится не больше, пока шаг не дошел до часа:
+// при переполнении шаг увеличивается по visitorSteps и соседние скетчи сливаются.
+const maxVisitorCells = 1440
+
+// visitorSteps - шаги скетчей клиентов по времени в минутах. Все шаги делят час и кратны друг другу, поэтому
+// скетчи сливаются в более крупный шаг без потерь, а часовые поясы со смещением, кратным 15 минутам, не мешают
+// выравниванию.
+var visitorSteps = []int64{1, 5, 15, 60}
+
+// Visitors - оценка числа уникальных клиентов по IP и по паре IP + User-Agent.
+type Visitors struct {
+	ByIP      uint64
+	ByIPAgent uint64
+}
+
+// VisitorSketch - скетчи уникальных клиентов, сливаются без потери точности.
+type VisitorSketch struct {
+	ByIP      *sketch.HyperLogLog
+	ByIPAgent *sketch.HyperLogLog
+}
+
+func newVisitorSketch(precision uint8) *VisitorSketch {
+	return &VisitorSketch{ByIP: sketch.NewHyperLogLog(precision), ByIPAgent: sketch.NewHyperLogLog(precision)}
+}
+
+func (v *VisitorSketch) add(client clientHashes) {
+	v.ByIP.Add(client.ip)
+	v.ByIPAgent.Add(client.ipAgent)
+}
+
+// Merge - добавляет клиентов другого скетча.
+func (v *VisitorSketch) Merge(other *VisitorSketch) error {
+	if other == nil {
+		return nil
+	}
+
+	if err := v.ByIP.Merge(other.ByIP); err != nil {
+		return err
+	}
+
+	return v.ByIPAgent.Merge(other.ByIPAgent)
+}
+
+// Estimate - оценка числа уникальных клиентов, для nil - нули.
+func (v *VisitorSketch) Estimate() Visitors {
+	if v == nil {
+		return Visitors{}
+	}
+
+	return Visitors{ByIP: v.ByIP.Estimate(), ByIPAgent: v.ByIPAgent.Estimate()}
+}
+
+// clientHashes - хеши клиента записи, считаются один раз и добавляются во все скетчи.
+type clientHashes struct {
+	ip      uint64
+	ipAgent uint64
+}
+
+// clientOf - хеши клиента текущей записи, ok = false если в формате нет $remote_addr. Если нет User-Agent,
+// пара IP + User-Agent считается с пустым User-Agent.
+func (s *DataHolder) clientOf() (client clientHashes, ok bool) {
+	ip, ok := s.entry.Get(RemoteAddr)
+	if !ok {
+		return clientHashes{}, false
+	}
+
+	agent, _ := s.entry.Get(HTTPUserAgent)
+
+	return clientHashes{ip: sketch.Hash(ip), ipAgent: sketch.Hash(ip, agent)}, true
+}
+
+// collectVisitors - учитывает клиента в общей оценке и в оценке для ресурса, если для него есть или еще можно
+// завести скетч.
+func (s *DataHolder) collectVisitors(client clientHashes, resource string, hasResource bool) {
+	s.Visitors.add(client)
+
+	if !hasResource {
+		return
+	}
+
+	resourceVisitors, ok := s.ResourceVisitors[resource]
+	if !ok {
+		if len(s.ResourceVisitors) >= MaxResourceVisitors {
+			return
+		}
+
+		resourceVisitors = newVisitorSketch(breakdownPrecision)
+		s.ResourceVisitors[resource] = resourceVisitors
+	}
+
+	resourceVisitors.add(client)
+}
+
+// collectIntervalVisitors - учитывает клиента в скетче его отрезка времени длиной VisitorStep минут.
+func (s *DataHolder) collectIntervalVisitors(minute int64, client clientHashes) {
+	cell := floorDiv(minute, s.visitorStep())
+
+	visitors, ok := s.TrafficVisitors[cell]
+	if !ok {
+		visitors = newVisitorSketch(breakdownPrecision)
+		s.TrafficVisitors[cell] = visitors
+	}
+
+	visitors.add(client)
+
+	if !ok {
+		s.limitVisitorCells()
+	}
+}
+
+// visitorStep - шаг скетчей клиентов по времени, для DataHolder без шага - минута.
+func (s *DataHolder) visitorStep() int64 {
+	return max(s.VisitorStep, 1)
+}
+
+// limitVisitorCells - увеличивает шаг скетчей клиентов, пока их не станет не больше maxVisitorCells,
+// но не дальше часа.
+func (s *DataHolder) limitVisitorCells() {
+	for _, step := range visitorSteps {
+		if len(s.TrafficVisitors) <= maxVisitorCells {
+			return
+		}
+
+		if step > s.visitorStep() {
+			s.coarsenVisitors(step)
+		}
+	}
+}
+
+// coarsenVisitors - сливает скетчи клиентов в отрезки длиной step минут, step должен быть кратен текущему шагу.
+func (s *DataHolder) coarsenVisitors(step int64) {
+	cells := make(map[int64]*VisitorSketch, len(s.TrafficVisitors))
+
+	for cell, visitors := range s.TrafficVisitors {
+		coarse := floorDiv(cell*s.visitorStep(), step)
+		if current, ok := cells[coarse]; ok {
+			_ = current.Merge(visitors) // точность у всех скетчей отрезков одинаковая
+		} else {
+			cells[coarse] = visitors
+		}
+	}
+
+	s.TrafficVisitors, s.VisitorStep = cells, step
+}
+
+// fillVisitors - оценки уникальных клиентов: общая и для топ ресурсов.
+func (s *Statistic) fillVisitors(data *DataHolder, topResources []KeyCount) {
+	s.Visitors = data.Visitors.Estimate()
+	s.ResourceVisitors = make(map[string]Visitors, len(topResources))
+
+	for _, resource := range topResources {
+		if resourceVisitors, ok := data.ResourceVisitors[resource.Value]; ok && !resource.Other {
+			s.ResourceVisitors[resource.Value] = resourceVisitors.Estimate()
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+// Chunk - часть файла [Offset, Offset+Size). Часть начинается с начала строки и заканчивается переводом строки,
+// кроме последней, которая заканчивается вместе с файлом.
+type Chunk struct {
+	Offset int64
+	Size   int64
+}
+
+// SplitFile - делит несжатый файл на parts частей примерно одинакового размера по границам строк, чтобы разбирать
+// их одновременно. Части меньше minSize не создаются. Сжатый файл нельзя читать с середины, для него, как и для
+// маленького файла, возвращается nil.
+func SplitFile(path string, parts int, minSize int64) ([]Chunk, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size()
+	parts = min(parts, int(size/max(minSize, 1)))
+
+	// Из канала или устройства нельзя читать с середины, а чтение сигнатуры забрало бы из них данные
+	if parts < 2 || !info.Mode().IsRegular() {
+		return nil, nil
+	}
+
+	// Ошибку распаковщика здесь не возвращаем: ее покажет разбор файла целиком
+	reader, err := NewLogReader(file)
+	if err != nil {
+		return nil, nil
+	}
+
+	reader.Close()
+
+	if reader.Compression != CompressionNone {
+		return nil, nil
+	}
+
+	chunks := make([]Chunk, 0, parts)
+	start := int64(0)
+
+	for i := 1; i < parts && start < size; i++ {
+		end, err := lineEnd(file, max(size*int64(i)/int64(parts), start))
+		if err != nil {
+			return nil, err
+		}
+
+		if end > start {
+			chunks = append(chunks, Chunk{Offset: start, Size: end - start})
+			start = end
+		}
+	}
+
+	if start < size {
+		chunks = append(chunks, Chunk{Offset: start, Size: size - start})
+	}
+
+	return chunks, nil
+}
+
+// lineEnd - смещение сразу после первого перевода строки, начиная с offset, или конец файла.
+func lineEnd(file *os.File, offset int64) (int64, error) {
+	reader := bufio.NewReaderSize(io.NewSectionReader(file, offset, 1<<62), readBufferSize)
+
+	line, err := reader.ReadSlice('\n')
+	for errors.Is(err, bufio.ErrBufferFull) {
+		offset += int64(len(line))
+		line, err = reader.ReadSlice('\n')
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	return offset + int64(len(line)), nil
+}
+
+// OpenChunk - открывает часть несжатого файла. Закрывается через Close.
+func OpenChunk(path string, chunk Chunk) (*LogReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	section := bufio.NewReaderSize(io.NewSectionReader(file, chunk.Offset, chunk.Size), readBufferSize)
+
+	return &LogReader{Reader: section, Compression: CompressionNone, closers: []func() error{file.Close}}, nil
+}
+
+// CountLines - число строк в части файла, последняя строка без перевода строки тоже считается.
+func CountLines(path string, chunk Chunk) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+
+	defer file.Close()
+
+	var (
+		buf   = make([]byte, readBufferSize)
+		lines = 0
+		last  = byte('\n')
+	)
+
+	section := io.NewSectionReader(file, chunk.Offset, chunk.Size)
+
+	for {
+		n, err := section.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if last != '\n' {
+		lines++
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,78 @@
+package infrastructure_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/infrastructure"
+)
+
+func TestSplitFile(t *testing.T) {
+	var content strings.Builder
+	for i := range 1000 {
+		content.WriteString(strings.Repeat("x", i%50) + "\n")
+	}
+
+	content.WriteString("last line without newline")
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	require.NoError(t, os.WriteFile(path, []byte(content.String()), 0o600))
+
+	chunks, err := infrastructure.SplitFile(path, 4, 1024)
+	require.NoError(t, err)
+	require.Len(t, chunks, 4)
+
+	var (
+		joined strings.Builder
+		lines  int
+		offset int64
+	)
+
+	for _, chunk := range chunks {
+		assert.Equal(t, offset, chunk.Offset, "chunks are contiguous")
+		offset += chunk.Size
+
+		reader, err := infrastructure.OpenChunk(path, chunk)
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, reader.Close())
+
+		joined.Write(data)
+
+		if chunk != chunks[len(chunks)-1] {
+			assert.True(t, strings.HasSuffix(string(data), "\n"), "chunk ends at a line boundary")
+		}
+
+		count, err := infrastructure.CountLines(path, chunk)
+		require.NoError(t, err)
+
+		lines += count
+	}
+
+	assert.Equal(t, content.String(), joined.String())
+	assert.Equal(t, 1001, lines)
+}
+
+func TestSplitFile_NotSplit(t *testing.T) {
+	dir := t.TempDir()
+
+	small := filepath.Join(dir, "small.log")
+	require.NoError(t, os.WriteFile(small, []byte(plainLog), 0o600))
+
+	compressed := filepath.Join(dir, "access.log.gz")
+	require.NoError(t, os.WriteFile(compressed, gzipped(t, strings.Repeat(plainLog, 1000)), 0o600))
+
+	for _, path := range []string{small, compressed} {
+		chunks, err := infrastructure.SplitFile(path, 4, 16)
+		require.NoError(t, err)
+		assert.Nil(t, chunks, path)
+	}
+}
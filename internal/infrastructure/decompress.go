@@ -0,0 +1,114 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Форматы сжатия, которые распознаются по сигнатуре в начале файла.
+const (
+	CompressionNone  = "none"
+	CompressionGzip  = "gzip"
+	CompressionBzip2 = "bzip2"
+	CompressionZstd  = "zstd"
+)
+
+// readBufferSize - размер буфера чтения файла, его же хватает чтобы заглянуть в сигнатуру не читая файл дважды.
+const readBufferSize = 64 * 1024
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// LogReader - поток строк лога, сжатый файл прозрачно распаковывается на лету.
+type LogReader struct {
+	io.Reader
+	// Compression - формат сжатия файла, определенный по сигнатуре.
+	Compression string
+	closers     []func() error
+}
+
+// OpenLog - открывает файл лога. Формат сжатия определяется по сигнатуре, а не по расширению, поэтому
+// access.log.2.gz, сжатый файл без расширения и скачанный по ссылке архив читаются одинаково.
+// Файл нужно закрыть через Close.
+func OpenLog(path string) (*LogReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := NewLogReader(file)
+	if err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	reader.closers = append(reader.closers, file.Close)
+
+	return reader, nil
+}
+
+// NewLogReader - распознает сжатие потока r и возвращает распакованный поток. Закрытие LogReader
+// не закрывает r.
+func NewLogReader(r io.Reader) (*LogReader, error) {
+	buffered := bufio.NewReaderSize(r, readBufferSize)
+
+	// Ошибку чтения сигнатуры не проверяем: короткий или пустой файл просто не сжат, а ошибка чтения
+	// вернется при чтении самого потока
+	magic, _ := buffered.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		decoder, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+
+		return &LogReader{Reader: decoder, Compression: CompressionGzip, closers: []func() error{decoder.Close}}, nil
+	case bytes.HasPrefix(magic, bzip2Magic) && len(magic) > len(bzip2Magic) && isBlockSize(magic[len(bzip2Magic)]):
+		return &LogReader{Reader: bzip2.NewReader(buffered), Compression: CompressionBzip2}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		decoder, err := zstd.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+
+		closeDecoder := func() error {
+			decoder.Close()
+
+			return nil
+		}
+
+		return &LogReader{Reader: decoder, Compression: CompressionZstd, closers: []func() error{closeDecoder}}, nil
+	default:
+		return &LogReader{Reader: buffered, Compression: CompressionNone}, nil
+	}
+}
+
+// isBlockSize - после сигнатуры bzip2 идет размер блока от '1' до '9', проверка отличает архив от текста,
+// который случайно начинается с "BZh".
+func isBlockSize(c byte) bool {
+	return c >= '1' && c <= '9'
+}
+
+// Close - закрывает распаковщик и файл.
+func (r *LogReader) Close() error {
+	var closeErr error
+
+	for _, closer := range r.closers {
+		if err := closer(); err != nil {
+			closeErr = err
+		}
+	}
+
+	return closeErr
+}
@@ -0,0 +1,106 @@
+package infrastructure_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/infrastructure"
+)
+
+const plainLog = "line one\nline two\n"
+
+// bzip2Log - plainLog, сжатый bzip2 -9: в стандартной библиотеке есть только распаковщик bzip2.
+const bzip2Log = "425a68393141592653598c77bfde000004d1800010400002258480200031064c40c869a68f0b2c20989c278bb9229c2848463bdfef00"
+
+func gzipped(t *testing.T, parts ...string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	// Каждая часть - отдельный член gzip, как у файлов, дописанных через cat a.gz b.gz
+	for _, part := range parts {
+		writer := gzip.NewWriter(&buf)
+		_, err := writer.Write([]byte(part))
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+	}
+
+	return buf.Bytes()
+}
+
+func zstdCompressed(t *testing.T, data string) []byte {
+	t.Helper()
+
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+
+	defer encoder.Close()
+
+	return encoder.EncodeAll([]byte(data), nil)
+}
+
+func TestOpenLog(t *testing.T) {
+	bzip2Data, err := hex.DecodeString(bzip2Log)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		file        string
+		data        []byte
+		compression string
+	}{
+		{"plain", "access.log", []byte(plainLog), infrastructure.CompressionNone},
+		{"gzip by magic bytes, not extension", "access.log.2", gzipped(t, plainLog), infrastructure.CompressionGzip},
+		{"concatenated gzip members", "access.log.3.gz", gzipped(t, "line one\n", "line two\n"), infrastructure.CompressionGzip},
+		{"bzip2", "access.log.4.bz2", bzip2Data, infrastructure.CompressionBzip2},
+		{"zstd", "access.log.5.zst", zstdCompressed(t, plainLog), infrastructure.CompressionZstd},
+		{"text starting like bzip2", "bzh.log", []byte("BZh is not a header\n"), infrastructure.CompressionNone},
+		{"empty", "empty.log", nil, infrastructure.CompressionNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, tt.data, 0o600))
+
+			reader, err := infrastructure.OpenLog(path)
+			require.NoError(t, err)
+
+			defer func() { assert.NoError(t, reader.Close()) }()
+
+			content, err := io.ReadAll(reader)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.compression, reader.Compression)
+
+			if tt.compression == infrastructure.CompressionNone {
+				assert.Equal(t, string(tt.data), string(content))
+			} else {
+				assert.Equal(t, plainLog, string(content))
+			}
+		})
+	}
+}
+
+func TestOpenLog_CorruptedArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log.gz")
+	data := gzipped(t, plainLog)
+	require.NoError(t, os.WriteFile(path, data[:len(data)/2], 0o600))
+
+	reader, err := infrastructure.OpenLog(path)
+	require.NoError(t, err)
+
+	defer reader.Close()
+
+	_, err = io.ReadAll(reader)
+	assert.Error(t, err)
+}
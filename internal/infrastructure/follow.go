@@ -0,0 +1,186 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// Follower - читает дописываемый файл как tail -F: сначала то, что уже есть в файле, затем новые строки.
+// Если файл переименовали при ротации и на его месте создан новый, старый дочитывается до конца, а новый
+// читается с начала. Если файл обрезали (copytruncate), чтение начинается заново с начала файла: обрезка
+// определяется по уменьшению размера или по изменению первых байт файла (до FingerprintSize), так что
+// замечается, даже если к следующей проверке файл успел вырасти больше прежнего. Не замечается только обрезка,
+// после которой начало файла дописано теми же байтами, что и раньше. Недописанная последняя строка ждет
+// перевода строки. Строки читаются так же, как LineReader: от строки длиннее maxLength хранится только начало.
+type Follower struct {
+	path    string
+	file    *os.File
+	info    os.FileInfo
+	reader  *bufio.Reader
+	offset  int64
+	partial lineBuffer
+	// head - прочитанное начало файла, не больше FingerprintSize байт, по нему замечается обрезка.
+	head []byte
+}
+
+// NewFollower - создает слежение за файлом path, файл открывается при первом Poll и может еще не существовать.
+// maxLength <= 0 - строки не обрезаются.
+func NewFollower(path string, maxLength int) *Follower {
+	return &Follower{path: path, partial: lineBuffer{maxLength: maxLength}}
+}
+
+// Poll - передает в handle все полные строки, дописанные с прошлого вызова.
+func (f *Follower) Poll(handle func(line Line)) error {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+
+			return err
+		}
+	}
+
+	current, err := os.Stat(f.path)
+	if err != nil {
+		// Файл переименован, а новый еще не создан: дочитываем старый и ждем новый
+		if errors.Is(err, fs.ErrNotExist) {
+			return f.readAvailable(handle)
+		}
+
+		return err
+	}
+
+	if !os.SameFile(f.info, current) {
+		return f.rotate(handle)
+	}
+
+	// Обрезка проверяется до чтения, иначе новое начало файла было бы прочитано с прежнего смещения
+	truncated, err := f.truncated(current.Size())
+	if err != nil {
+		return err
+	}
+
+	if truncated {
+		if err := f.restart(); err != nil {
+			return err
+		}
+	}
+
+	return f.readAvailable(handle)
+}
+
+// restart - начинает чтение файла заново с начала.
+func (f *Follower) restart() error {
+	if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	f.reader.Reset(f.file)
+	f.offset = 0
+	f.head = f.head[:0]
+	f.partial.reset()
+
+	return nil
+}
+
+// rotate - переходит на новый файл после ротации. Старый сначала дочитывается: в него могли дописать после
+// прошлого чтения, например nginx пишет в переименованный файл до USR1. Его последняя строка уже не будет дописана.
+func (f *Follower) rotate(handle func(line Line)) error {
+	if err := f.readAvailable(handle); err != nil {
+		return err
+	}
+
+	if !f.partial.empty() {
+		handle(f.partial.take())
+	}
+
+	f.Close()
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	return f.readAvailable(handle)
+}
+
+// truncated - обрезан ли файл: он стал короче прочитанного или его начало уже не совпадает с прочитанным.
+func (f *Follower) truncated(size int64) (bool, error) {
+	if size < f.offset {
+		return true, nil
+	}
+
+	if len(f.head) == 0 {
+		return false, nil
+	}
+
+	head := make([]byte, len(f.head))
+
+	n, err := f.file.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	return !bytes.Equal(head[:n], f.head), nil
+}
+
+func (f *Follower) open() error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return err
+	}
+
+	f.file, f.info, f.offset = file, info, 0
+	f.reader = bufio.NewReaderSize(file, readBufferSize)
+	f.head = f.head[:0]
+	f.partial.reset()
+
+	return nil
+}
+
+// readAvailable - читает файл до конца, полные строки передаются в handle.
+func (f *Follower) readAvailable(handle func(line Line)) error {
+	for {
+		chunk, err := f.reader.ReadSlice('\n')
+
+		if free := FingerprintSize - len(f.head); free > 0 && f.offset == int64(len(f.head)) {
+			f.head = append(f.head, chunk[:min(free, len(chunk))]...)
+		}
+
+		f.offset += int64(len(chunk))
+		f.partial.add(chunk)
+
+		switch {
+		case err == nil:
+			handle(f.partial.take())
+		case errors.Is(err, bufio.ErrBufferFull):
+		case errors.Is(err, io.EOF):
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// Close - закрывает файл.
+func (f *Follower) Close() error {
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+
+	return err
+}
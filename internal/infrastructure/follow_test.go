@@ -0,0 +1,144 @@
+package infrastructure_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/infrastructure"
+)
+
+func appendTo(t *testing.T, path, data string) {
+	t.Helper()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+
+	_, err = file.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+}
+
+func poll(t *testing.T, follower *infrastructure.Follower) []string {
+	t.Helper()
+
+	var lines []string
+
+	require.NoError(t, follower.Poll(func(line infrastructure.Line) { lines = append(lines, line.Text) }))
+
+	return lines
+}
+
+func TestFollower(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	follower := infrastructure.NewFollower(path, 0)
+
+	defer follower.Close()
+
+	assert.Empty(t, poll(t, follower), "file does not exist yet")
+
+	appendTo(t, path, "one\ntw")
+	assert.Equal(t, []string{"one"}, poll(t, follower))
+
+	appendTo(t, path, "o\r\nthree\n")
+	assert.Equal(t, []string{"two", "three"}, poll(t, follower), "partial line waits for newline")
+
+	assert.Empty(t, poll(t, follower))
+}
+
+func TestFollower_Rotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "access.log")
+	follower := infrastructure.NewFollower(path, 0)
+
+	defer follower.Close()
+
+	appendTo(t, path, "one\n")
+	assert.Equal(t, []string{"one"}, poll(t, follower))
+
+	// Строки, дописанные в старый файл после переименования, не теряются
+	require.NoError(t, os.Rename(path, filepath.Join(dir, "access.log.1")))
+	appendTo(t, filepath.Join(dir, "access.log.1"), "two\nlast")
+	assert.Equal(t, []string{"two"}, poll(t, follower), "new file is not created yet")
+
+	appendTo(t, path, "three\n")
+	assert.Equal(t, []string{"last", "three"}, poll(t, follower))
+}
+
+func TestFollower_RotationDrainsOldFile(t *testing.T) {
+	dir := t.TempDir()
+	path, rotated := filepath.Join(dir, "access.log"), filepath.Join(dir, "access.log.1")
+	follower := infrastructure.NewFollower(path, 0)
+
+	defer follower.Close()
+
+	appendTo(t, path, "one\n")
+	assert.Equal(t, []string{"one"}, poll(t, follower))
+
+	// logrotate create: файл переименован, новый создан, а nginx до USR1 еще пишет в старый
+	require.NoError(t, os.Rename(path, rotated))
+	appendTo(t, path, "new\n")
+	appendTo(t, rotated, "two\nlast")
+
+	assert.Equal(t, []string{"two", "last", "new"}, poll(t, follower))
+}
+
+func TestFollower_Truncation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	follower := infrastructure.NewFollower(path, 0)
+
+	defer follower.Close()
+
+	appendTo(t, path, "one\ntwo\n")
+	assert.Equal(t, []string{"one", "two"}, poll(t, follower))
+
+	require.NoError(t, os.Truncate(path, 0))
+	appendTo(t, path, "new\n")
+	assert.Equal(t, []string{"new"}, poll(t, follower))
+}
+
+func TestFollower_TruncationGrownPastOffset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	follower := infrastructure.NewFollower(path, 0)
+
+	defer follower.Close()
+
+	appendTo(t, path, "one\n")
+	assert.Equal(t, []string{"one"}, poll(t, follower))
+
+	// copytruncate, после которого файл успел вырасти больше прочитанного
+	require.NoError(t, os.Truncate(path, 0))
+	appendTo(t, path, "first\nsecond\n")
+	assert.Equal(t, []string{"first", "second"}, poll(t, follower))
+
+	appendTo(t, path, "third\n")
+	assert.Equal(t, []string{"third"}, poll(t, follower), "unchanged head is not a truncation")
+}
+
+func TestFollower_LongLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	follower := infrastructure.NewFollower(path, 8)
+
+	defer follower.Close()
+
+	var lines []infrastructure.Line
+
+	handle := func(line infrastructure.Line) { lines = append(lines, line) }
+
+	// Строка длиннее буфера чтения дописывается за два раза
+	appendTo(t, path, "0123456789"+strings.Repeat("x", 200<<10))
+	require.NoError(t, follower.Poll(handle))
+	assert.Empty(t, lines, "partial line waits for newline")
+
+	appendTo(t, path, "tail\r\nshort\n")
+	require.NoError(t, follower.Poll(handle))
+
+	assert.Equal(t, []infrastructure.Line{
+		{Text: "01234567", Size: 10 + 200<<10 + 6, Truncated: true, Terminated: true},
+		{Text: "short", Size: 6, Terminated: true},
+	}, lines)
+}
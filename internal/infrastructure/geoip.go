@@ -0,0 +1,147 @@
+package infrastructure
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// maxGeoCacheSize - сколько адресов запоминается, кеш сбрасывается при переполнении.
+const maxGeoCacheSize = 16384
+
+// geoRecord - поля записи базы, которые нужны для обогащения. Названия совпадают у GeoLite2 и DB-IP,
+// поэтому одна структура подходит и для баз стран и городов, и для баз ASN.
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	City struct {
+		Names struct {
+			En string `maxminddb:"en"`
+		} `maxminddb:"names"`
+	} `maxminddb:"city"`
+	ASN          uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// geoResult - результат обогащения одного адреса, ASN в виде "AS13335".
+type geoResult struct {
+	country      string
+	city         string
+	asn          string
+	organization string
+}
+
+// GeoIP - обогащение $remote_addr страной, городом и ASN по локальным базам MaxMind (.mmdb), без обращений в сеть.
+// Можно передать несколько баз, например GeoLite2-City и GeoLite2-ASN: непустые поля из следующих баз дополняют
+// найденные в предыдущих. Реализует domain.Enricher, безопасен для одновременного использования из нескольких горутин.
+type GeoIP struct {
+	readers []*maxminddb.Reader
+	mu      sync.RWMutex
+	cache   map[string]geoResult
+}
+
+// OpenGeoIP - открывает базы .mmdb, базы нужно закрыть через Close.
+func OpenGeoIP(paths []string) (*GeoIP, error) {
+	geoIP := &GeoIP{cache: make(map[string]geoResult)}
+
+	for _, path := range paths {
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			geoIP.Close()
+
+			return nil, errors.ErrGeoDatabase{Path: path, Reason: err.Error()}
+		}
+
+		geoIP.readers = append(geoIP.readers, reader)
+	}
+
+	return geoIP, nil
+}
+
+// Fields - поля, которые добавляет Enrich.
+func (g *GeoIP) Fields() []string {
+	return []string{domain.GeoCountry, domain.GeoCity, domain.GeoASN, domain.GeoOrganization}
+}
+
+// Enrich - дописывает в запись страну (ISO код), город, ASN и организацию по $remote_addr. Если адреса нет
+// в базах или он не разбирается, поля остаются пустыми строками.
+func (g *GeoIP) Enrich(entry *domain.LogEntry) {
+	address, ok := entry.Get(domain.RemoteAddr)
+	if !ok {
+		return
+	}
+
+	result := g.lookup(address)
+
+	entry.Set(domain.GeoCountry, result.country)
+	entry.Set(domain.GeoCity, result.city)
+	entry.Set(domain.GeoASN, result.asn)
+	entry.Set(domain.GeoOrganization, result.organization)
+}
+
+func (g *GeoIP) lookup(address string) geoResult {
+	g.mu.RLock()
+	result, ok := g.cache[address]
+	g.mu.RUnlock()
+
+	if ok {
+		return result
+	}
+
+	if ip := net.ParseIP(address); ip != nil {
+		for _, reader := range g.readers {
+			var record geoRecord
+			if err := reader.Lookup(ip, &record); err != nil {
+				continue
+			}
+
+			result.merge(record)
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.cache) >= maxGeoCacheSize {
+		clear(g.cache)
+	}
+
+	g.cache[strings.Clone(address)] = result
+
+	return result
+}
+
+func (r *geoResult) merge(record geoRecord) {
+	if r.country == "" {
+		r.country = record.Country.ISOCode
+	}
+
+	if r.city == "" {
+		r.city = record.City.Names.En
+	}
+
+	if r.asn == "" && record.ASN != 0 {
+		r.asn = "AS" + strconv.FormatUint(uint64(record.ASN), 10)
+		r.organization = record.Organization
+	}
+}
+
+// Close - закрывает базы.
+func (g *GeoIP) Close() error {
+	var closeErr error
+
+	for _, reader := range g.readers {
+		if err := reader.Close(); err != nil {
+			closeErr = errors.ErrCloseFile{}
+		}
+	}
+
+	return closeErr
+}
@@ -0,0 +1,161 @@
+package infrastructure_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/filter"
+	"LogAnalyzer/internal/domain/logformats"
+	"LogAnalyzer/internal/infrastructure"
+)
+
+// writeMMDB - собирает маленькую базу .mmdb во временной папке.
+func writeMMDB(t *testing.T, databaseType string, records map[string]mmdbtype.Map) string {
+	t.Helper()
+
+	tree, err := mmdbwriter.New(mmdbwriter.Options{DatabaseType: databaseType, RecordSize: 24})
+	require.NoError(t, err)
+
+	for cidr, record := range records {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		require.NoError(t, tree.Insert(network, record))
+	}
+
+	path := filepath.Join(t.TempDir(), databaseType+".mmdb")
+
+	file, err := os.Create(path)
+	require.NoError(t, err)
+
+	_, err = tree.WriteTo(file)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	return path
+}
+
+func openFixture(t *testing.T) *infrastructure.GeoIP {
+	t.Helper()
+
+	city := writeMMDB(t, "GeoLite2-City", map[string]mmdbtype.Map{
+		"81.2.69.0/24": {
+			"country": mmdbtype.Map{"iso_code": mmdbtype.String("GB")},
+			"city":    mmdbtype.Map{"names": mmdbtype.Map{"en": mmdbtype.String("London")}},
+		},
+		"2.125.160.0/24": {
+			"country": mmdbtype.Map{"iso_code": mmdbtype.String("DE")},
+		},
+	})
+	asn := writeMMDB(t, "GeoLite2-ASN", map[string]mmdbtype.Map{
+		"81.2.69.0/24": {
+			"autonomous_system_number":       mmdbtype.Uint32(20712),
+			"autonomous_system_organization": mmdbtype.String("Andrews & Arnold Ltd"),
+		},
+	})
+
+	geoIP, err := infrastructure.OpenGeoIP([]string{city, asn})
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, geoIP.Close()) })
+
+	return geoIP
+}
+
+func TestGeoIP_Enrich(t *testing.T) {
+	geoIP := openFixture(t)
+
+	tests := []struct {
+		name    string
+		address string
+		want    map[string]string
+	}{
+		{
+			name:    "city and asn databases are merged",
+			address: "81.2.69.160",
+			want: map[string]string{
+				domain.GeoCountry: "GB", domain.GeoCity: "London", domain.GeoASN: "AS20712", domain.GeoOrganization: "Andrews & Arnold Ltd",
+			},
+		},
+		{
+			name:    "country only",
+			address: "2.125.160.216",
+			want:    map[string]string{domain.GeoCountry: "DE", domain.GeoCity: "", domain.GeoASN: "", domain.GeoOrganization: ""},
+		},
+		{
+			name:    "not in databases",
+			address: "8.8.8.8",
+			want:    map[string]string{domain.GeoCountry: "", domain.GeoCity: "", domain.GeoASN: "", domain.GeoOrganization: ""},
+		},
+		{
+			name:    "not an ip",
+			address: "localhost",
+			want:    map[string]string{domain.GeoCountry: "", domain.GeoCity: "", domain.GeoASN: "", domain.GeoOrganization: ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entry domain.LogEntry
+
+			entry.Set(domain.RemoteAddr, tt.address)
+			geoIP.Enrich(&entry)
+
+			for _, field := range geoIP.Fields() {
+				value, ok := entry.Get(field)
+				assert.True(t, ok, field)
+				assert.Equal(t, tt.want[field], value, field)
+			}
+		})
+	}
+}
+
+func TestGeoIP_InvalidDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o600))
+
+	_, err := infrastructure.OpenGeoIP([]string{path})
+	assert.Error(t, err)
+}
+
+func TestFillGeo(t *testing.T) {
+	parser := logformats.NewCombined()
+	geoIP := openFixture(t)
+
+	// Поля геолокации доступны фильтру
+	geoFilter, err := filter.Parse(`geo_country != DE`, append(parser.Fields(), geoIP.Fields()...))
+	require.NoError(t, err)
+
+	data := domain.NewDataHolder(parser, geoFilter)
+	data.AddEnricher(geoIP)
+
+	logs := []string{
+		`81.2.69.160 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 100 "-" "curl/8.0"`,
+		`81.2.69.161 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 100 "-" "curl/8.0"`,
+		`2.125.160.216 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 100 "-" "curl/8.0"`,
+		`8.8.8.8 - - [17/May/2015:08:01:00 +0000] "GET /a HTTP/1.1" 200 50 "-" "curl/8.0"`,
+	}
+
+	for _, log := range logs {
+		data.Parse(log, time.Time{}, time.Time{})
+	}
+
+	statistic := &domain.Statistic{Top: domain.TopLimits{Sections: map[string]int{domain.TopCountries: 1}}}
+	statistic.Fill(data)
+
+	assert.Equal(t, []domain.GeoTraffic{
+		{Value: "GB", Requests: 2, Bytes: 200, Share: float32(2) / float32(3) * 100},
+		{Requests: 1, Bytes: 50, Share: float32(1) / float32(3) * 100, Other: true},
+	}, statistic.Geo.Countries)
+	assert.Equal(t, []domain.GeoTraffic{
+		{Value: "AS20712", Name: "Andrews & Arnold Ltd", Requests: 2, Bytes: 200, Share: float32(2) / float32(3) * 100},
+		{Value: domain.GeoUnknown, Requests: 1, Bytes: 50, Share: float32(1) / float32(3) * 100},
+	}, statistic.Geo.ASNs)
+}
@@ -0,0 +1,121 @@
+package infrastructure
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// Line - строка лога без перевода строки.
+type Line struct {
+	Text string
+	// Size - сколько байт строка занимает в потоке вместе с переводом строки.
+	Size int64
+	// Truncated - строка длиннее ограничения, в Text только ее начало.
+	Truncated bool
+	// Terminated - строка заканчивается переводом строки, иначе это последняя строка потока.
+	Terminated bool
+}
+
+// LineReader - делит поток на строки так же, как bufio.Scanner, но без ограничения на длину строки: от строки
+// длиннее maxLength в Text остается начало, а остаток пропускается, так что память не растет с длиной строки.
+type LineReader struct {
+	reader *bufio.Reader
+	buf    lineBuffer
+}
+
+// NewLineReader - создает чтение строк из r, maxLength <= 0 - строки не обрезаются.
+func NewLineReader(r io.Reader, maxLength int) *LineReader {
+	return &LineReader{reader: bufio.NewReaderSize(r, readBufferSize), buf: lineBuffer{maxLength: maxLength}}
+}
+
+// Next - следующая строка. В конце потока возвращает io.EOF, ошибку чтения - как есть, строка, прочитанная
+// до ошибки, при этом теряется.
+func (r *LineReader) Next() (Line, error) {
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+		r.buf.add(chunk)
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+
+		if errors.Is(err, io.EOF) && r.buf.empty() {
+			return Line{}, io.EOF
+		}
+
+		if err != nil && !errors.Is(err, io.EOF) {
+			r.buf.reset()
+
+			return Line{}, err
+		}
+
+		return r.buf.take(), nil
+	}
+}
+
+// lineBuffer - собирает строку из кусков, которые отдает bufio.Reader.ReadSlice. Хранится не больше maxLength байт
+// строки, остальные только считаются.
+type lineBuffer struct {
+	maxLength int
+	text      []byte
+	size      int64
+	length    int
+	last      byte
+	// terminated - последний кусок закончился переводом строки.
+	terminated bool
+}
+
+// add - добавляет кусок строки, перевод строки может быть только в конце куска.
+func (b *lineBuffer) add(chunk []byte) {
+	b.size += int64(len(chunk))
+
+	if len(chunk) > 0 && chunk[len(chunk)-1] == '\n' {
+		b.terminated = true
+		chunk = chunk[:len(chunk)-1]
+	}
+
+	if len(chunk) == 0 {
+		return
+	}
+
+	b.length += len(chunk)
+	b.last = chunk[len(chunk)-1]
+
+	if b.maxLength <= 0 {
+		b.text = append(b.text, chunk...)
+	} else if free := b.maxLength - len(b.text); free > 0 {
+		b.text = append(b.text, chunk[:min(free, len(chunk))]...)
+	}
+}
+
+func (b *lineBuffer) empty() bool {
+	return b.size == 0
+}
+
+// take - собранная строка, буфер после этого пуст.
+func (b *lineBuffer) take() Line {
+	text, length := b.text, b.length
+
+	// Как и bufio.Scanner, отбрасываем \r перед переводом строки
+	if b.last == '\r' {
+		length--
+		text = text[:min(len(text), length)]
+	}
+
+	line := Line{
+		Text:       string(text),
+		Size:       b.size,
+		Truncated:  b.maxLength > 0 && length > b.maxLength,
+		Terminated: b.terminated,
+	}
+
+	b.reset()
+
+	return line
+}
+
+func (b *lineBuffer) reset() {
+	b.text = b.text[:0]
+	b.size, b.length, b.last, b.terminated = 0, 0, 0, false
+}
@@ -0,0 +1,97 @@
+package infrastructure_test
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/infrastructure"
+)
+
+// readLines - все строки потока, размер строк в потоке должен сложиться в длину потока.
+func readLines(t *testing.T, content string, maxLength int) []infrastructure.Line {
+	t.Helper()
+
+	var (
+		lines []infrastructure.Line
+		size  int64
+	)
+
+	reader := infrastructure.NewLineReader(strings.NewReader(content), maxLength)
+
+	for {
+		line, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		lines = append(lines, line)
+		size += line.Size
+	}
+
+	assert.Equal(t, int64(len(content)), size)
+
+	return lines
+}
+
+func TestLineReader_MatchesScanner(t *testing.T) {
+	for _, content := range []string{
+		"",
+		"\n",
+		"one\ntwo\n",
+		"one\r\ntwo\r\n\r\nlast without newline\r",
+		"a\n\n\nb",
+		strings.Repeat("x", 200<<10) + "\nshort\n",
+	} {
+		var expected []string
+
+		scanner := bufio.NewScanner(strings.NewReader(content))
+		scanner.Buffer(nil, 1<<20)
+
+		for scanner.Scan() {
+			expected = append(expected, scanner.Text())
+		}
+
+		var actual []string
+
+		for _, line := range readLines(t, content, 0) {
+			assert.False(t, line.Truncated)
+			actual = append(actual, line.Text)
+		}
+
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestLineReader_Truncates(t *testing.T) {
+	long := strings.Repeat("0123456789", 30000)
+	lines := readLines(t, "short\r\n"+long+"\r\n"+"1234567890\r\nexact\n"+long, 10)
+
+	assert.Equal(t, []infrastructure.Line{
+		{Text: "short", Size: 7, Terminated: true},
+		{Text: "0123456789", Size: int64(len(long)) + 2, Truncated: true, Terminated: true},
+		{Text: "1234567890", Size: 12, Terminated: true},
+		{Text: "exact", Size: 6, Terminated: true},
+		{Text: "0123456789", Size: int64(len(long)), Truncated: true},
+	}, lines)
+}
+
+func TestLineReader_ReadError(t *testing.T) {
+	failure := errors.New("disk failure")
+	reader := infrastructure.NewLineReader(io.MultiReader(strings.NewReader("one\n"), iotest.ErrReader(failure)), 0)
+
+	line, err := reader.Next()
+	require.NoError(t, err)
+	assert.Equal(t, "one", line.Text)
+
+	_, err = reader.Next()
+	assert.ErrorIs(t, err, failure)
+}
@@ -0,0 +1,58 @@
+package infrastructure
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+
+	"LogAnalyzer/internal/domain"
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// Quarantine - файл, в который построчно в формате JSON пишутся строки логов, которые не удалось разобрать,
+// вместе с источником, номером строки и причиной. Reject можно вызывать из нескольких горутин.
+type Quarantine struct {
+	mu      sync.Mutex
+	file    *os.File
+	writer  *bufio.Writer
+	encoder *json.Encoder
+	logger  *slog.Logger
+}
+
+func NewQuarantine(path string, logger *slog.Logger) (*Quarantine, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, errors.ErrFileCreation{}
+	}
+
+	writer := bufio.NewWriter(file)
+
+	return &Quarantine{file: file, writer: writer, encoder: json.NewEncoder(writer), logger: logger}, nil
+}
+
+// Reject - дописывает отклоненную строку в файл карантина.
+func (q *Quarantine) Reject(line domain.RejectedLine) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.encoder.Encode(line); err != nil {
+		q.logger.Error("quarantine write error", "error", err)
+	}
+}
+
+// Close - сбрасывает буфер и закрывает файл карантина.
+func (q *Quarantine) Close() error {
+	if err := q.writer.Flush(); err != nil {
+		q.file.Close()
+
+		return errors.ErrFileWrite{}
+	}
+
+	if err := q.file.Close(); err != nil {
+		return errors.ErrCloseFile{}
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	stderrors "errors"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"LogAnalyzer/internal/domain/errors"
+)
+
+// stateVersion - версия формата файла состояния, меняется при несовместимых изменениях.
+const stateVersion = 2
+
+// FingerprintSize - сколько первых байт файла (после распаковки) берется для его отпечатка.
+const FingerprintSize = 1024
+
+// maxMissedRuns - сколько запусков подряд хранится отметка файла, который не нашелся среди источников.
+// Ротированный файл может временно пропасть из шаблона, а если он вернется, его нельзя посчитать заново.
+const maxMissedRuns = 100
+
+// FileCheckpoint - сколько уже прочитано из файла лога. Файл узнается по отпечатку начала содержимого,
+// а не по имени или inode: после ротации access.log становится access.log.1, а затем access.log.2.gz,
+// и во всех случаях продолжается с того же места. Offset - число байт (после распаковки) до конца
+// последней полной строки, Lines - число этих строк.
+type FileCheckpoint struct {
+	Source          string
+	Fingerprint     uint64
+	FingerprintSize int
+	Offset          int64
+	Lines           int
+	// Missed - сколько запусков подряд файл не встречался среди источников.
+	Missed int
+}
+
+// State - файл состояния инкрементального разбора: отметки прочитанного по файлам и накопленные данные.
+// Settings - настройки разбора, с которыми накоплены данные, с другими настройками продолжать нельзя.
+// Resume и Commit можно вызывать из нескольких горутин.
+type State struct {
+	Version  int
+	Settings string
+	Files    []FileCheckpoint
+	Data     []byte
+	path     string
+	mu       sync.Mutex
+	matched  []bool
+	next     []FileCheckpoint
+}
+
+// LoadState - читает файл состояния, если его еще нет, возвращается пустое состояние.
+func LoadState(path, settings string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return &State{Version: stateVersion, Settings: settings, path: path}, nil
+		}
+
+		return nil, errors.ErrStateFile{Path: path, Reason: err.Error()}
+	}
+
+	state := &State{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(state); err != nil {
+		return nil, errors.ErrStateFile{Path: path, Reason: "corrupted: " + err.Error()}
+	}
+
+	if state.Version != stateVersion {
+		return nil, errors.ErrStateFile{Path: path, Reason: "unsupported version, remove it to start over"}
+	}
+
+	if state.Settings != settings {
+		return nil, errors.ErrStateFile{
+			Path:   path,
+			Reason: "saved with other log format, filter or enrichment settings, remove it to start over",
+		}
+	}
+
+	state.path = path
+	state.matched = make([]bool, len(state.Files))
+
+	return state, nil
+}
+
+// Resume - отметка файла, который начинается с head. Для уже встречавшегося файла возвращается
+// сохраненная отметка и true, для нового - нулевая. Отпечаток в отметке обновляется по head.
+func (s *State) Resume(source string, head []byte) (FileCheckpoint, bool) {
+	checkpoint := FileCheckpoint{Source: source, Fingerprint: fingerprint(head), FingerprintSize: len(head)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, saved := range s.Files {
+		// Пока файл был короче FingerprintSize, отпечаток снят с меньшего начала, сравниваем столько же байт
+		if s.matched[i] || saved.FingerprintSize == 0 || saved.FingerprintSize > len(head) ||
+			fingerprint(head[:saved.FingerprintSize]) != saved.Fingerprint {
+			continue
+		}
+
+		s.matched[i] = true
+		checkpoint.Offset, checkpoint.Lines = saved.Offset, saved.Lines
+
+		return checkpoint, true
+	}
+
+	return checkpoint, false
+}
+
+// Commit - запоминает, сколько прочитано из файла в этот запуск. Пустые файлы не запоминаются:
+// по пустому началу нельзя узнать файл.
+func (s *State) Commit(checkpoint FileCheckpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if checkpoint.Offset > 0 && checkpoint.FingerprintSize > 0 {
+		s.next = append(s.next, checkpoint)
+	}
+}
+
+// Missing - отметки файлов прошлого запуска, которые не нашлись среди источников. Строки, дописанные
+// в них после прошлого запуска, не попадут в отчет.
+func (s *State) Missing() []FileCheckpoint {
+	var missing []FileCheckpoint
+
+	for i, saved := range s.Files {
+		if !s.matched[i] {
+			missing = append(missing, saved)
+		}
+	}
+
+	return missing
+}
+
+// Save - записывает отметки этого запуска и данные. Файл заменяется атомарно, поэтому при сбое
+// остается предыдущее состояние, и следующий запуск просто перечитает строки заново.
+func (s *State) Save(data []byte) error {
+	files := s.next
+
+	for _, missing := range s.Missing() {
+		if missing.Missed++; missing.Missed <= maxMissedRuns {
+			files = append(files, missing)
+		}
+	}
+
+	saved := State{Version: stateVersion, Settings: s.Settings, Files: files, Data: data}
+
+	temp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return errors.ErrStateFile{Path: s.path, Reason: err.Error()}
+	}
+
+	defer os.Remove(temp.Name())
+
+	writer := bufio.NewWriter(temp)
+
+	err = gob.NewEncoder(writer).Encode(&saved)
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Rename(temp.Name(), s.path)
+	}
+
+	if err != nil {
+		return errors.ErrStateFile{Path: s.path, Reason: err.Error()}
+	}
+
+	return nil
+}
+
+func fingerprint(head []byte) uint64 {
+	hash := fnv.New64a()
+	hash.Write(head)
+
+	return hash.Sum64()
+}
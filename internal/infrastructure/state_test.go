@@ -0,0 +1,92 @@
+package infrastructure_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/internal/domain/errors"
+	"LogAnalyzer/internal/infrastructure"
+)
+
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.bin")
+
+	state, err := infrastructure.LoadState(path, "logformat=combined")
+	require.NoError(t, err)
+
+	checkpoint, found := state.Resume("access.log", []byte("line one\n"))
+	assert.False(t, found)
+
+	checkpoint.Offset, checkpoint.Lines = 9, 1
+	state.Commit(checkpoint)
+
+	// Пустой файл нельзя узнать в следующий раз, он не запоминается
+	empty, _ := state.Resume("empty.log", nil)
+	state.Commit(empty)
+
+	require.NoError(t, state.Save([]byte("data")))
+
+	state, err = infrastructure.LoadState(path, "logformat=combined")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("data"), state.Data)
+
+	// Файл переименован ротацией и дописан: узнается по началу, хотя стал длиннее отпечатка прошлого запуска
+	checkpoint, found = state.Resume("access.log.1", []byte("line one\nline two\n"))
+	assert.True(t, found)
+	assert.Equal(t, int64(9), checkpoint.Offset)
+	assert.Equal(t, 1, checkpoint.Lines)
+
+	// Одну отметку нельзя продолжить дважды
+	_, found = state.Resume("copy.log", []byte("line one\nline two\n"))
+	assert.False(t, found)
+
+	_, found = state.Resume("access.log", []byte("line"))
+	assert.False(t, found, "new file shorter than the saved fingerprint")
+
+	assert.Empty(t, state.Missing())
+}
+
+func TestState_Missing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.bin")
+
+	state, err := infrastructure.LoadState(path, "")
+	require.NoError(t, err)
+
+	checkpoint, _ := state.Resume("access.log", []byte("line one\n"))
+	checkpoint.Offset = 9
+	state.Commit(checkpoint)
+	require.NoError(t, state.Save(nil))
+
+	// Пропавший файл помнится, чтобы не посчитать его заново, если он вернется
+	state, err = infrastructure.LoadState(path, "")
+	require.NoError(t, err)
+	require.NoError(t, state.Save(nil))
+
+	state, err = infrastructure.LoadState(path, "")
+	require.NoError(t, err)
+
+	missing := state.Missing()
+	require.Len(t, missing, 1)
+	assert.Equal(t, "access.log", missing[0].Source)
+	assert.Equal(t, 1, missing[0].Missed)
+}
+
+func TestLoadState_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.bin")
+
+	state, err := infrastructure.LoadState(path, "logformat=combined")
+	require.NoError(t, err)
+	require.NoError(t, state.Save(nil))
+
+	_, err = infrastructure.LoadState(path, "logformat=main")
+	assert.ErrorAs(t, err, &errors.ErrStateFile{})
+
+	require.NoError(t, os.WriteFile(path, []byte("garbage"), 0o600))
+
+	_, err = infrastructure.LoadState(path, "logformat=combined")
+	assert.ErrorAs(t, err, &errors.ErrStateFile{})
+}
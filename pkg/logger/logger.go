@@ -17,7 +17,8 @@ func NewFileLogger(filePath string) *FileLogger {
 		fmt.Println("Error opening file:", "error", err)
 	}
 
-	fileHandler := slog.NewTextHandler(file, nil)
+	// Лог пишется в файл, поэтому в нем и отладочные сообщения, например скорость разбора
+	fileHandler := slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug})
 	logger := slog.New(fileHandler)
 
 	return &FileLogger{file: file, logger: logger}
@@ -0,0 +1,217 @@
+// Package sketch содержит компактные сливаемые структуры для приблизительной статистики по потоку значений.
+package sketch
+
+import (
+	"errors"
+	"math"
+)
+
+// DefaultRelativeAccuracy - относительная точность квантилей по умолчанию: 1%.
+const DefaultRelativeAccuracy = 0.01
+
+// minIndexableValue - значения меньше этого попадают в нулевую корзину.
+const minIndexableValue = 1e-9
+
+// ErrIncompatibleSketches - попытка слить скетчи с разной точностью, общая для всех скетчей пакета.
+var ErrIncompatibleSketches = errors.New("sketches have different accuracy")
+
+// DDSketch - скетч квантилей с гарантией относительной ошибки (Masson, Rim, Lee. DDSketch, VLDB 2019).
+// Значения раскладываются по корзинам с логарифмическими границами [gamma^(i-1), gamma^i),
+// где gamma = (1+a)/(1-a), поэтому любой квантиль возвращается с относительной ошибкой не больше a,
+// а число корзин растет только логарифмически от диапазона значений: для размеров ответа от 1 байта
+// до 10 ГБ при a = 1% это не больше ~1200 счетчиков. Скетчи с одинаковой точностью сливаются без потери точности.
+// Минимум и максимум хранятся точно. Поддерживаются только неотрицательные значения.
+type DDSketch struct {
+	relativeAccuracy float64
+	logGamma         float64
+	// bins[i] - число значений в корзине с индексом offset+i.
+	bins      []uint64
+	offset    int
+	zeroCount uint64
+	count     uint64
+	min       float64
+	max       float64
+}
+
+// NewDDSketch - создает скетч с относительной точностью relativeAccuracy из интервала (0, 1).
+func NewDDSketch(relativeAccuracy float64) *DDSketch {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		relativeAccuracy = DefaultRelativeAccuracy
+	}
+
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+
+	return &DDSketch{relativeAccuracy: relativeAccuracy, logGamma: math.Log(gamma)}
+}
+
+// RelativeAccuracy - гарантированная относительная ошибка квантилей.
+func (s *DDSketch) RelativeAccuracy() float64 {
+	return s.relativeAccuracy
+}
+
+// Add - добавляет значение, отрицательные значения считаются нулем.
+func (s *DDSketch) Add(value float64) {
+	s.addCount(value, 1)
+}
+
+func (s *DDSketch) addCount(value float64, count uint64) {
+	if value < 0 || math.IsNaN(value) {
+		value = 0
+	}
+
+	if s.count == 0 || value < s.min {
+		s.min = value
+	}
+
+	if s.count == 0 || value > s.max {
+		s.max = value
+	}
+
+	s.count += count
+
+	if value < minIndexableValue {
+		s.zeroCount += count
+
+		return
+	}
+
+	index := s.index(value)
+	s.grow(index)
+	s.bins[index-s.offset] += count
+}
+
+func (s *DDSketch) index(value float64) int {
+	return int(math.Ceil(math.Log(value) / s.logGamma))
+}
+
+// value - представитель корзины, его относительное отклонение от любого значения в корзине не больше точности.
+func (s *DDSketch) value(index int) float64 {
+	return 2 * math.Exp(float64(index)*s.logGamma) / (1 + math.Exp(s.logGamma))
+}
+
+// grow - расширяет слайс корзин так, чтобы в него попадал индекс.
+func (s *DDSketch) grow(index int) {
+	switch {
+	case len(s.bins) == 0:
+		s.bins = make([]uint64, 1, 64)
+		s.offset = index
+	case index < s.offset:
+		grown := make([]uint64, len(s.bins)+s.offset-index, cap(s.bins)+s.offset-index)
+		copy(grown[s.offset-index:], s.bins)
+		s.bins = grown
+		s.offset = index
+	case index >= s.offset+len(s.bins):
+		s.bins = append(s.bins, make([]uint64, index-s.offset-len(s.bins)+1)...)
+	}
+}
+
+// Merge - добавляет в скетч все значения другого скетча.
+func (s *DDSketch) Merge(other *DDSketch) error {
+	if other == nil || other.count == 0 {
+		return nil
+	}
+
+	if other.relativeAccuracy != s.relativeAccuracy {
+		return ErrIncompatibleSketches
+	}
+
+	if s.count == 0 || other.min < s.min {
+		s.min = other.min
+	}
+
+	if s.count == 0 || other.max > s.max {
+		s.max = other.max
+	}
+
+	s.count += other.count
+	s.zeroCount += other.zeroCount
+
+	if len(other.bins) == 0 {
+		return nil
+	}
+
+	s.grow(other.offset)
+	s.grow(other.offset + len(other.bins) - 1)
+
+	for i, count := range other.bins {
+		s.bins[other.offset+i-s.offset] += count
+	}
+
+	return nil
+}
+
+// Count - число добавленных значений.
+func (s *DDSketch) Count() uint64 {
+	return s.count
+}
+
+// Min - точный минимум, 0 если значений нет.
+func (s *DDSketch) Min() float64 {
+	return s.min
+}
+
+// Max - точный максимум, 0 если значений нет.
+func (s *DDSketch) Max() float64 {
+	return s.max
+}
+
+// Quantile - значение квантиля q из [0, 1]. Ранг считается как floor(q*(n-1)) - так же, как перцентили
+// по отсортированному слайсу, результат отличается от точного не больше чем на относительную точность.
+func (s *DDSketch) Quantile(q float64) float64 {
+	if s.count == 0 {
+		return 0
+	}
+
+	q = math.Max(0, math.Min(1, q))
+
+	rank := uint64(math.Floor(q * float64(s.count-1)))
+
+	switch {
+	case rank == 0:
+		return s.min
+	case rank == s.count-1:
+		return s.max
+	case rank < s.zeroCount:
+		return 0
+	}
+
+	seen := s.zeroCount
+
+	for i, count := range s.bins {
+		seen += count
+
+		if seen > rank {
+			return math.Max(s.min, math.Min(s.max, s.value(s.offset+i)))
+		}
+	}
+
+	return s.max
+}
+
+// CountAtMost - сколько значений не больше value. Корзины считаются целиком: корзина входит, если ее представитель
+// не больше value, поэтому граница определяется с относительной ошибкой не больше точности скетча.
+func (s *DDSketch) CountAtMost(value float64) uint64 {
+	switch {
+	case s.count == 0 || value < 0:
+		return 0
+	case value >= s.max:
+		return s.count
+	case value < minIndexableValue:
+		return s.zeroCount
+	}
+
+	count := s.zeroCount
+
+	last := s.index(value)
+	if s.value(last) > value {
+		last--
+	}
+
+	last -= s.offset
+
+	for i := 0; i <= last && i < len(s.bins); i++ {
+		count += s.bins[i]
+	}
+
+	return count
+}
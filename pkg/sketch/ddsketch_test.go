@@ -0,0 +1,135 @@
+package sketch_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/pkg/sketch"
+)
+
+func TestDDSketch_RelativeAccuracy(t *testing.T) {
+	random := rand.New(rand.NewPCG(1, 2))
+	ddSketch := sketch.NewDDSketch(sketch.DefaultRelativeAccuracy)
+	values := make([]float64, 0, 100000)
+
+	// Логнормальное распределение похоже на размеры ответов: много мелких и длинный хвост
+	for range 100000 {
+		value := math.Exp(random.NormFloat64()*2 + 8)
+		values = append(values, value)
+		ddSketch.Add(value)
+	}
+
+	slices.Sort(values)
+
+	for _, q := range []float64{0, 0.5, 0.9, 0.95, 0.99, 0.999, 1} {
+		expected := values[int(q*float64(len(values)-1))]
+		assert.InEpsilon(t, expected, ddSketch.Quantile(q), sketch.DefaultRelativeAccuracy, "q=%v", q)
+	}
+
+	assert.Equal(t, uint64(len(values)), ddSketch.Count())
+	assert.InDelta(t, values[0], ddSketch.Min(), 0)
+	assert.InDelta(t, values[len(values)-1], ddSketch.Max(), 0)
+}
+
+func TestDDSketch_Merge(t *testing.T) {
+	whole := sketch.NewDDSketch(sketch.DefaultRelativeAccuracy)
+	left := sketch.NewDDSketch(sketch.DefaultRelativeAccuracy)
+	right := sketch.NewDDSketch(sketch.DefaultRelativeAccuracy)
+
+	for i := range 1000 {
+		value := float64(i * i)
+		whole.Add(value)
+
+		if i%3 == 0 {
+			left.Add(value)
+		} else {
+			right.Add(value)
+		}
+	}
+
+	require.NoError(t, left.Merge(right))
+
+	// Слитый скетч должен совпадать с построенным по всем значениям сразу
+	assert.Equal(t, whole, left)
+
+	coarse := sketch.NewDDSketch(0.05)
+	coarse.Add(1)
+	assert.ErrorIs(t, left.Merge(coarse), sketch.ErrIncompatibleSketches)
+}
+
+func TestDDSketch_CountAtMost(t *testing.T) {
+	random := rand.New(rand.NewPCG(3, 4))
+	ddSketch := sketch.NewDDSketch(sketch.DefaultRelativeAccuracy)
+	values := []float64{0, 0}
+
+	for range 10000 {
+		values = append(values, math.Exp(random.NormFloat64()*2+8))
+	}
+
+	for _, value := range values {
+		ddSketch.Add(value)
+	}
+
+	atMost := func(limit float64) uint64 {
+		var count uint64
+
+		for _, value := range values {
+			if value <= limit {
+				count++
+			}
+		}
+
+		return count
+	}
+
+	// Граница корзины размыта на относительную точность, поэтому ответ между точными для чуть меньшей
+	// и чуть большей границы
+	for _, limit := range []float64{1, 100, 1000, 3000, 1e4, 1e5} {
+		count := ddSketch.CountAtMost(limit)
+		assert.GreaterOrEqual(t, count, atMost(limit*(1-sketch.DefaultRelativeAccuracy)), "limit=%v", limit)
+		assert.LessOrEqual(t, count, atMost(limit/(1-sketch.DefaultRelativeAccuracy)), "limit=%v", limit)
+	}
+
+	assert.Equal(t, uint64(2), ddSketch.CountAtMost(0))
+	assert.Equal(t, ddSketch.Count(), ddSketch.CountAtMost(ddSketch.Max()))
+	assert.Zero(t, sketch.NewDDSketch(sketch.DefaultRelativeAccuracy).CountAtMost(10))
+}
+
+func TestDDSketch_Empty(t *testing.T) {
+	ddSketch := sketch.NewDDSketch(sketch.DefaultRelativeAccuracy)
+
+	assert.Zero(t, ddSketch.Quantile(0.5))
+	assert.Zero(t, ddSketch.Min())
+	assert.Zero(t, ddSketch.Max())
+
+	ddSketch.Add(0)
+	ddSketch.Add(0)
+	ddSketch.Add(10)
+
+	assert.Zero(t, ddSketch.Quantile(0.5))
+	assert.InDelta(t, 10, ddSketch.Quantile(1), 0)
+}
+
+func TestMoments(t *testing.T) {
+	var left, right sketch.Moments
+
+	// Квадраты не помещаются в uint64, но результат остается точным
+	left.Add(math.MaxUint32 * 16)
+	right.Add(math.MaxUint32 * 16)
+	right.Add(0)
+	left.Merge(right)
+
+	assert.Equal(t, uint64(3), left.Count)
+	assert.InEpsilon(t, float64(math.MaxUint32)*32/3, left.Mean(), 1e-12)
+	assert.InEpsilon(t, float64(math.MaxUint32)*16*math.Sqrt(2)/3, left.StdDev(), 1e-12)
+
+	var empty sketch.Moments
+
+	assert.Zero(t, empty.Mean())
+	assert.Zero(t, empty.StdDev())
+}
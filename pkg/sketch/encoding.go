@@ -0,0 +1,195 @@
+package sketch
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// encodingVersion - версия двоичного представления скетчей, меняется при несовместимых изменениях.
+const encodingVersion = 1
+
+// ErrInvalidEncoding - двоичное представление скетча повреждено или записано другой версией.
+var ErrInvalidEncoding = errors.New("invalid sketch encoding")
+
+// decoder - последовательное чтение полей, первая ошибка запоминается и дальше возвращаются нули.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	value, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = ErrInvalidEncoding
+
+		return 0
+	}
+
+	d.data = d.data[n:]
+
+	return value
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+
+	value, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.err = ErrInvalidEncoding
+
+		return 0
+	}
+
+	d.data = d.data[n:]
+
+	return value
+}
+
+func (d *decoder) float() float64 {
+	return math.Float64frombits(d.uvarint())
+}
+
+func (d *decoder) bytes(n uint64) []byte {
+	if d.err != nil {
+		return nil
+	}
+
+	if n > uint64(len(d.data)) {
+		d.err = ErrInvalidEncoding
+
+		return nil
+	}
+
+	value := d.data[:n]
+	d.data = d.data[n:]
+
+	return value
+}
+
+// finish - ошибка чтения, лишние байты в конце тоже считаются повреждением.
+func (d *decoder) finish() error {
+	if d.err == nil && len(d.data) > 0 {
+		return ErrInvalidEncoding
+	}
+
+	return d.err
+}
+
+// MarshalBinary - двоичное представление скетча для сохранения состояния между запусками.
+func (s *DDSketch) MarshalBinary() ([]byte, error) {
+	data := []byte{encodingVersion}
+	data = binary.AppendUvarint(data, math.Float64bits(s.relativeAccuracy))
+	data = binary.AppendUvarint(data, s.count)
+	data = binary.AppendUvarint(data, s.zeroCount)
+	data = binary.AppendUvarint(data, math.Float64bits(s.min))
+	data = binary.AppendUvarint(data, math.Float64bits(s.max))
+	data = binary.AppendVarint(data, int64(s.offset))
+	data = binary.AppendUvarint(data, uint64(len(s.bins)))
+
+	for _, bin := range s.bins {
+		data = binary.AppendUvarint(data, bin)
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary - восстанавливает скетч из MarshalBinary, прежнее содержимое заменяется.
+func (s *DDSketch) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] != encodingVersion {
+		return ErrInvalidEncoding
+	}
+
+	d := decoder{data: data[1:]}
+	relativeAccuracy := d.float()
+	restored := NewDDSketch(relativeAccuracy)
+	restored.count = d.uvarint()
+	restored.zeroCount = d.uvarint()
+	restored.min = d.float()
+	restored.max = d.float()
+	restored.offset = int(d.varint())
+
+	// Каждая корзина занимает хотя бы байт, так поврежденная длина не приведет к огромной аллокации
+	bins := d.uvarint()
+	if bins > uint64(len(d.data)) {
+		return ErrInvalidEncoding
+	}
+
+	for range bins {
+		restored.bins = append(restored.bins, d.uvarint())
+	}
+
+	if err := d.finish(); err != nil {
+		return err
+	}
+
+	if restored.relativeAccuracy != relativeAccuracy {
+		return ErrInvalidEncoding
+	}
+
+	*s = *restored
+
+	return nil
+}
+
+// MarshalBinary - двоичное представление скетча, разреженный скетч остается разреженным.
+func (h *HyperLogLog) MarshalBinary() ([]byte, error) {
+	data := []byte{encodingVersion, h.precision}
+
+	if h.dense != nil {
+		data = append(data, 1)
+
+		return append(data, h.dense...), nil
+	}
+
+	data = append(data, 0)
+	data = binary.AppendUvarint(data, uint64(len(h.sparse)))
+
+	for _, register := range h.sparse {
+		data = binary.AppendUvarint(data, uint64(register))
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary - восстанавливает скетч из MarshalBinary, прежнее содержимое заменяется.
+func (h *HyperLogLog) UnmarshalBinary(data []byte) error {
+	const header = 3
+
+	if len(data) < header || data[0] != encodingVersion || data[1] < MinPrecision || data[1] > MaxPrecision {
+		return ErrInvalidEncoding
+	}
+
+	restored := HyperLogLog{precision: data[1]}
+	d := decoder{data: data[header:]}
+
+	switch data[2] {
+	case 1:
+		restored.dense = append([]uint8(nil), d.bytes(uint64(restored.registers()))...)
+	case 0:
+		registers := d.uvarint()
+		if registers > uint64(len(d.data)) {
+			return ErrInvalidEncoding
+		}
+
+		for range registers {
+			restored.sparse = append(restored.sparse, uint32(d.uvarint()))
+		}
+	default:
+		return ErrInvalidEncoding
+	}
+
+	if err := d.finish(); err != nil {
+		return err
+	}
+
+	*h = restored
+
+	return nil
+}
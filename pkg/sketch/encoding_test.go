@@ -0,0 +1,55 @@
+package sketch_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/pkg/sketch"
+)
+
+func TestDDSketch_MarshalBinary(t *testing.T) {
+	original := sketch.NewDDSketch(0.02)
+	for i := range 1000 {
+		original.Add(float64(i % 97))
+	}
+
+	data, err := original.MarshalBinary()
+	require.NoError(t, err)
+
+	restored := sketch.NewDDSketch(sketch.DefaultRelativeAccuracy)
+	restored.Add(5)
+	require.NoError(t, restored.UnmarshalBinary(data))
+
+	assert.Equal(t, original, restored)
+
+	// Восстановленный скетч продолжает принимать значения
+	restored.Add(1e6)
+	assert.InEpsilon(t, 1e6, restored.Max(), 1e-9)
+
+	assert.ErrorIs(t, restored.UnmarshalBinary(data[:len(data)-1]), sketch.ErrInvalidEncoding)
+	assert.ErrorIs(t, restored.UnmarshalBinary(nil), sketch.ErrInvalidEncoding)
+}
+
+func TestHyperLogLog_MarshalBinary(t *testing.T) {
+	// 10 значений остаются в разреженном режиме, 10000 переводят скетч в плотный
+	for _, distinct := range []int{0, 10, 10000} {
+		original := sketch.NewHyperLogLog(12)
+		for i := range distinct {
+			original.Add(sketch.Hash(strconv.Itoa(i)))
+		}
+
+		data, err := original.MarshalBinary()
+		require.NoError(t, err)
+
+		restored := sketch.NewHyperLogLog(sketch.DefaultPrecision)
+		require.NoError(t, restored.UnmarshalBinary(data))
+
+		assert.Equal(t, original, restored, "distinct=%d", distinct)
+		assert.Equal(t, original.Estimate(), restored.Estimate())
+
+		assert.ErrorIs(t, restored.UnmarshalBinary(append(data, 0)), sketch.ErrInvalidEncoding)
+	}
+}
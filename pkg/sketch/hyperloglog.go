@@ -0,0 +1,218 @@
+package sketch
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// DefaultPrecision - точность HyperLogLog по умолчанию: 2^14 регистров, стандартная ошибка ~0.8%.
+	DefaultPrecision = 14
+	// MinPrecision и MaxPrecision - допустимые значения точности.
+	MinPrecision = 4
+	MaxPrecision = 18
+)
+
+// FNV-1a, 64 бита.
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// HyperLogLog - оценка числа различных значений (Flajolet и др., 2007) с оценщиком Ertl (2017), который
+// не требует эмпирических таблиц поправок и точен во всем диапазоне от единиц до миллиардов значений.
+// Стандартная ошибка 1.04/sqrt(2^precision). Пока различных значений мало, регистры хранятся разреженно -
+// отсортированным слайсом ненулевых регистров, и скетч занимает 4 байта на значение, а не 2^precision байт.
+// Скетчи с одинаковой точностью сливаются без потери точности, результат не зависит от порядка добавления.
+type HyperLogLog struct {
+	precision uint8
+	// sparse - ненулевые регистры в виде index<<8 | value, отсортированы по индексу. Используется пока dense == nil.
+	sparse []uint32
+	dense  []uint8
+}
+
+// NewHyperLogLog - создает пустой скетч, точность вне [MinPrecision, MaxPrecision] заменяется на DefaultPrecision.
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < MinPrecision || precision > MaxPrecision {
+		precision = DefaultPrecision
+	}
+
+	return &HyperLogLog{precision: precision}
+}
+
+// Hash - хеш строк для Add: FNV-1a с перемешиванием splitmix64, чтобы старшие биты были равномерными.
+// Части разделяются нулевым байтом, поэтому ("ab", "c") и ("a", "bc") дают разные хеши.
+// Хеш не зависит от запуска, поэтому одинаковые логи всегда дают одинаковую оценку.
+func Hash(parts ...string) uint64 {
+	hash := uint64(fnvOffset)
+
+	for i, part := range parts {
+		if i > 0 {
+			// Нулевой байт-разделитель: xor с нулем ничего не меняет, остается умножение.
+			hash *= fnvPrime
+		}
+
+		for j := 0; j < len(part); j++ {
+			hash ^= uint64(part[j])
+			hash *= fnvPrime
+		}
+	}
+
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+
+	return hash
+}
+
+// Add - учитывает значение по его хешу, см. Hash.
+func (h *HyperLogLog) Add(hash uint64) {
+	index := uint32(hash >> (64 - h.precision))
+	rank := uint8(bits.LeadingZeros64(hash<<h.precision|1<<(h.precision-1))) + 1
+
+	h.set(index, rank)
+}
+
+func (h *HyperLogLog) set(index uint32, rank uint8) {
+	if h.dense != nil {
+		h.dense[index] = max(h.dense[index], rank)
+
+		return
+	}
+
+	position := sort.Search(len(h.sparse), func(i int) bool { return h.sparse[i]>>8 >= index })
+
+	switch {
+	case position < len(h.sparse) && h.sparse[position]>>8 == index:
+		if uint8(h.sparse[position]) < rank {
+			h.sparse[position] = index<<8 | uint32(rank)
+		}
+	case len(h.sparse) >= h.registers()/4:
+		h.toDense()
+		h.dense[index] = rank
+	default:
+		h.sparse = append(h.sparse, 0)
+		copy(h.sparse[position+1:], h.sparse[position:])
+		h.sparse[position] = index<<8 | uint32(rank)
+	}
+}
+
+// toDense - переходит к плотному хранению, когда разреженное перестает экономить память.
+func (h *HyperLogLog) toDense() {
+	h.dense = make([]uint8, h.registers())
+
+	for _, register := range h.sparse {
+		h.dense[register>>8] = uint8(register)
+	}
+
+	h.sparse = nil
+}
+
+func (h *HyperLogLog) registers() int {
+	return 1 << h.precision
+}
+
+// Merge - добавляет в скетч все значения другого скетча.
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if other == nil {
+		return nil
+	}
+
+	if other.precision != h.precision {
+		return ErrIncompatibleSketches
+	}
+
+	if other.dense == nil {
+		for _, register := range other.sparse {
+			h.set(register>>8, uint8(register))
+		}
+
+		return nil
+	}
+
+	if h.dense == nil {
+		h.toDense()
+	}
+
+	for i, rank := range other.dense {
+		h.dense[i] = max(h.dense[i], rank)
+	}
+
+	return nil
+}
+
+// Estimate - оценка числа различных значений.
+func (h *HyperLogLog) Estimate() uint64 {
+	registers := h.registers()
+	limit := 64 - int(h.precision)
+	histogram := make([]int, limit+2)
+
+	if h.dense == nil {
+		histogram[0] = registers - len(h.sparse)
+
+		for _, register := range h.sparse {
+			histogram[uint8(register)]++
+		}
+	} else {
+		for _, rank := range h.dense {
+			histogram[rank]++
+		}
+	}
+
+	if histogram[0] == registers {
+		return 0
+	}
+
+	m := float64(registers)
+	z := m * ertlTau(1-float64(histogram[limit+1])/m)
+
+	for k := limit; k >= 1; k-- {
+		z = 0.5 * (z + float64(histogram[k]))
+	}
+
+	z += m * ertlSigma(float64(histogram[0])/m)
+
+	return uint64(math.Round(m * m / (2 * math.Ln2) / z))
+}
+
+// ertlSigma и ertlTau - поправки оценщика Ertl для пустых и переполненных регистров.
+func ertlSigma(x float64) float64 {
+	if x == 1 {
+		return math.Inf(1)
+	}
+
+	y, z := 1.0, x
+
+	for {
+		x *= x
+		previous := z
+		z += x * y
+		y += y
+
+		if z == previous {
+			return z
+		}
+	}
+}
+
+func ertlTau(x float64) float64 {
+	if x == 0 || x == 1 {
+		return 0
+	}
+
+	y, z := 1.0, 1-x
+
+	for {
+		x = math.Sqrt(x)
+		previous := z
+		y *= 0.5
+		z -= (1 - x) * (1 - x) * y
+
+		if z == previous {
+			return z / 3
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package sketch_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"LogAnalyzer/pkg/sketch"
+)
+
+func TestHyperLogLog_Estimate(t *testing.T) {
+	hll := sketch.NewHyperLogLog(sketch.DefaultPrecision)
+	assert.Zero(t, hll.Estimate())
+
+	added := 0
+
+	// Проверяем и разреженный режим, и переход к плотному, и большие значения
+	for _, distinct := range []int{1, 10, 100, 1000, 10000, 100000, 1000000} {
+		for ; added < distinct; added++ {
+			hll.Add(sketch.Hash("10.0." + strconv.Itoa(added)))
+		}
+
+		// Повторы не должны влиять на оценку
+		hll.Add(sketch.Hash("10.0.0"))
+
+		assert.InEpsilon(t, distinct, hll.Estimate(), 0.03, "distinct=%d", distinct)
+	}
+}
+
+func TestHyperLogLog_Merge(t *testing.T) {
+	whole := sketch.NewHyperLogLog(10)
+	left := sketch.NewHyperLogLog(10)
+	right := sketch.NewHyperLogLog(10)
+
+	for i := range 5000 {
+		hash := sketch.Hash(strconv.Itoa(i), "curl/8.0")
+		whole.Add(hash)
+
+		// В левый скетч попадает мало значений, он остается разреженным
+		if i%50 == 0 {
+			left.Add(hash)
+		} else {
+			right.Add(hash)
+		}
+	}
+
+	require.NoError(t, left.Merge(right))
+	assert.Equal(t, whole.Estimate(), left.Estimate())
+
+	require.NoError(t, right.Merge(left))
+	assert.Equal(t, whole.Estimate(), right.Estimate())
+
+	assert.ErrorIs(t, left.Merge(sketch.NewHyperLogLog(12)), sketch.ErrIncompatibleSketches)
+}
+
+func TestHash_SeparatesParts(t *testing.T) {
+	assert.NotEqual(t, sketch.Hash("ab", "c"), sketch.Hash("a", "bc"))
+	assert.Equal(t, sketch.Hash("10.0.0.1", "curl"), sketch.Hash("10.0.0.1", "curl"))
+}
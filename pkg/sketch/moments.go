@@ -0,0 +1,70 @@
+package sketch
+
+import (
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+// Moments - точные сумма и сумма квадратов целых неотрицательных значений. Сумма квадратов хранится в 128 битах,
+// поэтому результат не зависит от порядка добавления и слияния, в отличие от накопления во float64.
+type Moments struct {
+	Count       uint64
+	Sum         uint64
+	SquaresHigh uint64
+	SquaresLow  uint64
+}
+
+// Add - добавляет значение.
+func (m *Moments) Add(value uint64) {
+	m.Count++
+	m.Sum += value
+
+	high, low := bits.Mul64(value, value)
+	m.addSquares(high, low)
+}
+
+// Merge - добавляет моменты другого набора значений.
+func (m *Moments) Merge(other Moments) {
+	m.Count += other.Count
+	m.Sum += other.Sum
+	m.addSquares(other.SquaresHigh, other.SquaresLow)
+}
+
+func (m *Moments) addSquares(high, low uint64) {
+	var carry uint64
+
+	m.SquaresLow, carry = bits.Add64(m.SquaresLow, low, 0)
+	m.SquaresHigh, _ = bits.Add64(m.SquaresHigh, high, carry)
+}
+
+// Mean - среднее значение, 0 если значений нет.
+func (m Moments) Mean() float64 {
+	if m.Count == 0 {
+		return 0
+	}
+
+	return float64(m.Sum) / float64(m.Count)
+}
+
+// StdDev - стандартное отклонение генеральной совокупности: sqrt((n*sum(x^2) - sum(x)^2) / n^2).
+// Числитель считается в целых числах, поэтому не теряет точности на больших значениях.
+func (m Moments) StdDev() float64 {
+	if m.Count == 0 {
+		return 0
+	}
+
+	squares := new(big.Int).Lsh(new(big.Int).SetUint64(m.SquaresHigh), 64)
+	squares.Or(squares, new(big.Int).SetUint64(m.SquaresLow))
+
+	count := new(big.Int).SetUint64(m.Count)
+	sum := new(big.Int).SetUint64(m.Sum)
+
+	numerator := new(big.Int).Mul(count, squares)
+	numerator.Sub(numerator, new(big.Int).Mul(sum, sum))
+
+	denominator := new(big.Int).Mul(count, count)
+	variance, _ := new(big.Float).Quo(new(big.Float).SetInt(numerator), new(big.Float).SetInt(denominator)).Float64()
+
+	return math.Sqrt(variance)
+}